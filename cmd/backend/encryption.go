@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+	"github.com/samber/do/v2"
+)
+
+const (
+	rotateEncryptionKeySubcommand = "rotate-encryption-key"
+
+	defaultReencryptBatchSize = 100
+)
+
+// 先頭の鍵で暗号化し直すので、完了するまで古い鍵も ENCRYPTION_KEYS に残しておく
+func runRotateEncryptionKeyCommand(args []string) {
+	fs := flag.NewFlagSet(rotateEncryptionKeySubcommand, flag.ExitOnError)
+	batchSize := fs.Int("batch-size", defaultReencryptBatchSize, "rows per table to reencrypt in one transaction")
+	_ = fs.Parse(args)
+	if *batchSize <= 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	cfg := mustLoadConfig()
+	initLogger(cfg)
+	repo, err := do.Invoke[repository.Repository](setupDI(cfg))
+	if err != nil {
+		slog.Error("failed to resolve repository", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	total := 0
+	for ctx.Err() == nil {
+		n, err := repo.ReencryptBatch(ctx, *batchSize)
+		if err != nil {
+			slog.Error("encryption key rotation failed", "error", err, "rows", total)
+			os.Exit(1)
+		}
+		if n == 0 {
+			slog.Info("encryption key rotation finished", "rows", total)
+			return
+		}
+		total += n
+		slog.Info("rows reencrypted", "rows", total)
+	}
+	slog.Warn("encryption key rotation interrupted", "rows", total)
+	os.Exit(1)
+}
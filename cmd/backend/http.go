@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	metricsimpl "github.com/foxseedlab/mojiokoshin/external/metrics"
+	"github.com/foxseedlab/mojiokoshin/internal/config"
+	"github.com/foxseedlab/mojiokoshin/internal/health"
+	"github.com/samber/do/v2"
+)
+
+const (
+	httpReadHeaderTimeout = 5 * time.Second
+	httpShutdownTimeout   = 5 * time.Second
+)
+
+// HTTP_ADDR が未設定なら HTTP サーバーは起動しない
+func startHTTPServer(cfg *config.Config, injector do.Injector) context.CancelFunc {
+	if cfg.HTTPAddr == "" {
+		return func() {}
+	}
+	recorder, err := do.Invoke[*metricsimpl.PrometheusRecorder](injector)
+	if err != nil {
+		slog.Error("failed to resolve metrics recorder", "error", err)
+		os.Exit(1)
+	}
+	checker, err := do.Invoke[*health.Checker](injector)
+	if err != nil {
+		slog.Error("failed to resolve health checker", "error", err)
+		os.Exit(1)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", recorder.Handler())
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeHealthReport(w, checker.Liveness())
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		writeHealthReport(w, checker.Readiness(r.Context()))
+	})
+	server := &http.Server{
+		Addr:              cfg.HTTPAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: httpReadHeaderTimeout,
+	}
+	go func() {
+		slog.Info("startup: http server listening", "addr", cfg.HTTPAddr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("http server failed", "error", err)
+		}
+	}()
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			slog.Error("http server shutdown failed", "error", err)
+		}
+	}
+}
+
+func writeHealthReport(w http.ResponseWriter, report health.Report) {
+	w.Header().Set("Content-Type", "application/json")
+	if !report.OK() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		slog.Warn("failed to write health report", "error", err)
+	}
+}
@@ -11,11 +11,15 @@ import (
 	audioimpl "github.com/foxseedlab/mojiokoshin/external/audio"
 	configloader "github.com/foxseedlab/mojiokoshin/external/config"
 	"github.com/foxseedlab/mojiokoshin/external/discord"
+	metricsimpl "github.com/foxseedlab/mojiokoshin/external/metrics"
 	repositoryimpl "github.com/foxseedlab/mojiokoshin/external/repository"
+	summarizerimpl "github.com/foxseedlab/mojiokoshin/external/summarizer"
 	transcriberimpl "github.com/foxseedlab/mojiokoshin/external/transcriber"
+	translatorimpl "github.com/foxseedlab/mojiokoshin/external/translator"
 	webhookimpl "github.com/foxseedlab/mojiokoshin/external/webhook"
 	"github.com/foxseedlab/mojiokoshin/internal/config"
 	discordpkg "github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/health"
 	"github.com/foxseedlab/mojiokoshin/internal/session"
 	"github.com/samber/do/v2"
 )
@@ -23,6 +27,23 @@ import (
 const discordConnectTimeout = 20 * time.Second
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case retranscribeSubcommand:
+			runRetranscribeCommand(os.Args[2:])
+			return
+		case exportUserSubcommand:
+			runExportUserCommand(os.Args[2:])
+			return
+		case eraseUserSubcommand:
+			runEraseUserCommand(os.Args[2:])
+			return
+		case rotateEncryptionKeySubcommand:
+			runRotateEncryptionKeyCommand(os.Args[2:])
+			return
+		}
+	}
+
 	slog.Info("startup: loading configuration")
 	cfg := mustLoadConfig()
 	initLogger(cfg)
@@ -56,25 +77,39 @@ func setupDI(cfg *config.Config) do.Injector {
 	injector := do.New()
 
 	do.ProvideValue(injector, cfg)
+	metricsimpl.RegisterDI(injector)
 	repositoryimpl.RegisterDI(injector)
 	audioimpl.RegisterDI(injector)
 	discord.RegisterDI(injector)
 	transcriberimpl.RegisterDI(injector)
+	translatorimpl.RegisterDI(injector)
+	summarizerimpl.RegisterDI(injector)
 	webhookimpl.RegisterDI(injector)
 	session.RegisterDI(injector)
+	health.RegisterDI(injector)
 
 	return injector
 }
 
 func runBot(cfg *config.Config, injector do.Injector) {
+	stopTracing := startTracing(cfg)
+	defer stopTracing()
+
 	dc, manager := resolveRuntime(injector)
 	defer recoverBotPanic(dc, manager)
 
+	stopHTTP := startHTTPServer(cfg, injector)
+	defer stopHTTP()
+
 	connectDiscordOrExit(dc)
 	configureSlashAndHandlersOrExit(cfg, dc, manager)
 
+	stopJanitor := startRetentionJanitor(manager)
+	defer stopJanitor()
+
 	done := startDiscordRunLoop(dc)
 	waitForShutdown(done)
+	stopJanitor()
 	shutdownAllSessions(manager, session.StopReasonServerClosed)
 	closeDiscord(dc)
 }
@@ -131,7 +166,9 @@ func configureSlashAndHandlersOrExit(cfg *config.Config, dc discordpkg.Client, m
 
 	dc.RegisterVoiceStateUpdateHandler(manager.HandleVoiceStateUpdate)
 	dc.RegisterSlashCommandHandler(manager.HandleSlashCommand)
-	slog.Info("discord handlers registered", "guild_id", cfg.DiscordGuildID, "commands", []string{"mojiokoshi", "mojiokoshi-stop"})
+	dc.RegisterModalSubmitHandler(manager.HandleModalSubmit)
+	dc.RegisterComponentHandler(manager.HandleComponent)
+	slog.Info("discord handlers registered", "guild_id", cfg.DiscordGuildID, "commands", session.SlashCommandNames())
 }
 
 func startDiscordRunLoop(dc discordpkg.Client) <-chan struct{} {
@@ -146,6 +183,19 @@ func startDiscordRunLoop(dc discordpkg.Client) <-chan struct{} {
 	return done
 }
 
+func startRetentionJanitor(manager *session.Manager) context.CancelFunc {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				slog.Error("panic recovered in retention janitor", "panic", recovered)
+			}
+		}()
+		manager.RunRetentionJanitor(ctx)
+	}()
+	return cancel
+}
+
 func waitForShutdown(done <-chan struct{}) {
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
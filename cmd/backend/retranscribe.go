@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/foxseedlab/mojiokoshin/internal/session"
+)
+
+const retranscribeSubcommand = "retranscribe"
+
+func runRetranscribeCommand(args []string) {
+	fs := flag.NewFlagSet(retranscribeSubcommand, flag.ExitOnError)
+	sessionID := fs.String("session-id", "", "ID of the completed session to retranscribe (required)")
+	language := fs.String("language", "", "comma-separated language codes to recognize (defaults to the guild setting or DEFAULT_TRANSCRIBE_LANGUAGE)")
+	model := fs.String("model", "", "speech recognition model (defaults to the configured model)")
+	_ = fs.Parse(args)
+	if *sessionID == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	cfg := mustLoadConfig()
+	initLogger(cfg)
+	injector := setupDI(cfg)
+	dc, manager := resolveRuntime(injector)
+	connectDiscordOrExit(dc)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	result, err := manager.RetranscribeSession(ctx, session.RetranscribeInput{
+		SessionID: *sessionID,
+		Language:  *language,
+		Model:     *model,
+	})
+	stop()
+	closeDiscord(dc)
+	if err != nil {
+		slog.Error("retranscription failed", "error", err, "session_id", *sessionID)
+		os.Exit(1)
+	}
+	slog.Info("retranscription finished", "session_id", result.SessionID, "revision", result.Revision, "segment_count", result.SegmentCount)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	tracingimpl "github.com/foxseedlab/mojiokoshin/external/tracing"
+	"github.com/foxseedlab/mojiokoshin/internal/config"
+)
+
+const tracingShutdownTimeout = 5 * time.Second
+
+func startTracing(cfg *config.Config) func() {
+	shutdown, err := tracingimpl.Setup(context.Background(), cfg)
+	if err != nil {
+		slog.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+	if cfg.OTLPEndpoint != "" {
+		slog.Info("startup: tracing enabled", "otlp_endpoint", cfg.OTLPEndpoint)
+	}
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			slog.Error("tracing shutdown failed", "error", err)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/foxseedlab/mojiokoshin/internal/session"
+	"github.com/samber/do/v2"
+)
+
+const (
+	exportUserSubcommand = "export-user"
+	eraseUserSubcommand  = "erase-user"
+
+	exportFilePerm = 0o600
+)
+
+func runExportUserCommand(args []string) {
+	fs := flag.NewFlagSet(exportUserSubcommand, flag.ExitOnError)
+	userID := fs.String("user-id", "", "Discord user ID to export (required)")
+	out := fs.String("out", "", "path of the ZIP file to write (required)")
+	_ = fs.Parse(args)
+	if *userID == "" || *out == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	manager := mustResolveManager()
+	f, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, exportFilePerm)
+	if err != nil {
+		slog.Error("failed to create export file", "error", err, "path", *out)
+		os.Exit(1)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	err = manager.ExportUserData(ctx, *userID, f)
+	stop()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		slog.Error("user data export failed", "error", err)
+		_ = os.Remove(*out)
+		os.Exit(1)
+	}
+	slog.Info("user data exported", "path", *out)
+}
+
+func runEraseUserCommand(args []string) {
+	fs := flag.NewFlagSet(eraseUserSubcommand, flag.ExitOnError)
+	userID := fs.String("user-id", "", "Discord user ID to erase (required)")
+	mode := fs.String("mode", string(session.ErasureModePseudonymize), "\"pseudonymize\" keeps participation records under a random pseudonym, \"delete\" removes them")
+	_ = fs.Parse(args)
+	if *userID == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	manager := mustResolveManager()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	result, err := manager.EraseUserData(ctx, *userID, session.ErasureMode(*mode))
+	stop()
+	if err != nil {
+		slog.Error("user data erasure failed", "error", err, "mode", *mode)
+		os.Exit(1)
+	}
+	slog.Info("user data erased", "mode", *mode, "pseudonym", result.Pseudonym, "sessions", result.Sessions)
+}
+
+// Discord には接続せず、データベースだけを操作する
+func mustResolveManager() *session.Manager {
+	cfg := mustLoadConfig()
+	initLogger(cfg)
+	manager, err := do.Invoke[*session.Manager](setupDI(cfg))
+	if err != nil {
+		slog.Error("failed to resolve session manager", "error", err)
+		os.Exit(1)
+	}
+	return manager
+}
@@ -2,6 +2,7 @@ package audio
 
 import (
 	"github.com/foxseedlab/mojiokoshin/internal/audio"
+	"github.com/foxseedlab/mojiokoshin/internal/config"
 	"github.com/samber/do/v2"
 )
 
@@ -12,4 +13,8 @@ func RegisterDI(injector do.Injector) {
 	do.ProvideValue(injector, audio.MixerFactory(func() audio.Mixer {
 		return NewOpusMixer()
 	}))
+	do.Provide(injector, func(i do.Injector) (audio.Archive, error) {
+		c := do.MustInvoke[*config.Config](i)
+		return NewFileArchive(c.AudioArchiveDir), nil
+	})
 }
@@ -0,0 +1,118 @@
+package audio
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/foxseedlab/mojiokoshin/internal/audio"
+)
+
+const (
+	archiveFileExt    = ".pcm"
+	archiveDirPerm    = 0o750
+	archiveBufferSize = 64 * 1024
+)
+
+type FileArchive struct {
+	dir string
+}
+
+func NewFileArchive(dir string) audio.Archive {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return &noopArchive{}
+	}
+	return &FileArchive{dir: dir}
+}
+
+func (a *FileArchive) Create(sessionID string) (io.WriteCloser, error) {
+	path, err := a.pathFor(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(a.dir, archiveDirPerm); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &bufferedFile{file: f, w: bufio.NewWriterSize(f, archiveBufferSize)}, nil
+}
+
+func (a *FileArchive) Open(sessionID string) (io.ReadCloser, error) {
+	path, err := a.pathFor(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, audio.ErrArchiveNotFound
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+func (a *FileArchive) Delete(sessionID string) error {
+	path, err := a.pathFor(sessionID)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (a *FileArchive) pathFor(sessionID string) (string, error) {
+	sessionID = strings.TrimSpace(sessionID)
+	if sessionID == "" || filepath.Base(sessionID) != sessionID || strings.HasPrefix(sessionID, ".") {
+		return "", fmt.Errorf("invalid session id for audio archive: %q", sessionID)
+	}
+	return filepath.Join(a.dir, sessionID+archiveFileExt), nil
+}
+
+type bufferedFile struct {
+	file *os.File
+	w    *bufio.Writer
+}
+
+func (b *bufferedFile) Write(p []byte) (int, error) {
+	return b.w.Write(p)
+}
+
+func (b *bufferedFile) Close() error {
+	flushErr := b.w.Flush()
+	closeErr := b.file.Close()
+	if flushErr != nil {
+		return flushErr
+	}
+	return closeErr
+}
+
+type noopArchive struct{}
+
+func (a *noopArchive) Create(_ string) (io.WriteCloser, error) {
+	return nopWriteCloser{}, nil
+}
+
+func (a *noopArchive) Open(_ string) (io.ReadCloser, error) {
+	return nil, audio.ErrArchiveNotFound
+}
+
+func (a *noopArchive) Delete(_ string) error {
+	return nil
+}
+
+type nopWriteCloser struct{}
+
+func (nopWriteCloser) Write(p []byte) (int, error) { return len(p), nil }
+func (nopWriteCloser) Close() error                { return nil }
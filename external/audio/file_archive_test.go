@@ -0,0 +1,88 @@
+package audio
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	internalaudio "github.com/foxseedlab/mojiokoshin/internal/audio"
+)
+
+func TestFileArchive_CreateAndOpen(t *testing.T) {
+	archive := NewFileArchive(t.TempDir())
+
+	w, err := archive.Create("session-1")
+	if err != nil {
+		t.Fatalf("unexpected create error: %v", err)
+	}
+	if _, err := w.Write([]byte{1, 2, 3, 4}); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+
+	r, err := archive.Open("session-1")
+	if err != nil {
+		t.Fatalf("unexpected open error: %v", err)
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	if string(got) != string([]byte{1, 2, 3, 4}) {
+		t.Fatalf("unexpected archive body: %v", got)
+	}
+}
+
+func TestFileArchive_OpenMissingReturnsNotFound(t *testing.T) {
+	archive := NewFileArchive(t.TempDir())
+	if _, err := archive.Open("missing"); !errors.Is(err, internalaudio.ErrArchiveNotFound) {
+		t.Fatalf("expected ErrArchiveNotFound, got %v", err)
+	}
+}
+
+func TestFileArchive_Delete(t *testing.T) {
+	archive := NewFileArchive(t.TempDir())
+	w, err := archive.Create("session-1")
+	if err != nil {
+		t.Fatalf("unexpected create error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+
+	if err := archive.Delete("session-1"); err != nil {
+		t.Fatalf("unexpected delete error: %v", err)
+	}
+	if _, err := archive.Open("session-1"); !errors.Is(err, internalaudio.ErrArchiveNotFound) {
+		t.Fatalf("expected deleted archive to be missing, got %v", err)
+	}
+	if err := archive.Delete("session-1"); err != nil {
+		t.Fatalf("expected deleting a missing archive to succeed, got %v", err)
+	}
+}
+
+func TestFileArchive_RejectsPathTraversal(t *testing.T) {
+	archive := NewFileArchive(t.TempDir())
+	if _, err := archive.Create("../escape"); err == nil {
+		t.Fatal("expected error for path traversal session id")
+	}
+}
+
+func TestFileArchive_EmptyDirDisablesArchive(t *testing.T) {
+	archive := NewFileArchive("")
+	w, err := archive.Create("session-1")
+	if err != nil {
+		t.Fatalf("unexpected create error: %v", err)
+	}
+	if _, err := w.Write([]byte{1}); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	if _, err := archive.Open("session-1"); !errors.Is(err, internalaudio.ErrArchiveNotFound) {
+		t.Fatalf("expected ErrArchiveNotFound when archive is disabled, got %v", err)
+	}
+}
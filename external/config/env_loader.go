@@ -2,28 +2,54 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/caarlos0/env/v11"
 	internalconfig "github.com/foxseedlab/mojiokoshin/internal/config"
 )
 
 type envConfig struct {
-	Env                        string `env:"ENV" envDefault:"production"`
-	DefaultTranscribeLanguage  string `env:"DEFAULT_TRANSCRIBE_LANGUAGE" envDefault:"ja-JP"`
-	MaxTranscribeDurationMin   int    `env:"MAX_TRANSCRIBE_DURATION_MIN" envDefault:"120"`
-	DatabaseURL                string `env:"DATABASE_URL,required"`
-	GoogleCloudProjectID       string `env:"GOOGLE_CLOUD_PROJECT_ID,required"`
-	GoogleCloudCredentialsJSON string `env:"GOOGLE_CLOUD_CREDENTIALS_JSON,required"`
-	GoogleCloudSpeechLocation  string `env:"GOOGLE_CLOUD_SPEECH_LOCATION" envDefault:"asia-northeast1"`
-	GoogleCloudSpeechModel     string `env:"GOOGLE_CLOUD_SPEECH_MODEL" envDefault:"chirp_3"`
-	DiscordToken               string `env:"DISCORD_TOKEN,required"`
-	DiscordGuildID             string `env:"DISCORD_GUILD_ID,required"`
-	DiscordAutoTranscribe      bool   `env:"DISCORD_AUTO_TRANSCRIBE" envDefault:"false"`
-	DiscordAutoTranscribableVC string `env:"DISCORD_AUTO_TRANSCRIBABLE_VC_ID"`
-	DiscordShowPoweredBy       bool   `env:"DISCORD_MESSAGE_SHOW_POWERED_BY" envDefault:"true"`
-	DiscordCountOtherBots      bool   `env:"DISCORD_COUNT_OTHER_BOTS_AS_PARTICIPANTS" envDefault:"false"`
-	TranscriptTimezone         string `env:"TRANSCRIPT_TIMEZONE" envDefault:"Asia/Tokyo"`
-	TranscriptWebhookURL       string `env:"TRANSCRIPT_WEBHOOK_URL"`
+	Env                        string   `env:"ENV" envDefault:"production"`
+	DefaultTranscribeLanguage  string   `env:"DEFAULT_TRANSCRIBE_LANGUAGE" envDefault:"ja-JP"`
+	MaxTranscribeDurationMin   int      `env:"MAX_TRANSCRIBE_DURATION_MIN" envDefault:"120"`
+	DatabaseURL                string   `env:"DATABASE_URL,required"`
+	GoogleCloudProjectID       string   `env:"GOOGLE_CLOUD_PROJECT_ID,required"`
+	GoogleCloudCredentialsJSON string   `env:"GOOGLE_CLOUD_CREDENTIALS_JSON,required"`
+	GoogleCloudSpeechLocation  string   `env:"GOOGLE_CLOUD_SPEECH_LOCATION" envDefault:"asia-northeast1"`
+	GoogleCloudSpeechModel     string   `env:"GOOGLE_CLOUD_SPEECH_MODEL" envDefault:"chirp_3"`
+	DiscordToken               string   `env:"DISCORD_TOKEN,required"`
+	DiscordGuildID             string   `env:"DISCORD_GUILD_ID,required"`
+	DiscordAutoTranscribe      bool     `env:"DISCORD_AUTO_TRANSCRIBE" envDefault:"false"`
+	DiscordAutoTranscribableVC string   `env:"DISCORD_AUTO_TRANSCRIBABLE_VC_ID"`
+	DiscordShowPoweredBy       bool     `env:"DISCORD_MESSAGE_SHOW_POWERED_BY" envDefault:"true"`
+	DiscordCountOtherBots      bool     `env:"DISCORD_COUNT_OTHER_BOTS_AS_PARTICIPANTS" envDefault:"false"`
+	TranscriptTimezone         string   `env:"TRANSCRIPT_TIMEZONE" envDefault:"Asia/Tokyo"`
+	TranscriptWebhookURL       string   `env:"TRANSCRIPT_WEBHOOK_URL"`
+	TranscriptLowConfidence    float64  `env:"TRANSCRIPT_LOW_CONFIDENCE_THRESHOLD" envDefault:"0.5"`
+	AudioArchiveDir            string   `env:"AUDIO_ARCHIVE_DIR"`
+	VADEnabled                 bool     `env:"VAD_ENABLED" envDefault:"true"`
+	VADThresholdDBFS           float64  `env:"VAD_THRESHOLD_DBFS" envDefault:"-45"`
+	VADHangoverMS              int      `env:"VAD_HANGOVER_MS" envDefault:"300"`
+	RedactPhoneNumbers         bool     `env:"REDACT_PHONE_NUMBERS" envDefault:"false"`
+	RedactEmails               bool     `env:"REDACT_EMAILS" envDefault:"false"`
+	RedactCreditCards          bool     `env:"REDACT_CREDIT_CARDS" envDefault:"false"`
+	RedactBannedWords          []string `env:"REDACT_BANNED_WORDS" envSeparator:","`
+	RedactStoreOriginal        bool     `env:"REDACT_STORE_ORIGINAL" envDefault:"false"`
+	TranslationProvider        string   `env:"TRANSLATION_PROVIDER"`
+	TranslationTargetLanguages []string `env:"TRANSLATION_TARGET_LANGUAGES" envSeparator:","`
+	LibreTranslateURL          string   `env:"LIBRETRANSLATE_URL"`
+	LibreTranslateAPIKey       string   `env:"LIBRETRANSLATE_API_KEY"`
+	SummarizerBaseURL          string   `env:"SUMMARIZER_BASE_URL"`
+	SummarizerAPIKey           string   `env:"SUMMARIZER_API_KEY"`
+	SummarizerModel            string   `env:"SUMMARIZER_MODEL"`
+	SummarizerTimeoutSec       int      `env:"SUMMARIZER_TIMEOUT_SEC" envDefault:"120"`
+	RetentionIntervalMin       int      `env:"RETENTION_INTERVAL_MIN" envDefault:"60"`
+	RetentionBatchSize         int      `env:"RETENTION_BATCH_SIZE" envDefault:"100"`
+	EncryptionKeys             []string `env:"ENCRYPTION_KEYS" envSeparator:","`
+	EncryptionKeysFile         string   `env:"ENCRYPTION_KEYS_FILE"`
+	HTTPAddr                   string   `env:"HTTP_ADDR"`
+	OTLPEndpoint               string   `env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
 }
 
 func Load() (*internalconfig.Config, error) {
@@ -31,6 +57,10 @@ func Load() (*internalconfig.Config, error) {
 	if err := env.Parse(&raw); err != nil {
 		return nil, fmt.Errorf("environment variables are invalid or missing: %w", err)
 	}
+	encryptionKeys, err := loadEncryptionKeys(raw)
+	if err != nil {
+		return nil, err
+	}
 
 	cfg := &internalconfig.Config{
 		Env:                        raw.Env,
@@ -48,10 +78,56 @@ func Load() (*internalconfig.Config, error) {
 		DiscordCountOtherBots:      raw.DiscordCountOtherBots,
 		TranscriptTimezone:         raw.TranscriptTimezone,
 		TranscriptWebhookURL:       raw.TranscriptWebhookURL,
+		TranscriptLowConfidence:    raw.TranscriptLowConfidence,
 		DiscordShowPoweredBy:       raw.DiscordShowPoweredBy,
+		AudioArchiveDir:            raw.AudioArchiveDir,
+		VADEnabled:                 raw.VADEnabled,
+		VADThresholdDBFS:           raw.VADThresholdDBFS,
+		VADHangoverMS:              raw.VADHangoverMS,
+		RedactPhoneNumbers:         raw.RedactPhoneNumbers,
+		RedactEmails:               raw.RedactEmails,
+		RedactCreditCards:          raw.RedactCreditCards,
+		RedactBannedWords:          raw.RedactBannedWords,
+		RedactStoreOriginal:        raw.RedactStoreOriginal,
+		TranslationProvider:        raw.TranslationProvider,
+		TranslationTargetLanguages: raw.TranslationTargetLanguages,
+		LibreTranslateURL:          raw.LibreTranslateURL,
+		LibreTranslateAPIKey:       raw.LibreTranslateAPIKey,
+		SummarizerBaseURL:          raw.SummarizerBaseURL,
+		SummarizerAPIKey:           raw.SummarizerAPIKey,
+		SummarizerModel:            raw.SummarizerModel,
+		SummarizerTimeoutSec:       raw.SummarizerTimeoutSec,
+		RetentionIntervalMin:       raw.RetentionIntervalMin,
+		RetentionBatchSize:         raw.RetentionBatchSize,
+		EncryptionKeys:             encryptionKeys,
+		HTTPAddr:                   raw.HTTPAddr,
+		OTLPEndpoint:               raw.OTLPEndpoint,
 	}
 	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
 	return cfg, nil
 }
+
+// 鍵ファイルは1行に1つの鍵を書く。空行と # で始まる行は無視する
+func loadEncryptionKeys(raw envConfig) ([]string, error) {
+	if raw.EncryptionKeysFile == "" {
+		return raw.EncryptionKeys, nil
+	}
+	if len(raw.EncryptionKeys) > 0 {
+		return nil, fmt.Errorf("set only one of ENCRYPTION_KEYS and ENCRYPTION_KEYS_FILE")
+	}
+	body, err := os.ReadFile(raw.EncryptionKeysFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ENCRYPTION_KEYS_FILE: %w", err)
+	}
+	var keys []string
+	for _, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keys = append(keys, line)
+	}
+	return keys, nil
+}
@@ -7,26 +7,51 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
-	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/bwmarrin/discordgo"
 	discordpkg "github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/metrics"
 )
 
 type Client struct {
 	session   *discordgo.Session
 	token     string
 	botUserID string
+	metrics   metrics.Recorder
+	// Gateway の READY / RESUMED で true、切断で false になる
+	gatewayConnected atomic.Bool
 }
 
-func NewClient(token string) discordpkg.Client {
+func NewClient(token string, recorder metrics.Recorder) discordpkg.Client {
 	return &Client{
-		token: token,
+		token:   token,
+		metrics: recorder,
 	}
 }
 
+// observe は失敗した API 呼び出しをメトリクスに記録し、err をそのまま返す
+func (c *Client) observe(guildID, operation string, err error) error {
+	if err != nil {
+		c.metrics.DiscordAPIFailed(guildID, operation)
+	}
+	return err
+}
+
+// キャッシュにないチャンネルや DM のチャンネルは空を返す
+func (c *Client) channelGuildID(channelID string) string {
+	if c.session == nil || c.session.State == nil {
+		return ""
+	}
+	ch, err := c.session.State.Channel(channelID)
+	if err != nil {
+		return ""
+	}
+	return ch.GuildID
+}
+
 func (c *Client) Connect(ctx context.Context) error {
 	_ = ctx
 	s, err := discordgo.New("Bot " + c.token)
@@ -36,6 +61,9 @@ func (c *Client) Connect(ctx context.Context) error {
 	c.session = s
 	s.Identify.Intents = discordgo.MakeIntent(discordgo.IntentsGuildVoiceStates)
 	s.State.TrackVoice = true
+	s.AddHandler(func(_ *discordgo.Session, _ *discordgo.Ready) { c.gatewayConnected.Store(true) })
+	s.AddHandler(func(_ *discordgo.Session, _ *discordgo.Resumed) { c.gatewayConnected.Store(true) })
+	s.AddHandler(func(_ *discordgo.Session, _ *discordgo.Disconnect) { c.gatewayConnected.Store(false) })
 	if err := s.Open(); err != nil {
 		return err
 	}
@@ -47,7 +75,12 @@ func (c *Client) Connect(ctx context.Context) error {
 	return nil
 }
 
+func (c *Client) GatewayConnected() bool {
+	return c.gatewayConnected.Load()
+}
+
 func (c *Client) Close() error {
+	c.gatewayConnected.Store(false)
 	if c.session != nil {
 		return c.session.Close()
 	}
@@ -57,14 +90,22 @@ func (c *Client) Close() error {
 func (c *Client) JoinVoiceChannel(guildID, channelID string) (discordpkg.VoiceConnection, error) {
 	vc, err := c.session.ChannelVoiceJoin(guildID, channelID, false, false)
 	if err != nil {
-		return nil, err
+		return nil, c.observe(guildID, "join_voice", err)
 	}
 	return &voiceConnectionImpl{vc: vc}, nil
 }
 
-func (c *Client) SendChannelMessage(channelID, content string) error {
-	_, err := c.session.ChannelMessageSend(channelID, content)
-	return err
+func (c *Client) SendChannelMessage(channelID, content string) (string, error) {
+	msg, err := c.session.ChannelMessageSend(channelID, content)
+	if err != nil {
+		return "", c.observe(c.channelGuildID(channelID), "send_message", err)
+	}
+	return msg.ID, nil
+}
+
+func (c *Client) EditChannelMessage(channelID, messageID, content string) error {
+	_, err := c.session.ChannelMessageEdit(channelID, messageID, content)
+	return c.observe(c.channelGuildID(channelID), "edit_message", err)
 }
 
 func (c *Client) SendChannelMessageWithFile(msg discordpkg.FileMessage) error {
@@ -73,8 +114,89 @@ func (c *Client) SendChannelMessageWithFile(msg discordpkg.FileMessage) error {
 		Files: []*discordgo.File{
 			{Name: msg.Filename, ContentType: "text/plain", Reader: bytes.NewReader(msg.FileBody)},
 		},
+		Embeds:     toDiscordgoEmbeds(msg.Embeds),
+		Components: toDiscordgoComponents(msg.Buttons),
 	})
-	return err
+	return c.observe(c.channelGuildID(msg.ChannelID), "send_file", err)
+}
+
+func (c *Client) SendDirectMessageWithFile(userID string, msg discordpkg.FileMessage) error {
+	channel, err := c.session.UserChannelCreate(userID)
+	if err != nil {
+		return c.observe("", "create_dm_channel", err)
+	}
+	msg.ChannelID = channel.ID
+	return c.SendChannelMessageWithFile(msg)
+}
+
+func (c *Client) SendChannelEmbedMessage(msg discordpkg.EmbedMessage) (string, error) {
+	sent, err := c.session.ChannelMessageSendComplex(msg.ChannelID, &discordgo.MessageSend{
+		Content:    msg.Content,
+		Embeds:     toDiscordgoEmbeds(msg.Embeds),
+		Components: toDiscordgoComponents(msg.Buttons),
+	})
+	if err != nil {
+		return "", c.observe(c.channelGuildID(msg.ChannelID), "send_embed", err)
+	}
+	return sent.ID, nil
+}
+
+func (c *Client) EditChannelEmbedMessage(messageID string, msg discordpkg.EmbedMessage) error {
+	embeds := toDiscordgoEmbeds(msg.Embeds)
+	components := toDiscordgoComponents(msg.Buttons)
+	if components == nil {
+		components = []discordgo.MessageComponent{}
+	}
+	_, err := c.session.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         messageID,
+		Channel:    msg.ChannelID,
+		Embeds:     &embeds,
+		Components: &components,
+	})
+	return c.observe(c.channelGuildID(msg.ChannelID), "edit_embed", err)
+}
+
+func toDiscordgoComponents(buttons []discordpkg.Button) []discordgo.MessageComponent {
+	if len(buttons) == 0 {
+		return nil
+	}
+	row := discordgo.ActionsRow{Components: make([]discordgo.MessageComponent, 0, len(buttons))}
+	for _, b := range buttons {
+		row.Components = append(row.Components, discordgo.Button{
+			CustomID: b.CustomID,
+			Label:    b.Label,
+			Style:    toDiscordgoButtonStyle(b.Style),
+			Disabled: b.Disabled,
+		})
+	}
+	return []discordgo.MessageComponent{row}
+}
+
+func toDiscordgoButtonStyle(style discordpkg.ButtonStyle) discordgo.ButtonStyle {
+	switch style {
+	case discordpkg.ButtonStylePrimary:
+		return discordgo.PrimaryButton
+	case discordpkg.ButtonStyleDanger:
+		return discordgo.DangerButton
+	default:
+		return discordgo.SecondaryButton
+	}
+}
+
+func toDiscordgoEmbeds(embeds []discordpkg.Embed) []*discordgo.MessageEmbed {
+	out := make([]*discordgo.MessageEmbed, 0, len(embeds))
+	for _, e := range embeds {
+		fields := make([]*discordgo.MessageEmbedField, 0, len(e.Fields))
+		for _, f := range e.Fields {
+			fields = append(fields, &discordgo.MessageEmbedField{Name: f.Name, Value: f.Value, Inline: f.Inline})
+		}
+		embed := &discordgo.MessageEmbed{Title: e.Title, Description: e.Description, Color: e.Color, Fields: fields}
+		if e.Footer != "" {
+			embed.Footer = &discordgo.MessageEmbedFooter{Text: e.Footer}
+		}
+		out = append(out, embed)
+	}
+	return out
 }
 
 func (c *Client) RegisterVoiceStateUpdateHandler(handler func(discordpkg.VoiceStateEvent)) {
@@ -112,36 +234,203 @@ func (c *Client) RegisterSlashCommandHandler(handler func(discordpkg.SlashComman
 		if data.Name == "" {
 			return
 		}
-		userID := ""
-		if ic.Member != nil && ic.Member.User != nil {
-			userID = ic.Member.User.ID
-		}
-		if userID == "" && ic.User != nil {
-			userID = ic.User.ID
-		}
+		userID := interactionUserID(ic)
 		if userID == "" {
 			return
 		}
 		slog.Info("slash command interaction received", "guild_id", ic.GuildID, "channel_id", ic.ChannelID, "command", data.Name, "user_id", userID)
+		targetMessageID := ""
+		if data.CommandType == discordgo.MessageApplicationCommand {
+			targetMessageID = data.TargetID
+		}
 		handler(discordpkg.SlashCommandEvent{
-			GuildID:     ic.GuildID,
-			ChannelID:   ic.ChannelID,
-			CommandName: data.Name,
-			UserID:      userID,
-			RespondEphemeral: func(content string) error {
-				slog.Info("responding to slash interaction", "command", data.Name, "guild_id", ic.GuildID, "channel_id", ic.ChannelID, "user_id", userID)
+			GuildID:          ic.GuildID,
+			ChannelID:        ic.ChannelID,
+			CommandName:      data.Name,
+			UserID:           userID,
+			RoleIDs:          interactionRoleIDs(ic),
+			CanManageGuild:   interactionCanManageGuild(ic),
+			Options:          slashCommandOptionValues(data.Options),
+			TargetMessageID:  targetMessageID,
+			RespondEphemeral: ephemeralResponder(s, ic, data.Name, userID),
+			RespondModal: func(modal discordpkg.Modal) error {
+				slog.Info("responding to slash interaction with modal", "command", data.Name, "modal", modal.CustomID, "guild_id", ic.GuildID, "channel_id", ic.ChannelID, "user_id", userID)
 				return s.InteractionRespond(ic.Interaction, &discordgo.InteractionResponse{
-					Type: discordgo.InteractionResponseChannelMessageWithSource,
-					Data: &discordgo.InteractionResponseData{
-						Content: content,
-						Flags:   discordgo.MessageFlagsEphemeral,
-					},
+					Type: discordgo.InteractionResponseModal,
+					Data: modalResponseData(modal),
 				})
 			},
 		})
 	})
 }
 
+func (c *Client) RegisterModalSubmitHandler(handler func(discordpkg.ModalSubmitEvent)) {
+	c.session.AddHandler(func(s *discordgo.Session, ic *discordgo.InteractionCreate) {
+		if ic == nil || ic.Type != discordgo.InteractionModalSubmit {
+			return
+		}
+		data := ic.ModalSubmitData()
+		userID := interactionUserID(ic)
+		if data.CustomID == "" || userID == "" {
+			return
+		}
+		slog.Info("modal submit interaction received", "guild_id", ic.GuildID, "channel_id", ic.ChannelID, "modal", data.CustomID, "user_id", userID)
+		handler(discordpkg.ModalSubmitEvent{
+			GuildID:          ic.GuildID,
+			ChannelID:        ic.ChannelID,
+			CustomID:         data.CustomID,
+			UserID:           userID,
+			Values:           modalTextInputValues(data.Components),
+			RespondEphemeral: ephemeralResponder(s, ic, data.CustomID, userID),
+		})
+	})
+}
+
+func (c *Client) RegisterComponentHandler(handler func(discordpkg.ComponentEvent)) {
+	c.session.AddHandler(func(s *discordgo.Session, ic *discordgo.InteractionCreate) {
+		if ic == nil || ic.Type != discordgo.InteractionMessageComponent {
+			return
+		}
+		data := ic.MessageComponentData()
+		userID := interactionUserID(ic)
+		if data.CustomID == "" || userID == "" {
+			return
+		}
+		messageID := ""
+		if ic.Message != nil {
+			messageID = ic.Message.ID
+		}
+		slog.Info("component interaction received", "guild_id", ic.GuildID, "channel_id", ic.ChannelID, "component", data.CustomID, "user_id", userID)
+		handler(discordpkg.ComponentEvent{
+			GuildID:              ic.GuildID,
+			ChannelID:            ic.ChannelID,
+			MessageID:            messageID,
+			CustomID:             data.CustomID,
+			UserID:               userID,
+			RoleIDs:              interactionRoleIDs(ic),
+			CanManageGuild:       interactionCanManageGuild(ic),
+			Values:               data.Values,
+			RespondEphemeral:     ephemeralResponder(s, ic, data.CustomID, userID),
+			RespondEphemeralFile: ephemeralFileResponder(s, ic, data.CustomID, userID),
+		})
+	})
+}
+
+func interactionUserID(ic *discordgo.InteractionCreate) string {
+	if ic.Member != nil && ic.Member.User != nil {
+		return ic.Member.User.ID
+	}
+	if ic.User != nil {
+		return ic.User.ID
+	}
+	return ""
+}
+
+// DM での操作にはメンバー情報がないため、ロールも権限もないものとして扱う
+func interactionRoleIDs(ic *discordgo.InteractionCreate) []string {
+	if ic.Member == nil {
+		return nil
+	}
+	return ic.Member.Roles
+}
+
+func interactionCanManageGuild(ic *discordgo.InteractionCreate) bool {
+	if ic.Member == nil {
+		return false
+	}
+	return ic.Member.Permissions&(discordgo.PermissionAdministrator|discordgo.PermissionManageGuild) != 0
+}
+
+func ephemeralResponder(s *discordgo.Session, ic *discordgo.InteractionCreate, name, userID string) func(content string) error {
+	return func(content string) error {
+		slog.Info("responding to interaction", "name", name, "guild_id", ic.GuildID, "channel_id", ic.ChannelID, "user_id", userID)
+		return s.InteractionRespond(ic.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+}
+
+func ephemeralFileResponder(s *discordgo.Session, ic *discordgo.InteractionCreate, name, userID string) func(msg discordpkg.FileMessage) error {
+	return func(msg discordpkg.FileMessage) error {
+		slog.Info("responding to interaction with file", "name", name, "guild_id", ic.GuildID, "channel_id", ic.ChannelID, "user_id", userID, "filename", msg.Filename)
+		return s.InteractionRespond(ic.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: msg.Content,
+				Files: []*discordgo.File{
+					{Name: msg.Filename, ContentType: "text/plain", Reader: bytes.NewReader(msg.FileBody)},
+				},
+				Flags: discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+}
+
+func modalResponseData(modal discordpkg.Modal) *discordgo.InteractionResponseData {
+	rows := make([]discordgo.MessageComponent, 0, len(modal.Inputs))
+	for _, input := range modal.Inputs {
+		required := input.Required
+		rows = append(rows, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:  input.CustomID,
+					Label:     input.Label,
+					Style:     discordgo.TextInputParagraph,
+					Value:     input.Value,
+					Required:  &required,
+					MaxLength: input.MaxLength,
+				},
+			},
+		})
+	}
+	return &discordgo.InteractionResponseData{
+		CustomID:   modal.CustomID,
+		Title:      modal.Title,
+		Components: rows,
+	}
+}
+
+func modalTextInputValues(components []discordgo.MessageComponent) map[string]string {
+	values := make(map[string]string)
+	collectTextInputValues(components, values)
+	return values
+}
+
+func collectTextInputValues(components []discordgo.MessageComponent, values map[string]string) {
+	for _, component := range components {
+		switch c := component.(type) {
+		case *discordgo.ActionsRow:
+			collectTextInputValues(c.Components, values)
+		case *discordgo.Label:
+			collectTextInputValues([]discordgo.MessageComponent{c.Component}, values)
+		case *discordgo.TextInput:
+			if c.CustomID != "" {
+				values[c.CustomID] = c.Value
+			}
+		}
+	}
+}
+
+func slashCommandOptionValues(options []*discordgo.ApplicationCommandInteractionDataOption) map[string]string {
+	values := make(map[string]string, len(options))
+	for _, opt := range options {
+		if opt == nil || opt.Name == "" {
+			continue
+		}
+		switch opt.Type {
+		case discordgo.ApplicationCommandOptionString:
+			values[opt.Name] = opt.StringValue()
+		default:
+			values[opt.Name] = fmt.Sprint(opt.Value)
+		}
+	}
+	return values
+}
+
 func (c *Client) UpsertGuildSlashCommands(guildID string, defs []discordpkg.SlashCommandDefinition) error {
 	appID := c.applicationID()
 	if appID == "" {
@@ -149,7 +438,7 @@ func (c *Client) UpsertGuildSlashCommands(guildID string, defs []discordpkg.Slas
 	}
 	existing, err := c.session.ApplicationCommands(appID, guildID)
 	if err != nil {
-		return err
+		return c.observe(guildID, "list_commands", err)
 	}
 	existingByName := make(map[string]*discordgo.ApplicationCommand, len(existing))
 	for _, cmd := range existing {
@@ -171,19 +460,98 @@ func (c *Client) upsertGuildSlashCommand(appID, guildID string, def discordpkg.S
 		return nil
 	}
 	payload := &discordgo.ApplicationCommand{
+		Type:        applicationCommandType(def.Type),
 		Name:        def.Name,
 		Description: def.Description,
+		Options:     applicationCommandOptions(def.Options),
 	}
 	cmd, ok := existingByName[def.Name]
 	if !ok {
 		_, err := c.session.ApplicationCommandCreate(appID, guildID, payload)
-		return err
+		return c.observe(guildID, "create_command", err)
 	}
-	if cmd.Description == def.Description {
+	if applicationCommandUpToDate(cmd, payload) {
 		return nil
 	}
 	_, err := c.session.ApplicationCommandEdit(appID, guildID, cmd.ID, payload)
-	return err
+	return c.observe(guildID, "edit_command", err)
+}
+
+func applicationCommandOptions(defs []discordpkg.SlashCommandOption) []*discordgo.ApplicationCommandOption {
+	if len(defs) == 0 {
+		return nil
+	}
+	options := make([]*discordgo.ApplicationCommandOption, 0, len(defs))
+	for _, def := range defs {
+		options = append(options, &discordgo.ApplicationCommandOption{
+			Type:        applicationCommandOptionType(def.Type),
+			Name:        def.Name,
+			Description: def.Description,
+			Required:    def.Required,
+			Choices:     applicationCommandOptionChoices(def.Choices),
+		})
+	}
+	return options
+}
+
+func applicationCommandOptionChoices(defs []discordpkg.SlashCommandOptionChoice) []*discordgo.ApplicationCommandOptionChoice {
+	if len(defs) == 0 {
+		return nil
+	}
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(defs))
+	for _, def := range defs {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: def.Name, Value: def.Value})
+	}
+	return choices
+}
+
+func applicationCommandType(t discordpkg.SlashCommandType) discordgo.ApplicationCommandType {
+	switch t {
+	case discordpkg.SlashCommandTypeMessage:
+		return discordgo.MessageApplicationCommand
+	default:
+		return discordgo.ChatApplicationCommand
+	}
+}
+
+func applicationCommandOptionType(t discordpkg.SlashCommandOptionType) discordgo.ApplicationCommandOptionType {
+	switch t {
+	case discordpkg.SlashCommandOptionString:
+		return discordgo.ApplicationCommandOptionString
+	default:
+		return discordgo.ApplicationCommandOptionString
+	}
+}
+
+func applicationCommandUpToDate(existing, desired *discordgo.ApplicationCommand) bool {
+	if existing.Type != desired.Type || existing.Description != desired.Description || len(existing.Options) != len(desired.Options) {
+		return false
+	}
+	for i, want := range desired.Options {
+		got := existing.Options[i]
+		if got == nil ||
+			got.Type != want.Type ||
+			got.Name != want.Name ||
+			got.Description != want.Description ||
+			got.Required != want.Required ||
+			!applicationCommandChoicesEqual(got.Choices, want.Choices) {
+			return false
+		}
+	}
+	return true
+}
+
+func applicationCommandChoicesEqual(existing, desired []*discordgo.ApplicationCommandOptionChoice) bool {
+	if len(existing) != len(desired) {
+		return false
+	}
+	for i, want := range desired {
+		got := existing[i]
+		if got == nil || got.Name != want.Name || fmt.Sprint(got.Value) != fmt.Sprint(want.Value) {
+			return false
+		}
+	}
+	return true
 }
 
 func (c *Client) GetUserVoiceChannelID(guildID, userID string) (string, error) {
@@ -223,7 +591,7 @@ func (c *Client) voiceChannelIDFromREST(guildID, userID string) (string, error)
 		if isRESTNotFound(err) {
 			return "", nil
 		}
-		return "", err
+		return "", c.observe(guildID, "get_voice_state", err)
 	}
 	if vs == nil {
 		return "", nil
@@ -518,7 +886,7 @@ func (v *voiceConnectionImpl) ReceiveAudio(callback func(userID string, opusPCM
 		userID := ssrcToUser[p.SSRC]
 		mu.RUnlock()
 		if userID == "" {
-			userID = strconv.FormatUint(uint64(p.SSRC), 10)
+			userID = discordpkg.UnidentifiedSpeakerID(p.SSRC)
 		}
 		callback(userID, p.Opus)
 	}
@@ -7,6 +7,8 @@ import (
 	"testing"
 
 	"github.com/bwmarrin/discordgo"
+	discordpkg "github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/metrics"
 )
 
 type roundTripFunc func(req *http.Request) (*http.Response, error)
@@ -41,7 +43,7 @@ func TestGetUserVoiceChannelID_UsesStateCacheFirst(t *testing.T) {
 		t.Fatalf("failed to add guild to state: %v", err)
 	}
 
-	c := &Client{session: s}
+	c := &Client{session: s, metrics: metrics.Nop}
 	channelID, err := c.GetUserVoiceChannelID("guild-1", "user-1")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -66,7 +68,7 @@ func TestGetUserVoiceChannelID_FallsBackToRESTWhenStateIsCold(t *testing.T) {
 		}, nil
 	})
 
-	c := &Client{session: s}
+	c := &Client{session: s, metrics: metrics.Nop}
 	channelID, err := c.GetUserVoiceChannelID("guild-1", "user-1")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -86,7 +88,7 @@ func TestGetUserVoiceChannelID_ReturnsEmptyOnRESTNotFound(t *testing.T) {
 		}, nil
 	})
 
-	c := &Client{session: s}
+	c := &Client{session: s, metrics: metrics.Nop}
 	channelID, err := c.GetUserVoiceChannelID("guild-1", "user-1")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -95,3 +97,167 @@ func TestGetUserVoiceChannelID_ReturnsEmptyOnRESTNotFound(t *testing.T) {
 		t.Fatalf("expected empty channel id, got %q", channelID)
 	}
 }
+
+func TestApplicationCommandUpToDate_DetectsOptionChanges(t *testing.T) {
+	desired := &discordgo.ApplicationCommand{
+		Name:        "mojiokoshi-retranscribe",
+		Description: "retranscribe",
+		Options: applicationCommandOptions([]discordpkg.SlashCommandOption{
+			{Name: "session_id", Description: "session", Type: discordpkg.SlashCommandOptionString, Required: true},
+		}),
+	}
+	existing := &discordgo.ApplicationCommand{
+		Name:        "mojiokoshi-retranscribe",
+		Description: "retranscribe",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "session_id", Description: "session", Required: true},
+		},
+	}
+	if !applicationCommandUpToDate(existing, desired) {
+		t.Fatal("expected identical command to be up to date")
+	}
+
+	existing.Options[0].Required = false
+	if applicationCommandUpToDate(existing, desired) {
+		t.Fatal("expected option requirement change to require an edit")
+	}
+
+	existing.Options = nil
+	if applicationCommandUpToDate(existing, desired) {
+		t.Fatal("expected missing options to require an edit")
+	}
+}
+
+func TestSlashCommandOptionValues(t *testing.T) {
+	values := slashCommandOptionValues([]*discordgo.ApplicationCommandInteractionDataOption{
+		{Name: "session_id", Type: discordgo.ApplicationCommandOptionString, Value: "session-1"},
+		nil,
+	})
+	if values["session_id"] != "session-1" {
+		t.Fatalf("unexpected option values: %+v", values)
+	}
+}
+
+func TestModalTextInputValues_ReadsActionRowsAndLabels(t *testing.T) {
+	values := modalTextInputValues([]discordgo.MessageComponent{
+		&discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			&discordgo.TextInput{CustomID: "content", Value: "corrected"},
+		}},
+		&discordgo.Label{Component: &discordgo.TextInput{CustomID: "note", Value: "typo"}},
+	})
+	if values["content"] != "corrected" || values["note"] != "typo" {
+		t.Fatalf("unexpected values: %+v", values)
+	}
+}
+
+func TestApplicationCommandType_MessageCommand(t *testing.T) {
+	if got := applicationCommandType(discordpkg.SlashCommandTypeMessage); got != discordgo.MessageApplicationCommand {
+		t.Fatalf("unexpected message command type: %v", got)
+	}
+	if got := applicationCommandType(discordpkg.SlashCommandTypeChatInput); got != discordgo.ChatApplicationCommand {
+		t.Fatalf("unexpected chat input command type: %v", got)
+	}
+}
+
+func TestApplicationCommandUpToDate_DetectsChoiceChanges(t *testing.T) {
+	desired := &discordgo.ApplicationCommand{
+		Name:        "mojiokoshi",
+		Description: "start",
+		Options: applicationCommandOptions([]discordpkg.SlashCommandOption{
+			{Name: "vad", Description: "vad", Type: discordpkg.SlashCommandOptionString, Choices: []discordpkg.SlashCommandOptionChoice{{Name: "on", Value: "on"}, {Name: "off", Value: "off"}}},
+		}),
+	}
+	existing := &discordgo.ApplicationCommand{
+		Name:        "mojiokoshi",
+		Description: "start",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "vad", Description: "vad", Choices: []*discordgo.ApplicationCommandOptionChoice{{Name: "on", Value: "on"}, {Name: "off", Value: "off"}}},
+		},
+	}
+	if !applicationCommandUpToDate(existing, desired) {
+		t.Fatal("expected identical choices to be up to date")
+	}
+
+	existing.Options[0].Choices = existing.Options[0].Choices[:1]
+	if applicationCommandUpToDate(existing, desired) {
+		t.Fatal("expected removed choice to require an edit")
+	}
+}
+
+func TestToDiscordgoComponents_PutsButtonsInOneRow(t *testing.T) {
+	if got := toDiscordgoComponents(nil); got != nil {
+		t.Fatalf("expected no components, got %+v", got)
+	}
+	components := toDiscordgoComponents([]discordpkg.Button{
+		{CustomID: "stop:1", Label: "Stop", Style: discordpkg.ButtonStyleDanger},
+		{CustomID: "download:1", Label: "Download"},
+	})
+	if len(components) != 1 {
+		t.Fatalf("expected one action row, got %d", len(components))
+	}
+	row, ok := components[0].(discordgo.ActionsRow)
+	if !ok || len(row.Components) != 2 {
+		t.Fatalf("unexpected action row: %+v", components[0])
+	}
+	stop := row.Components[0].(discordgo.Button)
+	download := row.Components[1].(discordgo.Button)
+	if stop.Style != discordgo.DangerButton || download.Style != discordgo.SecondaryButton || stop.CustomID != "stop:1" {
+		t.Fatalf("unexpected buttons: %+v %+v", stop, download)
+	}
+}
+
+func TestInteractionMemberPermissions(t *testing.T) {
+	ic := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{Member: &discordgo.Member{
+		Roles:       []string{"role-1"},
+		Permissions: discordgo.PermissionManageGuild,
+	}}}
+	if got := interactionRoleIDs(ic); len(got) != 1 || got[0] != "role-1" {
+		t.Fatalf("unexpected roles: %v", got)
+	}
+	if !interactionCanManageGuild(ic) {
+		t.Fatal("expected manage guild permission")
+	}
+	ic.Member.Permissions = discordgo.PermissionSendMessages
+	if interactionCanManageGuild(ic) {
+		t.Fatal("expected no manage guild permission")
+	}
+	dm := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{User: &discordgo.User{ID: "user-1"}}}
+	if interactionRoleIDs(dm) != nil || interactionCanManageGuild(dm) {
+		t.Fatal("expected no roles in direct messages")
+	}
+}
+
+type failedCallRecorder struct {
+	metrics.Recorder
+	calls []string
+}
+
+func (r *failedCallRecorder) DiscordAPIFailed(guildID, operation string) {
+	r.calls = append(r.calls, guildID+":"+operation)
+}
+
+func TestSendChannelMessage_RecordsFailedCallWithGuild(t *testing.T) {
+	s := newTestSession(t, func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusForbidden,
+			Status:     "403 Forbidden",
+			Body:       io.NopCloser(strings.NewReader(`{"message":"Missing Access","code":50001}`)),
+			Header:     make(http.Header),
+		}, nil
+	})
+	if err := s.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-1",
+		Channels: []*discordgo.Channel{{ID: "vc-1", GuildID: "guild-1"}},
+	}); err != nil {
+		t.Fatalf("failed to add guild to state: %v", err)
+	}
+
+	rec := &failedCallRecorder{Recorder: metrics.Nop}
+	c := &Client{session: s, metrics: rec}
+	if _, err := c.SendChannelMessage("vc-1", "hello"); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(rec.calls) != 1 || rec.calls[0] != "guild-1:send_message" {
+		t.Fatalf("unexpected recorded calls: %v", rec.calls)
+	}
+}
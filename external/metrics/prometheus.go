@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "mojiokoshin"
+
+type PrometheusRecorder struct {
+	registry         *prometheus.Registry
+	activeSessions   *prometheus.GaugeVec
+	opusPackets      *prometheus.CounterVec
+	mixedFrames      *prometheus.CounterVec
+	writtenFrames    *prometheus.CounterVec
+	sttReconnects    *prometheus.CounterVec
+	segmentLatency   *prometheus.HistogramVec
+	webhookRequests  *prometheus.CounterVec
+	discordAPIErrors *prometheus.CounterVec
+}
+
+func NewPrometheusRecorder() *PrometheusRecorder {
+	r := &PrometheusRecorder{
+		registry: prometheus.NewRegistry(),
+		activeSessions: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "active_sessions", Help: "Number of running transcription sessions.",
+		}, []string{"guild_id"}),
+		opusPackets: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "opus_packets_received_total", Help: "Opus packets received from voice connections.",
+		}, []string{"guild_id"}),
+		mixedFrames: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "audio_frames_mixed_total", Help: "PCM frames read from the mixer, including silent frames.",
+		}, []string{"guild_id"}),
+		writtenFrames: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "audio_frames_written_total", Help: "PCM frames written to the speech-to-text stream.",
+		}, []string{"guild_id"}),
+		sttReconnects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "stt_reconnects_total", Help: "Speech-to-text stream reconnects.",
+		}, []string{"guild_id"}),
+		segmentLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "segment_latency_seconds", Help: "Time from the end of speech until the segment is posted to the channel.",
+			Buckets: []float64{0.5, 1, 2, 3, 5, 8, 13, 21, 34},
+		}, []string{"guild_id"}),
+		webhookRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "webhook_requests_total", Help: "Transcript webhook deliveries by result.",
+		}, []string{"guild_id", "result"}),
+		discordAPIErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "discord_api_errors_total", Help: "Failed Discord API calls by operation.",
+		}, []string{"guild_id", "operation"}),
+	}
+	r.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		r.activeSessions,
+		r.opusPackets,
+		r.mixedFrames,
+		r.writtenFrames,
+		r.sttReconnects,
+		r.segmentLatency,
+		r.webhookRequests,
+		r.discordAPIErrors,
+	)
+	return r
+}
+
+// Handler は /metrics で公開する Prometheus 形式のハンドラー
+func (r *PrometheusRecorder) Handler() http.Handler {
+	return promhttp.HandlerFor(r.registry, promhttp.HandlerOpts{})
+}
+
+func (r *PrometheusRecorder) SessionStarted(guildID string) {
+	r.activeSessions.WithLabelValues(guildID).Inc()
+}
+
+func (r *PrometheusRecorder) SessionStopped(guildID string) {
+	r.activeSessions.WithLabelValues(guildID).Dec()
+}
+
+func (r *PrometheusRecorder) AddOpusPackets(guildID string, n int) {
+	r.opusPackets.WithLabelValues(guildID).Add(float64(n))
+}
+
+func (r *PrometheusRecorder) AddMixedFrames(guildID string, n int) {
+	r.mixedFrames.WithLabelValues(guildID).Add(float64(n))
+}
+
+func (r *PrometheusRecorder) AddWrittenFrames(guildID string, n int) {
+	r.writtenFrames.WithLabelValues(guildID).Add(float64(n))
+}
+
+func (r *PrometheusRecorder) STTReconnected(guildID string) {
+	r.sttReconnects.WithLabelValues(guildID).Inc()
+}
+
+func (r *PrometheusRecorder) ObserveSegmentLatency(guildID string, latency time.Duration) {
+	r.segmentLatency.WithLabelValues(guildID).Observe(latency.Seconds())
+}
+
+func (r *PrometheusRecorder) WebhookSent(guildID string, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	r.webhookRequests.WithLabelValues(guildID, result).Inc()
+}
+
+func (r *PrometheusRecorder) DiscordAPIFailed(guildID, operation string) {
+	r.discordAPIErrors.WithLabelValues(guildID, operation).Inc()
+}
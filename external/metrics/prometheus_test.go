@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/foxseedlab/mojiokoshin/internal/metrics"
+)
+
+var _ metrics.Recorder = (*PrometheusRecorder)(nil)
+
+func TestPrometheusRecorder_HandlerExposesRecordedMetrics(t *testing.T) {
+	r := NewPrometheusRecorder()
+	r.SessionStarted("guild-1")
+	r.SessionStarted("guild-1")
+	r.SessionStopped("guild-1")
+	r.AddOpusPackets("guild-1", 50)
+	r.AddMixedFrames("guild-1", 40)
+	r.AddWrittenFrames("guild-1", 30)
+	r.STTReconnected("guild-1")
+	r.ObserveSegmentLatency("guild-1", 1500*time.Millisecond)
+	r.WebhookSent("guild-1", nil)
+	r.WebhookSent("guild-1", errors.New("timeout"))
+	r.DiscordAPIFailed("guild-2", "send_message")
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	body, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := string(body)
+	for _, want := range []string{
+		`mojiokoshin_active_sessions{guild_id="guild-1"} 1`,
+		`mojiokoshin_opus_packets_received_total{guild_id="guild-1"} 50`,
+		`mojiokoshin_audio_frames_mixed_total{guild_id="guild-1"} 40`,
+		`mojiokoshin_audio_frames_written_total{guild_id="guild-1"} 30`,
+		`mojiokoshin_stt_reconnects_total{guild_id="guild-1"} 1`,
+		`mojiokoshin_segment_latency_seconds_bucket{guild_id="guild-1",le="1"} 0`,
+		`mojiokoshin_segment_latency_seconds_bucket{guild_id="guild-1",le="2"} 1`,
+		`mojiokoshin_segment_latency_seconds_sum{guild_id="guild-1"} 1.5`,
+		`mojiokoshin_segment_latency_seconds_count{guild_id="guild-1"} 1`,
+		`mojiokoshin_webhook_requests_total{guild_id="guild-1",result="success"} 1`,
+		`mojiokoshin_webhook_requests_total{guild_id="guild-1",result="failure"} 1`,
+		`mojiokoshin_discord_api_errors_total{guild_id="guild-2",operation="send_message"} 1`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in scrape output", want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+)
+
+func (r *PostgresRepository) InsertAuditEvent(ctx context.Context, event repository.AuditEvent) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO audit_events (guild_id, channel_id, session_id, actor_user_id, action, reason)
+		 VALUES ($1, $2, $3, $4, $5, $6)`,
+		event.GuildID, event.ChannelID, event.SessionID, event.ActorUserID, string(event.Action), event.Reason)
+	return err
+}
+
+func (r *PostgresRepository) ListAuditEvents(ctx context.Context, input repository.ListAuditEventsInput) ([]repository.AuditEvent, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id::text, guild_id, channel_id, session_id, actor_user_id, action, reason, created_at
+		 FROM audit_events
+		 WHERE ($1 = '' OR guild_id = $1)
+		   AND ($2 = '' OR session_id = $2)
+		   AND ($3 = '' OR actor_user_id = $3)
+		 ORDER BY created_at DESC
+		 LIMIT NULLIF($4, 0)`,
+		input.GuildID, input.SessionID, input.ActorUserID, input.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []repository.AuditEvent
+	for rows.Next() {
+		var e repository.AuditEvent
+		var action string
+		if err := rows.Scan(&e.ID, &e.GuildID, &e.ChannelID, &e.SessionID, &e.ActorUserID, &action, &e.Reason, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Action = repository.AuditAction(action)
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
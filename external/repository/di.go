@@ -31,6 +31,16 @@ func RegisterDI(injector do.Injector) {
 			p.Close()
 			return nil, fmt.Errorf("failed to run migration: %w", err)
 		}
-		return NewPostgresRepository(p), nil
+		keys, err := config.ParseEncryptionKeys(cfg.EncryptionKeys)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		r, err := NewPostgresRepository(p, keys)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		return r, nil
 	})
 }
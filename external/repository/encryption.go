@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/foxseedlab/mojiokoshin/internal/config"
+)
+
+// 暗号化した値は "enc:v1:<鍵ID>:<base64(nonce || 暗号文)>" として保存する
+const encryptedValuePrefix = "enc:v1:"
+
+var ErrEncryptionDisabled = errors.New("encryption keys are not configured")
+
+// fieldCipher は文字起こしの本文などを AES-GCM で暗号化する。nil の場合は平文のまま保存する
+type fieldCipher struct {
+	currentKeyID string
+	aeads        map[string]cipher.AEAD
+}
+
+func newFieldCipher(keys []config.EncryptionKey) (*fieldCipher, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	c := &fieldCipher{currentKeyID: keys[0].ID, aeads: make(map[string]cipher.AEAD, len(keys))}
+	for _, k := range keys {
+		block, err := aes.NewCipher(k.Key)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %s: %w", k.ID, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %s: %w", k.ID, err)
+		}
+		c.aeads[k.ID] = aead
+	}
+	return c, nil
+}
+
+// 現在の鍵で暗号化された値の先頭。鍵の入れ替え対象を探すのに使う
+func (c *fieldCipher) currentPrefix() string {
+	return encryptedValuePrefix + c.currentKeyID + ":"
+}
+
+// 空文字は暗号化しない
+func (c *fieldCipher) encryptText(plain string) (string, error) {
+	if c == nil || plain == "" {
+		return plain, nil
+	}
+	aead := c.aeads[c.currentKeyID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plain), nil)
+	return c.currentPrefix() + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// 暗号化を有効にする前に保存された平文はそのまま返す
+func (c *fieldCipher) decryptText(stored string) (string, error) {
+	rest, ok := strings.CutPrefix(stored, encryptedValuePrefix)
+	if !ok {
+		return stored, nil
+	}
+	if c == nil {
+		return "", ErrEncryptionDisabled
+	}
+	keyID, encoded, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", errors.New("malformed encrypted value")
+	}
+	aead, ok := c.aeads[keyID]
+	if !ok {
+		return "", fmt.Errorf("encryption key %s is not configured", keyID)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("decrypt with key %s: %w", keyID, err)
+	}
+	return string(plain), nil
+}
+
+// JSONB 列には、暗号文を JSON の文字列として保存する
+func (c *fieldCipher) encryptJSON(plain []byte) ([]byte, error) {
+	if c == nil || len(plain) == 0 {
+		return plain, nil
+	}
+	sealed, err := c.encryptText(string(plain))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(sealed)
+}
+
+func (c *fieldCipher) decryptJSON(stored []byte) ([]byte, error) {
+	var sealed string
+	if !strings.HasPrefix(string(stored), `"`+encryptedValuePrefix) || json.Unmarshal(stored, &sealed) != nil {
+		return stored, nil
+	}
+	plain, err := c.decryptText(sealed)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(plain), nil
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/foxseedlab/mojiokoshin/internal/config"
+)
+
+func testKey(id string, b byte) config.EncryptionKey {
+	return config.EncryptionKey{ID: id, Key: bytes.Repeat([]byte{b}, 32)}
+}
+
+func TestFieldCipher_TextRoundTrip(t *testing.T) {
+	c, err := newFieldCipher([]config.EncryptionKey{testKey("k1", 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := c.encryptText("こんにちは")
+	if err != nil {
+		t.Fatalf("unexpected encrypt error: %v", err)
+	}
+	if !strings.HasPrefix(sealed, "enc:v1:k1:") || strings.Contains(sealed, "こんにちは") {
+		t.Fatalf("unexpected sealed value: %q", sealed)
+	}
+	if again, _ := c.encryptText("こんにちは"); again == sealed {
+		t.Fatal("expected a random nonce per value")
+	}
+	plain, err := c.decryptText(sealed)
+	if err != nil || plain != "こんにちは" {
+		t.Fatalf("unexpected decrypt result: %q %v", plain, err)
+	}
+	if got, _ := c.encryptText(""); got != "" {
+		t.Fatalf("expected empty value to be kept: %q", got)
+	}
+}
+
+func TestFieldCipher_ReadsPlaintextAndOldKeys(t *testing.T) {
+	old, _ := newFieldCipher([]config.EncryptionKey{testKey("old", 1)})
+	sealed, _ := old.encryptText("secret")
+	rotated, _ := newFieldCipher([]config.EncryptionKey{testKey("new", 2), testKey("old", 1)})
+
+	if plain, err := rotated.decryptText(sealed); err != nil || plain != "secret" {
+		t.Fatalf("expected old key to decrypt: %q %v", plain, err)
+	}
+	if plain, err := rotated.decryptText("legacy text"); err != nil || plain != "legacy text" {
+		t.Fatalf("expected plaintext to pass through: %q %v", plain, err)
+	}
+	withoutOld, _ := newFieldCipher([]config.EncryptionKey{testKey("new", 2)})
+	if _, err := withoutOld.decryptText(sealed); err == nil {
+		t.Fatal("expected error for unknown key")
+	}
+	var disabled *fieldCipher
+	if _, err := disabled.decryptText(sealed); !errors.Is(err, ErrEncryptionDisabled) {
+		t.Fatalf("unexpected error without keys: %v", err)
+	}
+	if got, _ := disabled.encryptText("plain"); got != "plain" {
+		t.Fatalf("expected plaintext without keys: %q", got)
+	}
+}
+
+func TestFieldCipher_JSONRoundTrip(t *testing.T) {
+	c, _ := newFieldCipher([]config.EncryptionKey{testKey("k1", 1)})
+	payload := []byte(`{"participants":["Alice"]}`)
+	sealed, err := c.encryptJSON(payload)
+	if err != nil {
+		t.Fatalf("unexpected encrypt error: %v", err)
+	}
+	if !bytes.HasPrefix(sealed, []byte(`"enc:v1:k1:`)) {
+		t.Fatalf("expected a JSON string: %s", sealed)
+	}
+	plain, err := c.decryptJSON(sealed)
+	if err != nil || !bytes.Equal(plain, payload) {
+		t.Fatalf("unexpected decrypt result: %s %v", plain, err)
+	}
+	if plain, _ := c.decryptJSON(payload); !bytes.Equal(plain, payload) {
+		t.Fatalf("expected plaintext JSON to pass through: %s", plain)
+	}
+	if sealed, _ := c.encryptJSON(nil); sealed != nil {
+		t.Fatalf("expected NULL to be kept: %s", sealed)
+	}
+}
+
+func TestEncryptedTable_SQL(t *testing.T) {
+	table := encryptedTable{name: "session_artifacts", key: "session_id", textColumns: []string{"transcript_text"}, jsonColumns: []string{"webhook_payload"}}
+	if got := table.updateSQL(); got != `UPDATE session_artifacts SET transcript_text = $2, webhook_payload = $3 WHERE session_id = $1` {
+		t.Fatalf("unexpected update SQL: %s", got)
+	}
+	if got := table.selectStaleSQL(); !strings.Contains(got, "NOT starts_with(webhook_payload #>> '{}', $1)") {
+		t.Fatalf("unexpected select SQL: %s", got)
+	}
+}
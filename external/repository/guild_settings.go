@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *PostgresRepository) AddPhraseHint(ctx context.Context, input repository.AddPhraseHintInput) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`INSERT INTO guild_phrase_hints (guild_id, phrase, created_by_user_id)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (guild_id, phrase) DO NOTHING`,
+		input.GuildID, input.Phrase, input.CreatedByUserID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *PostgresRepository) RemovePhraseHint(ctx context.Context, guildID, phrase string) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`DELETE FROM guild_phrase_hints WHERE guild_id = $1 AND phrase = $2`,
+		guildID, phrase)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *PostgresRepository) ListPhraseHints(ctx context.Context, guildID string) ([]repository.PhraseHint, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT guild_id, phrase, created_by_user_id, created_at
+		 FROM guild_phrase_hints
+		 WHERE guild_id = $1
+		 ORDER BY created_at, phrase`,
+		guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []repository.PhraseHint
+	for rows.Next() {
+		var h repository.PhraseHint
+		if err := rows.Scan(&h.GuildID, &h.Phrase, &h.CreatedByUserID, &h.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, h)
+	}
+	return list, rows.Err()
+}
+
+func (r *PostgresRepository) SaveReplacementRule(ctx context.Context, input repository.SaveReplacementRuleInput) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO guild_replacement_rules (guild_id, pattern, replacement, is_regex, created_by_user_id)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (guild_id, pattern) DO UPDATE
+		 SET replacement = EXCLUDED.replacement, is_regex = EXCLUDED.is_regex`,
+		input.GuildID, input.Pattern, input.Replacement, input.IsRegex, input.CreatedByUserID)
+	return err
+}
+
+func (r *PostgresRepository) RemoveReplacementRule(ctx context.Context, guildID, pattern string) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`DELETE FROM guild_replacement_rules WHERE guild_id = $1 AND pattern = $2`,
+		guildID, pattern)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *PostgresRepository) ListReplacementRules(ctx context.Context, guildID string) ([]repository.ReplacementRule, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT guild_id, pattern, replacement, is_regex, created_by_user_id, created_at
+		 FROM guild_replacement_rules
+		 WHERE guild_id = $1
+		 ORDER BY created_at, pattern`,
+		guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []repository.ReplacementRule
+	for rows.Next() {
+		var rule repository.ReplacementRule
+		if err := rows.Scan(&rule.GuildID, &rule.Pattern, &rule.Replacement, &rule.IsRegex, &rule.CreatedByUserID, &rule.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, rule)
+	}
+	return list, rows.Err()
+}
+
+func (r *PostgresRepository) GetGuildSettings(ctx context.Context, guildID string) (*repository.GuildSettings, error) {
+	var s repository.GuildSettings
+	err := r.pool.QueryRow(ctx,
+		`SELECT guild_id, automatic_punctuation, spoken_punctuation, profanity_filter,
+		        number_normalization, text_normalization, languages, consent_mode,
+		        transcript_retention_days, artifact_retention_days, start_role_ids, moderator_role_ids, stop_policy,
+		        updated_by_user_id, updated_at
+		 FROM guild_settings
+		 WHERE guild_id = $1`,
+		guildID).Scan(&s.GuildID, &s.AutomaticPunctuation, &s.SpokenPunctuation, &s.ProfanityFilter,
+		&s.NumberNormalization, &s.TextNormalization, &s.Languages, &s.ConsentMode,
+		&s.TranscriptRetentionDays, &s.ArtifactRetentionDays, &s.StartRoleIDs, &s.ModeratorRoleIDs, &s.StopPolicy,
+		&s.UpdatedByUserID, &s.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *PostgresRepository) SaveGuildSettings(ctx context.Context, s repository.GuildSettings) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO guild_settings (guild_id, automatic_punctuation, spoken_punctuation, profanity_filter,
+		                             number_normalization, text_normalization, languages, consent_mode,
+		                             transcript_retention_days, artifact_retention_days, start_role_ids, moderator_role_ids, stop_policy,
+		                             updated_by_user_id, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, NOW())
+		 ON CONFLICT (guild_id) DO UPDATE
+		 SET automatic_punctuation = EXCLUDED.automatic_punctuation,
+		     spoken_punctuation = EXCLUDED.spoken_punctuation,
+		     profanity_filter = EXCLUDED.profanity_filter,
+		     number_normalization = EXCLUDED.number_normalization,
+		     text_normalization = EXCLUDED.text_normalization,
+		     languages = EXCLUDED.languages,
+		     consent_mode = EXCLUDED.consent_mode,
+		     transcript_retention_days = EXCLUDED.transcript_retention_days,
+		     artifact_retention_days = EXCLUDED.artifact_retention_days,
+		     start_role_ids = EXCLUDED.start_role_ids,
+		     moderator_role_ids = EXCLUDED.moderator_role_ids,
+		     stop_policy = EXCLUDED.stop_policy,
+		     updated_by_user_id = EXCLUDED.updated_by_user_id,
+		     updated_at = EXCLUDED.updated_at`,
+		s.GuildID, s.AutomaticPunctuation, s.SpokenPunctuation, s.ProfanityFilter,
+		s.NumberNormalization, s.TextNormalization, nonNullStrings(s.Languages), guildConsentMode(s.ConsentMode),
+		s.TranscriptRetentionDays, s.ArtifactRetentionDays, nonNullStrings(s.StartRoleIDs), nonNullStrings(s.ModeratorRoleIDs), guildStopPolicy(s.StopPolicy),
+		s.UpdatedByUserID)
+	return err
+}
+
+func guildConsentMode(mode repository.ConsentMode) repository.ConsentMode {
+	if mode == "" {
+		return repository.ConsentModeAnnounce
+	}
+	return mode
+}
+
+func guildStopPolicy(policy repository.StopPolicy) repository.StopPolicy {
+	if policy == "" {
+		return repository.StopPolicyParticipants
+	}
+	return policy
+}
+
+// nil のスライスは NULL として送られ NOT NULL 制約に反するため、空の配列にそろえる
+func nonNullStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type encryptedTable struct {
+	name        string
+	key         string
+	textColumns []string
+	jsonColumns []string
+}
+
+var encryptedTables = []encryptedTable{
+	{name: "session_artifacts", key: "session_id", textColumns: []string{"transcript_text"}, jsonColumns: []string{"webhook_payload", "summary"}},
+	{name: "transcript_segments", key: "id", textColumns: []string{"content", "raw_content"}, jsonColumns: []string{"words", "translations"}},
+	{name: "segment_revisions", key: "id", textColumns: []string{"content"}},
+}
+
+// 現在の鍵で暗号化されていない値を持つ行を探す。$1 は現在の鍵の接頭辞
+func (t encryptedTable) selectStaleSQL() string {
+	conds := make([]string, 0, len(t.textColumns)+len(t.jsonColumns))
+	for _, col := range t.textColumns {
+		conds = append(conds, fmt.Sprintf("(%s <> '' AND NOT starts_with(%s, $1))", col, col))
+	}
+	for _, col := range t.jsonColumns {
+		conds = append(conds, fmt.Sprintf("(%s IS NOT NULL AND NOT starts_with(%s #>> '{}', $1))", col, col))
+	}
+	return fmt.Sprintf(`SELECT %s::text, %s FROM %s WHERE %s LIMIT $2 FOR UPDATE SKIP LOCKED`,
+		t.key, strings.Join(t.columns(), ", "), t.name, strings.Join(conds, " OR "))
+}
+
+func (t encryptedTable) updateSQL() string {
+	sets := make([]string, 0, len(t.columns()))
+	for i, col := range t.columns() {
+		sets = append(sets, fmt.Sprintf("%s = $%d", col, i+2))
+	}
+	return fmt.Sprintf(`UPDATE %s SET %s WHERE %s = $1`, t.name, strings.Join(sets, ", "), t.key)
+}
+
+func (t encryptedTable) columns() []string {
+	return append(append([]string{}, t.textColumns...), t.jsonColumns...)
+}
+
+func (r *PostgresRepository) ReencryptBatch(ctx context.Context, limit int) (int, error) {
+	if r.cipher == nil {
+		return 0, ErrEncryptionDisabled
+	}
+	total := 0
+	for _, t := range encryptedTables {
+		n, err := r.reencryptTable(ctx, t, limit)
+		if err != nil {
+			return total, fmt.Errorf("reencrypt %s: %w", t.name, err)
+		}
+		total += n
+	}
+	return total, nil
+}
+
+type staleRow struct {
+	key   string
+	texts []string
+	jsons [][]byte
+}
+
+func (r *PostgresRepository) reencryptTable(ctx context.Context, t encryptedTable, limit int) (int, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	stale, err := selectStaleRows(ctx, tx, t, r.cipher.currentPrefix(), limit)
+	if err != nil || len(stale) == 0 {
+		return 0, err
+	}
+	for _, row := range stale {
+		args, err := r.reencryptRow(row)
+		if err != nil {
+			return 0, fmt.Errorf("row %s: %w", row.key, err)
+		}
+		if _, err := tx.Exec(ctx, t.updateSQL(), args...); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return len(stale), nil
+}
+
+func selectStaleRows(ctx context.Context, tx pgx.Tx, t encryptedTable, prefix string, limit int) ([]staleRow, error) {
+	rows, err := tx.Query(ctx, t.selectStaleSQL(), prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []staleRow
+	for rows.Next() {
+		row := staleRow{texts: make([]string, len(t.textColumns)), jsons: make([][]byte, len(t.jsonColumns))}
+		dest := []any{&row.key}
+		for i := range row.texts {
+			dest = append(dest, &row.texts[i])
+		}
+		for i := range row.jsons {
+			dest = append(dest, &row.jsons[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		list = append(list, row)
+	}
+	return list, rows.Err()
+}
+
+// 同じ行の他の列が現在の鍵で暗号化済みでも、復号して暗号化し直すだけなので問題ない
+func (r *PostgresRepository) reencryptRow(row staleRow) ([]any, error) {
+	args := []any{row.key}
+	for _, v := range row.texts {
+		plain, err := r.cipher.decryptText(v)
+		if err != nil {
+			return nil, err
+		}
+		sealed, err := r.cipher.encryptText(plain)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, sealed)
+	}
+	for _, v := range row.jsons {
+		plain, err := r.cipher.decryptJSON(v)
+		if err != nil {
+			return nil, err
+		}
+		sealed, err := r.cipher.encryptJSON(plain)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, nullableJSON(sealed))
+	}
+	return args, nil
+}
@@ -34,6 +34,7 @@ var migrationStatements = []string{
 	`ALTER TABLE sessions ADD COLUMN IF NOT EXISTS segment_count INTEGER NOT NULL DEFAULT 0`,
 	`ALTER TABLE sessions ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()`,
 	`ALTER TABLE sessions ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()`,
+	`ALTER TABLE sessions ADD COLUMN IF NOT EXISTS transcript_revision INTEGER NOT NULL DEFAULT 0`,
 	`UPDATE sessions SET guild_name = guild_id WHERE guild_name = ''`,
 	`UPDATE sessions SET channel_name = channel_id WHERE channel_name = ''`,
 	`UPDATE sessions SET timezone = 'UTC' WHERE timezone = ''`,
@@ -92,6 +93,18 @@ var migrationStatements = []string{
 		UNIQUE(session_id, segment_index)
 	)`,
 	`ALTER TABLE transcript_segments ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()`,
+	`ALTER TABLE transcript_segments ADD COLUMN IF NOT EXISTS revision INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE transcript_segments DROP CONSTRAINT IF EXISTS transcript_segments_session_id_segment_index_key`,
+	`ALTER TABLE transcript_segments ADD COLUMN IF NOT EXISTS discord_message_id TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE transcript_segments ADD COLUMN IF NOT EXISTS corrected_at TIMESTAMPTZ`,
+	`ALTER TABLE transcript_segments ADD COLUMN IF NOT EXISTS ended_at TIMESTAMPTZ`,
+	`ALTER TABLE transcript_segments ADD COLUMN IF NOT EXISTS confidence DOUBLE PRECISION`,
+	`ALTER TABLE transcript_segments ADD COLUMN IF NOT EXISTS words JSONB`,
+	`ALTER TABLE transcript_segments ADD COLUMN IF NOT EXISTS raw_content TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE transcript_segments ADD COLUMN IF NOT EXISTS language TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE transcript_segments ADD COLUMN IF NOT EXISTS translations JSONB`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS uq_transcript_segments_revision_index ON transcript_segments (session_id, revision, segment_index)`,
+	`CREATE INDEX IF NOT EXISTS idx_transcript_segments_discord_message ON transcript_segments (discord_message_id) WHERE discord_message_id <> ''`,
 	`DO $$ BEGIN
 		IF NOT EXISTS (
 			SELECT 1 FROM pg_constraint
@@ -103,6 +116,57 @@ var migrationStatements = []string{
 	END $$`,
 	`DROP INDEX IF EXISTS idx_transcript_segments_session`,
 	`CREATE INDEX IF NOT EXISTS idx_transcript_segments_spoken ON transcript_segments (session_id, spoken_at, segment_index)`,
+	`CREATE TABLE IF NOT EXISTS segment_revisions (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		segment_id UUID NOT NULL REFERENCES transcript_segments(id) ON DELETE CASCADE,
+		version INTEGER NOT NULL,
+		content TEXT NOT NULL,
+		edited_by_user_id TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		UNIQUE(segment_id, version)
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_segment_revisions_editor ON segment_revisions (edited_by_user_id, created_at DESC) WHERE edited_by_user_id <> ''`,
+	`CREATE TABLE IF NOT EXISTS guild_phrase_hints (
+		guild_id TEXT NOT NULL,
+		phrase TEXT NOT NULL,
+		created_by_user_id TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (guild_id, phrase)
+	)`,
+	`CREATE TABLE IF NOT EXISTS guild_replacement_rules (
+		guild_id TEXT NOT NULL,
+		pattern TEXT NOT NULL,
+		replacement TEXT NOT NULL,
+		is_regex BOOLEAN NOT NULL DEFAULT FALSE,
+		created_by_user_id TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (guild_id, pattern)
+	)`,
+	`CREATE TABLE IF NOT EXISTS guild_settings (
+		guild_id TEXT PRIMARY KEY,
+		automatic_punctuation BOOLEAN NOT NULL DEFAULT TRUE,
+		spoken_punctuation BOOLEAN NOT NULL DEFAULT FALSE,
+		profanity_filter BOOLEAN NOT NULL DEFAULT FALSE,
+		number_normalization BOOLEAN NOT NULL DEFAULT FALSE,
+		text_normalization BOOLEAN NOT NULL DEFAULT FALSE,
+		languages TEXT[] NOT NULL DEFAULT '{}',
+		updated_by_user_id TEXT NOT NULL DEFAULT '',
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE TABLE IF NOT EXISTS transcript_subscriptions (
+		guild_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (guild_id, user_id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS recording_consents (
+		guild_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		consent TEXT NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (guild_id, user_id)
+	)`,
+	`ALTER TABLE guild_settings ADD COLUMN IF NOT EXISTS consent_mode TEXT NOT NULL DEFAULT 'announce'`,
 	`CREATE TABLE IF NOT EXISTS session_participants (
 		session_id UUID NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
 		user_id TEXT NOT NULL,
@@ -133,7 +197,33 @@ var migrationStatements = []string{
 		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
 		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 	)`,
+	`ALTER TABLE session_artifacts ADD COLUMN IF NOT EXISTS summary JSONB`,
 	`CREATE INDEX IF NOT EXISTS idx_session_artifacts_webhook_payload_gin ON session_artifacts USING GIN (webhook_payload jsonb_path_ops)`,
+	`ALTER TABLE guild_settings ADD COLUMN IF NOT EXISTS transcript_retention_days INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE guild_settings ADD COLUMN IF NOT EXISTS artifact_retention_days INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE sessions ADD COLUMN IF NOT EXISTS artifacts_purged_at TIMESTAMPTZ`,
+	`ALTER TABLE sessions ADD COLUMN IF NOT EXISTS transcripts_purged_at TIMESTAMPTZ`,
+	`CREATE INDEX IF NOT EXISTS idx_sessions_artifacts_retention ON sessions (guild_id, ended_at) WHERE artifacts_purged_at IS NULL AND status = 'completed'`,
+	`CREATE INDEX IF NOT EXISTS idx_sessions_transcripts_retention ON sessions (guild_id, ended_at) WHERE transcripts_purged_at IS NULL AND status = 'completed'`,
+	`ALTER TABLE guild_settings ADD COLUMN IF NOT EXISTS start_role_ids TEXT[] NOT NULL DEFAULT '{}'`,
+	`ALTER TABLE guild_settings ADD COLUMN IF NOT EXISTS moderator_role_ids TEXT[] NOT NULL DEFAULT '{}'`,
+	`ALTER TABLE guild_settings ADD COLUMN IF NOT EXISTS stop_policy TEXT NOT NULL DEFAULT 'participants'`,
+	`CREATE TABLE IF NOT EXISTS audit_events (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		guild_id TEXT NOT NULL,
+		channel_id TEXT NOT NULL DEFAULT '',
+		session_id TEXT NOT NULL DEFAULT '',
+		actor_user_id TEXT NOT NULL DEFAULT '',
+		action TEXT NOT NULL,
+		reason TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_audit_events_guild ON audit_events (guild_id, created_at DESC)`,
+	`CREATE INDEX IF NOT EXISTS idx_audit_events_session ON audit_events (session_id, created_at DESC) WHERE session_id <> ''`,
+	`CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events (actor_user_id, created_at DESC) WHERE actor_user_id <> ''`,
+	`ALTER TABLE sessions ADD COLUMN IF NOT EXISTS started_by_user_id TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE sessions ADD COLUMN IF NOT EXISTS start_trigger TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE sessions ADD COLUMN IF NOT EXISTS stopped_by_user_id TEXT NOT NULL DEFAULT ''`,
 }
 
 func RunMigration(ctx context.Context, pool *pgxpool.Pool) error {
@@ -2,27 +2,52 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/foxseedlab/mojiokoshin/internal/config"
 	"github.com/foxseedlab/mojiokoshin/internal/repository"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const pgErrCodeInvalidTextRepresentation = "22P02"
+
+const sessionColumns = `id, guild_id, guild_name, channel_id, channel_name, started_at, ended_at, status, stop_reason, started_by_user_id, start_trigger, stopped_by_user_id, timezone, duration_seconds, segment_count, transcript_revision, created_at, updated_at, artifacts_purged_at, transcripts_purged_at`
+
+const segmentColumns = `ts.id, ts.session_id, ts.content, ts.raw_content, ts.segment_index, ts.revision, ts.discord_message_id, ts.spoken_at, ts.ended_at, ts.confidence, ts.words, ts.language, ts.translations, ts.corrected_at, ts.created_at`
+
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
 type PostgresRepository struct {
-	pool *pgxpool.Pool
+	pool   *pgxpool.Pool
+	cipher *fieldCipher
+}
+
+// keys が空の場合は暗号化せずに保存する
+func NewPostgresRepository(pool *pgxpool.Pool, keys []config.EncryptionKey) (repository.Repository, error) {
+	c, err := newFieldCipher(keys)
+	if err != nil {
+		return nil, err
+	}
+	return &PostgresRepository{pool: pool, cipher: c}, nil
 }
 
-func NewPostgresRepository(pool *pgxpool.Pool) repository.Repository {
-	return &PostgresRepository{pool: pool}
+func (r *PostgresRepository) Ping(ctx context.Context) error {
+	return r.pool.Ping(ctx)
 }
 
 func (r *PostgresRepository) CreateSession(ctx context.Context, input repository.CreateSessionInput) (*repository.Session, error) {
 	row := r.pool.QueryRow(ctx,
-		`INSERT INTO sessions (guild_id, guild_name, channel_id, channel_name, started_at, status)
-		 VALUES ($1, $1, $2, $2, $3, 'running')
-		 RETURNING id, guild_id, guild_name, channel_id, channel_name, started_at, ended_at, status, stop_reason, timezone, duration_seconds, segment_count, created_at, updated_at`,
-		input.GuildID, input.ChannelID, input.StartedAt)
+		`INSERT INTO sessions (guild_id, guild_name, channel_id, channel_name, started_at, status, started_by_user_id, start_trigger)
+		 VALUES ($1, $1, $2, $2, $3, 'running', $4, $5)
+		 RETURNING `+sessionColumns,
+		input.GuildID, input.ChannelID, input.StartedAt, input.StartedByUserID, string(input.StartTrigger))
 	s, err := scanSession(row)
 	if err != nil {
 		return nil, err
@@ -56,6 +81,7 @@ func (r *PostgresRepository) SaveSessionOutput(ctx context.Context, input reposi
 		     timezone = COALESCE(NULLIF($6, ''), timezone),
 		     duration_seconds = GREATEST($7, 0),
 		     segment_count = GREATEST($8, 0),
+		     stopped_by_user_id = $9,
 		     updated_at = NOW()
 		 WHERE id = $1`,
 		input.SessionID,
@@ -66,6 +92,7 @@ func (r *PostgresRepository) SaveSessionOutput(ctx context.Context, input reposi
 		input.Timezone,
 		input.DurationSeconds,
 		input.SegmentCount,
+		input.StoppedByUserID,
 	)
 	if err != nil {
 		return err
@@ -80,27 +107,76 @@ func (r *PostgresRepository) SaveSessionOutput(ctx context.Context, input reposi
 		}
 	}
 
-	if _, err := tx.Exec(ctx,
+	if err := r.upsertSessionArtifact(ctx, tx, repository.SaveTranscriptArtifactInput{
+		SessionID:          input.SessionID,
+		TranscriptFilename: input.TranscriptFilename,
+		TranscriptText:     input.TranscriptText,
+		WebhookPayloadJSON: input.WebhookPayloadJSON,
+		SummaryJSON:        input.SummaryJSON,
+	}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresRepository) SaveTranscriptArtifact(ctx context.Context, input repository.SaveTranscriptArtifactInput) error {
+	return r.upsertSessionArtifact(ctx, r.pool, input)
+}
+
+func (r *PostgresRepository) GetTranscriptArtifact(ctx context.Context, sessionID string) (*repository.TranscriptArtifact, error) {
+	var a repository.TranscriptArtifact
+	err := r.pool.QueryRow(ctx,
+		`SELECT session_id, transcript_filename, transcript_text, updated_at
+		 FROM session_artifacts WHERE session_id = $1`,
+		sessionID).Scan(&a.SessionID, &a.TranscriptFilename, &a.TranscriptText, &a.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows || isInvalidTextRepresentation(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if a.TranscriptText, err = r.cipher.decryptText(a.TranscriptText); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *PostgresRepository) upsertSessionArtifact(ctx context.Context, db execer, input repository.SaveTranscriptArtifactInput) error {
+	text, err := r.cipher.encryptText(input.TranscriptText)
+	if err != nil {
+		return err
+	}
+	payload, err := r.cipher.encryptJSON(input.WebhookPayloadJSON)
+	if err != nil {
+		return err
+	}
+	summary, err := r.cipher.encryptJSON(input.SummaryJSON)
+	if err != nil {
+		return err
+	}
+	// 保存期間を過ぎて削除したセッションには成果物を作り直さない
+	_, err = db.Exec(ctx,
 		`INSERT INTO session_artifacts
-			(session_id, transcript_filename, transcript_text, webhook_payload)
-		 VALUES ($1, $2, $3, $4::jsonb)
+			(session_id, transcript_filename, transcript_text, webhook_payload, summary)
+		 SELECT id, $2, $3, $4::jsonb, $5::jsonb
+		 FROM sessions WHERE id = $1 AND artifacts_purged_at IS NULL
 		 ON CONFLICT (session_id) DO UPDATE
 		 SET transcript_filename = EXCLUDED.transcript_filename,
 		     transcript_text = EXCLUDED.transcript_text,
 		     webhook_payload = EXCLUDED.webhook_payload,
+		     summary = COALESCE(EXCLUDED.summary, session_artifacts.summary),
 		     updated_at = NOW()`,
 		input.SessionID,
 		input.TranscriptFilename,
-		input.TranscriptText,
-		nullableJSON(input.WebhookPayloadJSON),
-	); err != nil {
-		return err
-	}
-
-	if err := tx.Commit(ctx); err != nil {
-		return err
-	}
-	return nil
+		text,
+		nullableJSON(payload),
+		nullableJSON(summary),
+	)
+	return err
 }
 
 func upsertSessionParticipant(ctx context.Context, tx pgx.Tx, sessionID string, endedAt time.Time, p repository.SessionParticipantSnapshot) error {
@@ -143,7 +219,7 @@ func normalizeParticipantSeenAt(firstSeenAt, lastSeenAt, endedAt time.Time) (tim
 
 func (r *PostgresRepository) GetRunningSessionByChannel(ctx context.Context, guildID, channelID string) (*repository.Session, error) {
 	row := r.pool.QueryRow(ctx,
-		`SELECT id, guild_id, guild_name, channel_id, channel_name, started_at, ended_at, status, stop_reason, timezone, duration_seconds, segment_count, created_at, updated_at
+		`SELECT `+sessionColumns+`
 		 FROM sessions WHERE guild_id = $1 AND channel_id = $2 AND status = 'running'
 		 LIMIT 1`,
 		guildID, channelID)
@@ -157,18 +233,123 @@ func (r *PostgresRepository) GetRunningSessionByChannel(ctx context.Context, gui
 	return s, nil
 }
 
+func (r *PostgresRepository) GetSessionByID(ctx context.Context, sessionID string) (*repository.Session, error) {
+	row := r.pool.QueryRow(ctx,
+		`SELECT `+sessionColumns+`
+		 FROM sessions WHERE id = $1`,
+		sessionID)
+	s, err := scanSession(row)
+	if err != nil {
+		if err == pgx.ErrNoRows || isInvalidTextRepresentation(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *PostgresRepository) ListSessionParticipants(ctx context.Context, sessionID string) ([]repository.SessionParticipant, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT session_id, user_id, display_name, is_bot, first_seen_at, last_seen_at
+		 FROM session_participants WHERE session_id = $1 ORDER BY first_seen_at ASC, user_id ASC`,
+		sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []repository.SessionParticipant
+	for rows.Next() {
+		var p repository.SessionParticipant
+		if err := rows.Scan(&p.SessionID, &p.UserID, &p.DisplayName, &p.IsBot, &p.FirstSeenAt, &p.LastSeenAt); err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+func (r *PostgresRepository) SaveTranscriptRevision(ctx context.Context, input repository.SaveTranscriptRevisionInput) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE sessions
+		 SET transcript_revision = $2,
+		     segment_count = GREATEST($3, 0),
+		     updated_at = NOW()
+		 WHERE id = $1 AND transcript_revision < $2`,
+		input.SessionID,
+		input.Revision,
+		input.SegmentCount,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	if err := r.upsertSessionArtifact(ctx, tx, repository.SaveTranscriptArtifactInput{
+		SessionID:          input.SessionID,
+		TranscriptFilename: input.TranscriptFilename,
+		TranscriptText:     input.TranscriptText,
+		WebhookPayloadJSON: input.WebhookPayloadJSON,
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
 func (r *PostgresRepository) InsertSegment(ctx context.Context, input repository.InsertSegmentInput) error {
-	_, err := r.pool.Exec(ctx,
-		`INSERT INTO transcript_segments (session_id, content, segment_index, spoken_at)
-		 VALUES ($1, $2, $3, $4)`,
-		input.SessionID, input.Content, input.SegmentIndex, input.SpokenAt)
+	words, err := marshalSegmentJSON(input.Words)
+	if err != nil {
+		return fmt.Errorf("marshal segment words: %w", err)
+	}
+	translations, err := marshalSegmentJSON(input.Translations)
+	if err != nil {
+		return fmt.Errorf("marshal segment translations: %w", err)
+	}
+	content, err := r.cipher.encryptText(input.Content)
+	if err != nil {
+		return err
+	}
+	rawContent, err := r.cipher.encryptText(input.RawContent)
+	if err != nil {
+		return err
+	}
+	if words, err = r.cipher.encryptJSON(words); err != nil {
+		return err
+	}
+	if translations, err = r.cipher.encryptJSON(translations); err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx,
+		`INSERT INTO transcript_segments (session_id, content, raw_content, segment_index, revision, spoken_at, ended_at, confidence, words, language, translations)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		input.SessionID, content, rawContent, input.SegmentIndex, input.Revision, input.SpokenAt, nullableTime(input.EndedAt), nullableConfidence(input.Confidence), nullableJSON(words), input.Language, nullableJSON(translations))
 	return err
 }
 
+func marshalSegmentJSON[T any](items []T) ([]byte, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(items)
+}
+
 func (r *PostgresRepository) ListSegmentsBySessionID(ctx context.Context, sessionID string) ([]repository.TranscriptSegment, error) {
 	rows, err := r.pool.Query(ctx,
-		`SELECT id, session_id, content, segment_index, spoken_at, created_at
-		 FROM transcript_segments WHERE session_id = $1 ORDER BY segment_index ASC`,
+		`SELECT `+segmentColumns+`
+		 FROM transcript_segments ts
+		 JOIN sessions s ON s.id = ts.session_id
+		 WHERE ts.session_id = $1 AND ts.revision = s.transcript_revision
+		 ORDER BY ts.segment_index ASC`,
 		sessionID)
 	if err != nil {
 		return nil, err
@@ -176,22 +357,205 @@ func (r *PostgresRepository) ListSegmentsBySessionID(ctx context.Context, sessio
 	defer rows.Close()
 	var list []repository.TranscriptSegment
 	for rows.Next() {
-		var seg repository.TranscriptSegment
-		if err := rows.Scan(&seg.ID, &seg.SessionID, &seg.Content, &seg.SegmentIndex, &seg.SpokenAt, &seg.CreatedAt); err != nil {
+		seg, err := r.scanSegment(rows)
+		if err != nil {
 			return nil, err
 		}
-		list = append(list, seg)
+		list = append(list, *seg)
 	}
 	return list, rows.Err()
 }
 
-type sessionScanner interface {
+func (r *PostgresRepository) SetSegmentDiscordMessageID(ctx context.Context, sessionID string, segmentIndex int, messageID string) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE transcript_segments ts
+		 SET discord_message_id = $3
+		 FROM sessions s
+		 WHERE s.id = ts.session_id
+		   AND ts.session_id = $1
+		   AND ts.segment_index = $2
+		   AND ts.revision = s.transcript_revision`,
+		sessionID, segmentIndex, messageID)
+	return err
+}
+
+func (r *PostgresRepository) SetSegmentTranslations(ctx context.Context, sessionID string, segmentIndex int, translations []repository.SegmentTranslation) (bool, error) {
+	payload, err := marshalSegmentJSON(translations)
+	if err != nil {
+		return false, fmt.Errorf("marshal segment translations: %w", err)
+	}
+	if payload, err = r.cipher.encryptJSON(payload); err != nil {
+		return false, err
+	}
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE transcript_segments ts
+		 SET translations = $3
+		 FROM sessions s
+		 WHERE s.id = ts.session_id
+		   AND ts.session_id = $1
+		   AND ts.segment_index = $2
+		   AND ts.revision = s.transcript_revision
+		   AND ts.corrected_at IS NULL`,
+		sessionID, segmentIndex, nullableJSON(payload))
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *PostgresRepository) GetSegment(ctx context.Context, sessionID string, segmentIndex int) (*repository.TranscriptSegment, error) {
+	row := r.pool.QueryRow(ctx,
+		`SELECT `+segmentColumns+`
+		 FROM transcript_segments ts
+		 JOIN sessions s ON s.id = ts.session_id
+		 WHERE ts.session_id = $1 AND ts.segment_index = $2 AND ts.revision = s.transcript_revision`,
+		sessionID, segmentIndex)
+	seg, err := r.scanSegment(row)
+	if err != nil {
+		if err == pgx.ErrNoRows || isInvalidTextRepresentation(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return seg, nil
+}
+
+func (r *PostgresRepository) GetSegmentByDiscordMessageID(ctx context.Context, messageID string) (*repository.TranscriptSegment, error) {
+	row := r.pool.QueryRow(ctx,
+		`SELECT `+segmentColumns+`
+		 FROM transcript_segments ts
+		 WHERE ts.discord_message_id = $1
+		 ORDER BY ts.created_at DESC
+		 LIMIT 1`,
+		messageID)
+	seg, err := r.scanSegment(row)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return seg, nil
+}
+
+func (r *PostgresRepository) CorrectSegment(ctx context.Context, input repository.CorrectSegmentInput) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var current string
+	if err := tx.QueryRow(ctx,
+		`SELECT content FROM transcript_segments WHERE id = $1 FOR UPDATE`,
+		input.SegmentID,
+	).Scan(&current); err != nil {
+		return err
+	}
+	content, err := r.cipher.encryptText(input.Content)
+	if err != nil {
+		return err
+	}
+	// 初回の修正時に、音声認識の結果をバージョン0として残しておく。暗号化済みの値はそのまま写す
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO segment_revisions (segment_id, version, content)
+		 VALUES ($1, 0, $2)
+		 ON CONFLICT (segment_id, version) DO NOTHING`,
+		input.SegmentID, current,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO segment_revisions (segment_id, version, content, edited_by_user_id, created_at)
+		 SELECT $1, MAX(version) + 1, $2, $3, $4
+		 FROM segment_revisions WHERE segment_id = $1`,
+		input.SegmentID, content, input.EditedByUserID, input.EditedAt,
+	); err != nil {
+		return err
+	}
+	translations, err := marshalSegmentJSON(input.Translations)
+	if err != nil {
+		return fmt.Errorf("marshal segment translations: %w", err)
+	}
+	if translations, err = r.cipher.encryptJSON(translations); err != nil {
+		return err
+	}
+	// 単語と信頼度は修正前の音声認識の結果なので、修正後の本文と食い違わないように消す
+	if _, err := tx.Exec(ctx,
+		`UPDATE transcript_segments
+		 SET content = $2, corrected_at = $3, translations = $4, words = NULL, confidence = NULL
+		 WHERE id = $1`,
+		input.SegmentID, content, input.EditedAt, nullableJSON(translations),
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+type rowScanner interface {
 	Scan(dest ...any) error
 }
 
-func scanSession(scanner sessionScanner) (*repository.Session, error) {
+func (r *PostgresRepository) scanSegment(scanner rowScanner) (*repository.TranscriptSegment, error) {
+	var seg repository.TranscriptSegment
+	var words, translations []byte
+	if err := scanner.Scan(
+		&seg.ID,
+		&seg.SessionID,
+		&seg.Content,
+		&seg.RawContent,
+		&seg.SegmentIndex,
+		&seg.Revision,
+		&seg.DiscordMessageID,
+		&seg.SpokenAt,
+		&seg.EndedAt,
+		&seg.Confidence,
+		&words,
+		&seg.Language,
+		&translations,
+		&seg.CorrectedAt,
+		&seg.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if err := r.decryptSegment(&seg, &words, &translations); err != nil {
+		return nil, err
+	}
+	if len(words) > 0 {
+		if err := json.Unmarshal(words, &seg.Words); err != nil {
+			return nil, fmt.Errorf("unmarshal segment words: %w", err)
+		}
+	}
+	if len(translations) > 0 {
+		if err := json.Unmarshal(translations, &seg.Translations); err != nil {
+			return nil, fmt.Errorf("unmarshal segment translations: %w", err)
+		}
+	}
+	return &seg, nil
+}
+
+func (r *PostgresRepository) decryptSegment(seg *repository.TranscriptSegment, words, translations *[]byte) error {
+	var err error
+	if seg.Content, err = r.cipher.decryptText(seg.Content); err != nil {
+		return err
+	}
+	if seg.RawContent, err = r.cipher.decryptText(seg.RawContent); err != nil {
+		return err
+	}
+	if *words, err = r.cipher.decryptJSON(*words); err != nil {
+		return err
+	}
+	*translations, err = r.cipher.decryptJSON(*translations)
+	return err
+}
+
+func scanSession(scanner rowScanner) (*repository.Session, error) {
 	var s repository.Session
 	var endedAt *time.Time
+	var startTrigger string
 	if err := scanner.Scan(
 		&s.ID,
 		&s.GuildID,
@@ -202,18 +566,31 @@ func scanSession(scanner sessionScanner) (*repository.Session, error) {
 		&endedAt,
 		&s.Status,
 		&s.StopReason,
+		&s.StartedByUserID,
+		&startTrigger,
+		&s.StoppedByUserID,
 		&s.Timezone,
 		&s.DurationSeconds,
 		&s.SegmentCount,
+		&s.TranscriptRevision,
 		&s.CreatedAt,
 		&s.UpdatedAt,
+		&s.ArtifactsPurgedAt,
+		&s.TranscriptsPurgedAt,
 	); err != nil {
 		return nil, err
 	}
 	s.EndedAt = endedAt
+	s.StartTrigger = repository.StartTrigger(startTrigger)
 	return &s, nil
 }
 
+// セッションIDはユーザー入力から渡されることがあり、UUIDとして不正な値は「存在しない」として扱う
+func isInvalidTextRepresentation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgErrCodeInvalidTextRepresentation
+}
+
 func fallbackDisplayName(displayName, userID string) string {
 	if displayName == "" {
 		return userID
@@ -227,3 +604,17 @@ func nullableJSON(v []byte) any {
 	}
 	return string(v)
 }
+
+func nullableConfidence(v float64) any {
+	if v <= 0 {
+		return nil
+	}
+	return v
+}
+
+func nullableTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *PostgresRepository) PurgeExpiredArtifacts(ctx context.Context, input repository.PurgeExpiredInput) ([]string, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	sessionIDs, err := lockExpiredSessions(ctx, tx, "artifacts_purged_at", input)
+	if err != nil || len(sessionIDs) == 0 {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM session_artifacts WHERE session_id = ANY($1::uuid[])`, sessionIDs); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx,
+		`UPDATE sessions SET artifacts_purged_at = NOW(), updated_at = NOW() WHERE id = ANY($1::uuid[])`,
+		sessionIDs); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return sessionIDs, nil
+}
+
+func (r *PostgresRepository) PurgeExpiredTranscripts(ctx context.Context, input repository.PurgeExpiredInput) ([]string, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	sessionIDs, err := lockExpiredSessions(ctx, tx, "transcripts_purged_at", input)
+	if err != nil || len(sessionIDs) == 0 {
+		return nil, err
+	}
+	// segment_revisions は transcript_segments の削除に連動して消える
+	for _, stmt := range []string{
+		`DELETE FROM transcript_segments WHERE session_id = ANY($1::uuid[])`,
+		`DELETE FROM session_participants WHERE session_id = ANY($1::uuid[])`,
+		`DELETE FROM session_artifacts WHERE session_id = ANY($1::uuid[])`,
+		`UPDATE sessions
+		 SET guild_name = '', channel_name = '',
+		     transcripts_purged_at = NOW(),
+		     artifacts_purged_at = COALESCE(artifacts_purged_at, NOW()),
+		     updated_at = NOW()
+		 WHERE id = ANY($1::uuid[])`,
+	} {
+		if _, err := tx.Exec(ctx, stmt, sessionIDs); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return sessionIDs, nil
+}
+
+// 他のインスタンスと同時に実行しても同じセッションを二重に処理しないように、行をロックして取り出す
+func lockExpiredSessions(ctx context.Context, tx pgx.Tx, purgedColumn string, input repository.PurgeExpiredInput) ([]string, error) {
+	rows, err := tx.Query(ctx,
+		`SELECT id::text FROM sessions
+		 WHERE guild_id = $1 AND status = 'completed' AND ended_at < $2 AND `+purgedColumn+` IS NULL
+		 ORDER BY ended_at ASC
+		 LIMIT $3
+		 FOR UPDATE SKIP LOCKED`,
+		input.GuildID, input.EndedBefore, input.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var sessionIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		sessionIDs = append(sessionIDs, id)
+	}
+	return sessionIDs, rows.Err()
+}
+
+func (r *PostgresRepository) DeleteSession(ctx context.Context, sessionID string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM sessions WHERE id = $1`, sessionID)
+	if err != nil {
+		if isInvalidTextRepresentation(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
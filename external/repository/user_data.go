@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *PostgresRepository) ListUserParticipations(ctx context.Context, userID string) ([]repository.UserParticipation, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT s.id, s.guild_id, s.guild_name, s.channel_id, s.channel_name, s.started_at, s.ended_at,
+		        sp.display_name, sp.is_bot, sp.first_seen_at, sp.last_seen_at
+		 FROM session_participants sp
+		 JOIN sessions s ON s.id = sp.session_id
+		 WHERE sp.user_id = $1
+		 ORDER BY sp.last_seen_at DESC`,
+		userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []repository.UserParticipation
+	for rows.Next() {
+		var p repository.UserParticipation
+		if err := rows.Scan(&p.SessionID, &p.GuildID, &p.GuildName, &p.ChannelID, &p.ChannelName, &p.StartedAt, &p.EndedAt,
+			&p.DisplayName, &p.IsBot, &p.FirstSeenAt, &p.LastSeenAt); err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+func (r *PostgresRepository) ListUserSegmentEdits(ctx context.Context, userID string) ([]repository.UserSegmentEdit, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT ts.session_id, ts.segment_index, ts.revision, sr.version, sr.content, sr.created_at
+		 FROM segment_revisions sr
+		 JOIN transcript_segments ts ON ts.id = sr.segment_id
+		 WHERE sr.edited_by_user_id = $1
+		 ORDER BY sr.created_at ASC`,
+		userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []repository.UserSegmentEdit
+	for rows.Next() {
+		var e repository.UserSegmentEdit
+		if err := rows.Scan(&e.SessionID, &e.SegmentIndex, &e.Revision, &e.Version, &e.Content, &e.EditedAt); err != nil {
+			return nil, err
+		}
+		if e.Content, err = r.cipher.decryptText(e.Content); err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+func (r *PostgresRepository) ListUserGuildPreferences(ctx context.Context, userID string) ([]repository.UserGuildPreference, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT COALESCE(ts.guild_id, rc.guild_id), ts.guild_id IS NOT NULL, COALESCE(rc.consent, '')
+		 FROM (SELECT guild_id FROM transcript_subscriptions WHERE user_id = $1) ts
+		 FULL OUTER JOIN (SELECT guild_id, consent FROM recording_consents WHERE user_id = $1) rc
+		   ON rc.guild_id = ts.guild_id
+		 ORDER BY 1`,
+		userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []repository.UserGuildPreference
+	for rows.Next() {
+		var p repository.UserGuildPreference
+		var consent string
+		if err := rows.Scan(&p.GuildID, &p.TranscriptSubscribed, &consent); err != nil {
+			return nil, err
+		}
+		p.RecordingConsent = repository.RecordingConsent(consent)
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+func (r *PostgresRepository) ListUserArtifacts(ctx context.Context, userID string) ([]repository.UserArtifact, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT sa.session_id, sp.display_name, sa.transcript_text, sa.webhook_payload
+		 FROM session_artifacts sa
+		 JOIN session_participants sp ON sp.session_id = sa.session_id
+		 WHERE sp.user_id = $1`,
+		userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []repository.UserArtifact
+	for rows.Next() {
+		var a repository.UserArtifact
+		if err := rows.Scan(&a.SessionID, &a.DisplayName, &a.TranscriptText, &a.WebhookPayloadJSON); err != nil {
+			return nil, err
+		}
+		if a.TranscriptText, err = r.cipher.decryptText(a.TranscriptText); err != nil {
+			return nil, err
+		}
+		if a.WebhookPayloadJSON, err = r.cipher.decryptJSON(a.WebhookPayloadJSON); err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+func (r *PostgresRepository) EraseUser(ctx context.Context, input repository.EraseUserInput) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	for _, a := range input.Artifacts {
+		text, err := r.cipher.encryptText(a.TranscriptText)
+		if err != nil {
+			return err
+		}
+		payload, err := r.cipher.encryptJSON(a.WebhookPayloadJSON)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx,
+			`UPDATE session_artifacts SET transcript_text = $2, webhook_payload = $3, updated_at = NOW() WHERE session_id = $1`,
+			a.SessionID, text, nullableJSON(payload)); err != nil {
+			return err
+		}
+	}
+	if input.Pseudonym == "" {
+		if _, err := tx.Exec(ctx, `DELETE FROM session_participants WHERE user_id = $1`, input.UserID); err != nil {
+			return err
+		}
+	} else if _, err := tx.Exec(ctx,
+		`UPDATE session_participants SET user_id = $2, display_name = $2, updated_at = NOW() WHERE user_id = $1`,
+		input.UserID, input.Pseudonym); err != nil {
+		return err
+	}
+	for _, stmt := range []string{
+		`UPDATE segment_revisions SET edited_by_user_id = $2 WHERE edited_by_user_id = $1`,
+		`UPDATE guild_phrase_hints SET created_by_user_id = $2 WHERE created_by_user_id = $1`,
+		`UPDATE guild_replacement_rules SET created_by_user_id = $2 WHERE created_by_user_id = $1`,
+		`UPDATE guild_settings SET updated_by_user_id = $2 WHERE updated_by_user_id = $1`,
+		`UPDATE audit_events SET actor_user_id = $2 WHERE actor_user_id = $1`,
+		`UPDATE sessions SET started_by_user_id = $2 WHERE started_by_user_id = $1`,
+		`UPDATE sessions SET stopped_by_user_id = $2 WHERE stopped_by_user_id = $1`,
+	} {
+		if _, err := tx.Exec(ctx, stmt, input.UserID, input.Pseudonym); err != nil {
+			return err
+		}
+	}
+	for _, stmt := range []string{
+		`DELETE FROM transcript_subscriptions WHERE user_id = $1`,
+		`DELETE FROM recording_consents WHERE user_id = $1 AND consent <> 'denied'`,
+	} {
+		if _, err := tx.Exec(ctx, stmt, input.UserID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+)
+
+func (r *PostgresRepository) SetTranscriptSubscription(ctx context.Context, guildID, userID string, subscribed bool) error {
+	if !subscribed {
+		_, err := r.pool.Exec(ctx,
+			`DELETE FROM transcript_subscriptions WHERE guild_id = $1 AND user_id = $2`,
+			guildID, userID)
+		return err
+	}
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO transcript_subscriptions (guild_id, user_id)
+		 VALUES ($1, $2)
+		 ON CONFLICT (guild_id, user_id) DO NOTHING`,
+		guildID, userID)
+	return err
+}
+
+func (r *PostgresRepository) IsTranscriptSubscribed(ctx context.Context, guildID, userID string) (bool, error) {
+	var subscribed bool
+	err := r.pool.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM transcript_subscriptions WHERE guild_id = $1 AND user_id = $2)`,
+		guildID, userID).Scan(&subscribed)
+	return subscribed, err
+}
+
+func (r *PostgresRepository) ListTranscriptSubscribers(ctx context.Context, guildID string, userIDs []string) ([]string, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+	rows, err := r.pool.Query(ctx,
+		`SELECT user_id FROM transcript_subscriptions
+		 WHERE guild_id = $1 AND user_id = ANY($2)
+		 ORDER BY user_id`,
+		guildID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		list = append(list, userID)
+	}
+	return list, rows.Err()
+}
+
+func (r *PostgresRepository) SetRecordingConsent(ctx context.Context, guildID, userID string, consent repository.RecordingConsent) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO recording_consents (guild_id, user_id, consent, updated_at)
+		 VALUES ($1, $2, $3, NOW())
+		 ON CONFLICT (guild_id, user_id) DO UPDATE
+		 SET consent = EXCLUDED.consent, updated_at = EXCLUDED.updated_at`,
+		guildID, userID, string(consent))
+	return err
+}
+
+func (r *PostgresRepository) ListRecordingConsents(ctx context.Context, guildID string, userIDs []string) (map[string]repository.RecordingConsent, error) {
+	consents := make(map[string]repository.RecordingConsent, len(userIDs))
+	if len(userIDs) == 0 {
+		return consents, nil
+	}
+	rows, err := r.pool.Query(ctx,
+		`SELECT user_id, consent FROM recording_consents
+		 WHERE guild_id = $1 AND user_id = ANY($2)`,
+		guildID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userID, consent string
+		if err := rows.Scan(&userID, &consent); err != nil {
+			return nil, err
+		}
+		consents[userID] = repository.RecordingConsent(consent)
+	}
+	return consents, rows.Err()
+}
@@ -0,0 +1,25 @@
+package summarizer
+
+import (
+	"context"
+
+	"github.com/foxseedlab/mojiokoshin/internal/config"
+	"github.com/foxseedlab/mojiokoshin/internal/summarizer"
+	"github.com/samber/do/v2"
+)
+
+func RegisterDI(injector do.Injector) {
+	do.Provide(injector, func(i do.Injector) (summarizer.Summarizer, error) {
+		c := do.MustInvoke[*config.Config](i)
+		if c.SummarizerBaseURL == "" {
+			return noopSummarizer{}, nil
+		}
+		return NewOpenAICompatibleSummarizer(c.SummarizerBaseURL, c.SummarizerAPIKey, c.SummarizerModel), nil
+	})
+}
+
+type noopSummarizer struct{}
+
+func (noopSummarizer) Summarize(context.Context, string) (*summarizer.Summary, error) {
+	return nil, summarizer.ErrDisabled
+}
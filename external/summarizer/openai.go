@@ -0,0 +1,124 @@
+package summarizer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/foxseedlab/mojiokoshin/internal/summarizer"
+)
+
+const summarySystemPrompt = `あなたは会議の議事録担当です。与えられたボイスチャットの文字起こしを読み、次の JSON だけを出力してください。
+{"summary": "会話全体の要約（日本語で5文以内）", "decisions": ["決まったこと"], "action_items": ["誰が何をするか"]}
+該当するものがない項目は空の配列にしてください。文字起こしに含まれない内容を推測で補わないでください。`
+
+var errEmptySummary = errors.New("summary response is empty")
+
+// OpenAICompatibleSummarizer は OpenAI の Chat Completions API と互換のサーバーで要約する。
+// Ollama や llama.cpp などのローカルサーバーも baseURL を変えるだけで使える。
+type OpenAICompatibleSummarizer struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+type chatCompletionRequest struct {
+	Model          string              `json:"model"`
+	Messages       []chatMessage       `json:"messages"`
+	ResponseFormat *chatResponseFormat `json:"response_format,omitempty"`
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatResponseFormat struct {
+	Type string `json:"type"`
+}
+
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+func NewOpenAICompatibleSummarizer(baseURL, apiKey, model string) summarizer.Summarizer {
+	return &OpenAICompatibleSummarizer{
+		baseURL: strings.TrimRight(strings.TrimSpace(baseURL), "/"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  &http.Client{},
+	}
+}
+
+func (s *OpenAICompatibleSummarizer) Summarize(ctx context.Context, transcript string) (*summarizer.Summary, error) {
+	b, err := json.Marshal(chatCompletionRequest{
+		Model: s.model,
+		Messages: []chatMessage{
+			{Role: "system", Content: summarySystemPrompt},
+			{Role: "user", Content: transcript},
+		},
+		ResponseFormat: &chatResponseFormat{Type: "json_object"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	content, err := s.complete(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	return parseSummary(content)
+}
+
+func (s *OpenAICompatibleSummarizer) complete(ctx context.Context, body []byte) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("chat completions API returned status %d", resp.StatusCode)
+	}
+	var out chatCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", err
+	}
+	if len(out.Choices) == 0 {
+		return "", errEmptySummary
+	}
+	return out.Choices[0].Message.Content, nil
+}
+
+// JSON モードに対応しないサーバーはコードブロックや前置きを付けて返すことがあるため、
+// 最初の { から最後の } までを取り出して読む
+func parseSummary(content string) (*summarizer.Summary, error) {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return nil, errEmptySummary
+	}
+	var summary summarizer.Summary
+	if err := json.Unmarshal([]byte(content[start:end+1]), &summary); err != nil {
+		return nil, fmt.Errorf("decode summary: %w", err)
+	}
+	if strings.TrimSpace(summary.Summary) == "" {
+		return nil, errEmptySummary
+	}
+	return &summary, nil
+}
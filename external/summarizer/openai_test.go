@@ -0,0 +1,50 @@
+package summarizer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAICompatibleSummarizer_Summarize(t *testing.T) {
+	var got chatCompletionRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Fatalf("unexpected authorization header: %q", r.Header.Get("Authorization"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		content := "```json\n{\"summary\":\"リリース日を決めた\",\"decisions\":[\"3月1日にリリース\"],\"action_items\":[\"Alice が告知文を書く\"]}\n```"
+		_ = json.NewEncoder(w).Encode(chatCompletionResponse{Choices: []struct {
+			Message chatMessage `json:"message"`
+		}{{Message: chatMessage{Role: "assistant", Content: content}}}})
+	}))
+	defer server.Close()
+
+	s := NewOpenAICompatibleSummarizer(server.URL+"/v1/", "secret", "gpt-4o-mini")
+	summary, err := s.Summarize(context.Background(), "[00:00:01] リリースは3月1日にしましょう")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary.Summary != "リリース日を決めた" || len(summary.Decisions) != 1 || len(summary.ActionItems) != 1 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	if got.Model != "gpt-4o-mini" || len(got.Messages) != 2 || got.Messages[1].Content != "[00:00:01] リリースは3月1日にしましょう" {
+		t.Fatalf("unexpected request: %+v", got)
+	}
+}
+
+func TestParseSummary_RejectsEmptySummary(t *testing.T) {
+	if _, err := parseSummary(`{"summary":"","decisions":[],"action_items":[]}`); err == nil {
+		t.Fatal("expected error for empty summary")
+	}
+	if _, err := parseSummary("要約できませんでした"); err == nil {
+		t.Fatal("expected error for non-JSON response")
+	}
+}
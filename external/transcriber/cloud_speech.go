@@ -7,11 +7,17 @@ import (
 	"log/slog"
 	"strings"
 	"sync"
+	"time"
 
+	"cloud.google.com/go/auth"
 	"cloud.google.com/go/auth/credentials"
 	speech "cloud.google.com/go/speech/apiv2"
 	speechpb "cloud.google.com/go/speech/apiv2/speechpb"
+	"github.com/foxseedlab/mojiokoshin/internal/metrics"
+	"github.com/foxseedlab/mojiokoshin/internal/tracing"
 	"github.com/foxseedlab/mojiokoshin/internal/transcriber"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/api/option"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -21,8 +27,13 @@ const (
 	speechAPIEndpointPort = 443
 	audioSampleRateHertz  = 48000
 	audioChannelCount     = 2
+	audioBytesPerSecond   = audioSampleRateHertz * audioChannelCount * 2
+	streamDrainTimeout    = 30 * time.Second
+	phraseHintBoost       = 10
 )
 
+var tracer = otel.Tracer("github.com/foxseedlab/mojiokoshin/external/transcriber")
+
 type CloudSpeechConfig struct {
 	ProjectID       string
 	CredentialsJSON string
@@ -37,9 +48,13 @@ type CloudSpeechTranscriber struct {
 	defaultLanguage string
 	location        string
 	model           string
+	metrics         metrics.Recorder
+
+	credsMu sync.Mutex
+	creds   *auth.Credentials
 }
 
-func NewCloudSpeechTranscriber(cfg CloudSpeechConfig) transcriber.Transcriber {
+func NewCloudSpeechTranscriber(cfg CloudSpeechConfig, recorder metrics.Recorder) transcriber.Transcriber {
 	location := strings.TrimSpace(cfg.Location)
 	model := strings.TrimSpace(cfg.Model)
 
@@ -49,31 +64,43 @@ func NewCloudSpeechTranscriber(cfg CloudSpeechConfig) transcriber.Transcriber {
 		defaultLanguage: cfg.Language,
 		location:        location,
 		model:           model,
+		metrics:         recorder,
 	}
 }
 
-func (t *CloudSpeechTranscriber) StartStreaming(ctx context.Context, sessionID, language string, receiver transcriber.ResultReceiver) (transcriber.StreamWriter, error) {
-	slog.Info("starting cloud speech streaming", "session_id", sessionID, "location", t.location, "language", language, "model", t.model)
+func (t *CloudSpeechTranscriber) StartStreaming(ctx context.Context, sessionID string, opts transcriber.StreamOptions, receiver transcriber.ResultReceiver) (transcriber.StreamWriter, error) {
+	// ctx はストリームが終わるまで使うため、スパンの ctx は渡さない
+	_, span := tracer.Start(ctx, "transcriber.open_stream", trace.WithAttributes(tracing.SessionID(sessionID)))
+	w, err := t.startStreaming(ctx, sessionID, opts, receiver)
+	tracing.End(span, err)
+	return w, err
+}
+
+func (t *CloudSpeechTranscriber) startStreaming(ctx context.Context, sessionID string, opts transcriber.StreamOptions, receiver transcriber.ResultReceiver) (transcriber.StreamWriter, error) {
+	language := strings.TrimSpace(opts.Language)
 	if language == "" {
 		language = t.defaultLanguage
 	}
+	model := strings.TrimSpace(opts.Model)
+	if model == "" {
+		model = t.model
+	}
+	languages := append([]string{language}, opts.AlternativeLanguages...)
+	slog.Info("starting cloud speech streaming", "session_id", sessionID, "location", t.location, "languages", languages, "model", model, "phrase_hints", len(opts.PhraseHints))
 
-	creds, err := credentials.DetectDefault(&credentials.DetectOptions{
-		CredentialsJSON: []byte(t.credentialsJSON),
-		Scopes:          []string{"https://www.googleapis.com/auth/cloud-platform"},
-	})
+	creds, err := t.credentials()
 	if err != nil {
-		return nil, fmt.Errorf("detect credentials: %w", err)
+		return nil, err
 	}
 
-	opts := []option.ClientOption{
+	clientOpts := []option.ClientOption{
 		option.WithAuthCredentials(creds),
 	}
 	if t.location != "global" {
-		opts = append(opts, option.WithEndpoint(fmt.Sprintf("%s-speech.googleapis.com:%d", t.location, speechAPIEndpointPort)))
+		clientOpts = append(clientOpts, option.WithEndpoint(fmt.Sprintf("%s-speech.googleapis.com:%d", t.location, speechAPIEndpointPort)))
 	}
 
-	client, err := speech.NewClient(ctx, opts...)
+	client, err := speech.NewClient(ctx, clientOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -90,8 +117,8 @@ func (t *CloudSpeechTranscriber) StartStreaming(ctx context.Context, sessionID,
 			StreamingRequest: &speechpb.StreamingRecognizeRequest_StreamingConfig{
 				StreamingConfig: &speechpb.StreamingRecognitionConfig{
 					Config: &speechpb.RecognitionConfig{
-						Model:         t.model,
-						LanguageCodes: []string{language},
+						Model:         model,
+						LanguageCodes: languages,
 						DecodingConfig: &speechpb.RecognitionConfig_ExplicitDecodingConfig{
 							ExplicitDecodingConfig: &speechpb.ExplicitDecodingConfig{
 								Encoding:          speechpb.ExplicitDecodingConfig_LINEAR16,
@@ -99,7 +126,14 @@ func (t *CloudSpeechTranscriber) StartStreaming(ctx context.Context, sessionID,
 								AudioChannelCount: audioChannelCount,
 							},
 						},
-						Features: &speechpb.RecognitionFeatures{},
+						Features: &speechpb.RecognitionFeatures{
+							EnableWordTimeOffsets:      true,
+							EnableWordConfidence:       true,
+							EnableAutomaticPunctuation: opts.Features.AutomaticPunctuation,
+							EnableSpokenPunctuation:    opts.Features.SpokenPunctuation,
+							ProfanityFilter:            opts.Features.ProfanityFilter,
+						},
+						Adaptation: speechAdaptation(opts.PhraseHints),
 					},
 					StreamingFeatures: &speechpb.StreamingRecognitionFeatures{InterimResults: true},
 				},
@@ -116,8 +150,13 @@ func (t *CloudSpeechTranscriber) StartStreaming(ctx context.Context, sessionID,
 	w := &streamWriter{
 		stream:   stream,
 		receiver: receiver,
-		newStreamFn: func() (speechpb.Speech_StreamingRecognizeClient, error) {
-			next, err := client.StreamingRecognize(ctx)
+		onReconnected: func() {
+			t.metrics.STTReconnected(opts.GuildID)
+		},
+		newStreamFn: func() (next speechpb.Speech_StreamingRecognizeClient, err error) {
+			_, span := tracer.Start(ctx, "transcriber.reconnect_stream", trace.WithAttributes(tracing.SessionID(sessionID)))
+			defer func() { tracing.End(span, err) }()
+			next, err = client.StreamingRecognize(ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -136,13 +175,64 @@ func (t *CloudSpeechTranscriber) StartStreaming(ctx context.Context, sessionID,
 	return w, nil
 }
 
+// Ping はアクセストークンを取得できるかで、認証情報が有効かを確かめる。トークンは期限まで使い回される
+func (t *CloudSpeechTranscriber) Ping(ctx context.Context) error {
+	creds, err := t.credentials()
+	if err != nil {
+		return err
+	}
+	if _, err := creds.Token(ctx); err != nil {
+		return fmt.Errorf("fetch access token: %w", err)
+	}
+	return nil
+}
+
+func (t *CloudSpeechTranscriber) credentials() (*auth.Credentials, error) {
+	t.credsMu.Lock()
+	defer t.credsMu.Unlock()
+	if t.creds != nil {
+		return t.creds, nil
+	}
+	creds, err := credentials.DetectDefault(&credentials.DetectOptions{
+		CredentialsJSON: []byte(t.credentialsJSON),
+		Scopes:          []string{"https://www.googleapis.com/auth/cloud-platform"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("detect credentials: %w", err)
+	}
+	t.creds = creds
+	return creds, nil
+}
+
+func speechAdaptation(hints []string) *speechpb.SpeechAdaptation {
+	if len(hints) == 0 {
+		return nil
+	}
+	phrases := make([]*speechpb.PhraseSet_Phrase, 0, len(hints))
+	for _, h := range hints {
+		phrases = append(phrases, &speechpb.PhraseSet_Phrase{Value: h})
+	}
+	return &speechpb.SpeechAdaptation{
+		PhraseSets: []*speechpb.SpeechAdaptation_AdaptationPhraseSet{{
+			Value: &speechpb.SpeechAdaptation_AdaptationPhraseSet_InlinePhraseSet{
+				InlinePhraseSet: &speechpb.PhraseSet{Phrases: phrases, Boost: phraseHintBoost},
+			},
+		}},
+	}
+}
+
 type streamWriter struct {
-	mu          sync.Mutex
-	closed      bool
-	stream      speechpb.Speech_StreamingRecognizeClient
-	receiver    transcriber.ResultReceiver
+	mu           sync.Mutex
+	closed       bool
+	stream       speechpb.Speech_StreamingRecognizeClient
+	receiver     transcriber.ResultReceiver
+	receiverDone chan struct{}
+	// 書き込み済みの音声の長さ。再接続後のストリームはこの位置を基準にオフセットを補正する
+	written     time.Duration
 	newStreamFn func() (speechpb.Speech_StreamingRecognizeClient, error)
 	closeFn     func() error
+	// 再接続に成功するたびに呼ぶ
+	onReconnected func()
 }
 
 func (w *streamWriter) Write(pcm []byte) error {
@@ -164,11 +254,18 @@ func (w *streamWriter) Write(pcm []byte) error {
 		if err := w.reconnectLocked(); err != nil {
 			return fmt.Errorf("reconnect stream: %w", err)
 		}
-		return w.stream.Send(req)
+		if err := w.stream.Send(req); err != nil {
+			return err
+		}
 	}
+	w.written += pcmDuration(len(pcm))
 	return nil
 }
 
+func pcmDuration(n int) time.Duration {
+	return time.Duration(n) * time.Second / audioBytesPerSecond
+}
+
 func (w *streamWriter) Close() error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -180,9 +277,25 @@ func (w *streamWriter) Close() error {
 		_ = w.closeFn()
 		return err
 	}
+	w.waitReceiverDrainedLocked()
 	return w.closeFn()
 }
 
+// CloseSend 後もサーバーは確定結果を返し続けるため、受信ループが終わるまで待ってから接続を閉じる。
+// 実行中セッションの停止時は context がキャンセル済みなので即座に抜ける。
+func (w *streamWriter) waitReceiverDrainedLocked() {
+	if w.receiverDone == nil {
+		return
+	}
+	timer := time.NewTimer(streamDrainTimeout)
+	defer timer.Stop()
+	select {
+	case <-w.receiverDone:
+	case <-timer.C:
+		slog.Warn("timed out waiting for transcriber results to drain", "timeout", streamDrainTimeout.String())
+	}
+}
+
 func (w *streamWriter) reconnectLocked() error {
 	slog.Warn("transcriber stream aborted; reconnecting")
 	_ = w.stream.CloseSend()
@@ -193,12 +306,17 @@ func (w *streamWriter) reconnectLocked() error {
 	}
 	w.stream = next
 	w.startReceiver(next, w.receiver)
-	slog.Info("transcriber stream reconnected")
+	w.onReconnected()
+	slog.Info("transcriber stream reconnected", "stream_base_offset_ms", w.written.Milliseconds())
 	return nil
 }
 
 func (w *streamWriter) startReceiver(stream speechpb.Speech_StreamingRecognizeClient, receiver transcriber.ResultReceiver) {
+	done := make(chan struct{})
+	w.receiverDone = done
+	offsets := &streamOffsets{base: w.written, lastFinalEnd: w.written}
 	go func() {
+		defer close(done)
 		for {
 			resp, err := stream.Recv()
 			if err != nil {
@@ -217,12 +335,61 @@ func (w *streamWriter) startReceiver(stream speechpb.Speech_StreamingRecognizeCl
 				if len(result.GetAlternatives()) == 0 {
 					continue
 				}
-				receiver.OnResult(i, result.GetAlternatives()[0].GetTranscript(), result.GetIsFinal())
+				receiver.OnResult(offsets.result(i, result))
 			}
 		}
 	}()
 }
 
+// streamOffsets は接続ごとに 0 から始まる Cloud Speech のオフセットを、
+// StartStreaming からの通算位置に変換する。
+type streamOffsets struct {
+	base         time.Duration
+	lastFinalEnd time.Duration
+}
+
+func (o *streamOffsets) result(index int, result *speechpb.StreamingRecognitionResult) transcriber.Result {
+	alt := result.GetAlternatives()[0]
+	end := o.base + result.GetResultEndOffset().AsDuration()
+	// 単語ごとの時刻が返らないモデルでは、直前の確定結果の終わりを開始位置とみなす
+	start := o.lastFinalEnd
+	if words := alt.GetWords(); len(words) > 0 && words[0].GetStartOffset() != nil {
+		start = o.base + words[0].GetStartOffset().AsDuration()
+	}
+	if start > end {
+		start = end
+	}
+	if result.GetIsFinal() {
+		o.lastFinalEnd = end
+	}
+	return transcriber.Result{
+		SegmentIndex: index,
+		Text:         alt.GetTranscript(),
+		IsFinal:      result.GetIsFinal(),
+		StartOffset:  start,
+		EndOffset:    end,
+		Confidence:   float64(alt.GetConfidence()),
+		Words:        o.words(alt.GetWords()),
+		Language:     result.GetLanguageCode(),
+	}
+}
+
+func (o *streamOffsets) words(words []*speechpb.WordInfo) []transcriber.Word {
+	if len(words) == 0 {
+		return nil
+	}
+	out := make([]transcriber.Word, 0, len(words))
+	for _, w := range words {
+		out = append(out, transcriber.Word{
+			Text:        w.GetWord(),
+			StartOffset: o.base + w.GetStartOffset().AsDuration(),
+			EndOffset:   o.base + w.GetEndOffset().AsDuration(),
+			Confidence:  float64(w.GetConfidence()),
+		})
+	}
+	return out
+}
+
 func isReconnectableStreamError(err error) bool {
 	if err == io.EOF || strings.Contains(strings.ToLower(err.Error()), "eof") {
 		return true
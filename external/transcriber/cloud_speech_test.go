@@ -0,0 +1,228 @@
+package transcriber
+
+import (
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	speechpb "cloud.google.com/go/speech/apiv2/speechpb"
+	"github.com/foxseedlab/mojiokoshin/internal/transcriber"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func recognitionResult(text string, isFinal bool, end time.Duration, wordStarts ...time.Duration) *speechpb.StreamingRecognitionResult {
+	alt := &speechpb.SpeechRecognitionAlternative{Transcript: text}
+	for _, start := range wordStarts {
+		alt.Words = append(alt.Words, &speechpb.WordInfo{
+			Word:        text,
+			StartOffset: durationpb.New(start),
+			EndOffset:   durationpb.New(end),
+		})
+	}
+	return &speechpb.StreamingRecognitionResult{
+		Alternatives:    []*speechpb.SpeechRecognitionAlternative{alt},
+		IsFinal:         isFinal,
+		ResultEndOffset: durationpb.New(end),
+	}
+}
+
+func TestStreamOffsets_Result(t *testing.T) {
+	tests := []struct {
+		name         string
+		offsets      streamOffsets
+		result       *speechpb.StreamingRecognitionResult
+		wantStart    time.Duration
+		wantEnd      time.Duration
+		wantLastEnd  time.Duration
+		wantWordFrom time.Duration
+	}{
+		{
+			name:         "first stream uses word offsets",
+			offsets:      streamOffsets{},
+			result:       recognitionResult("hello", true, 2*time.Second, 500*time.Millisecond),
+			wantStart:    500 * time.Millisecond,
+			wantEnd:      2 * time.Second,
+			wantLastEnd:  2 * time.Second,
+			wantWordFrom: 500 * time.Millisecond,
+		},
+		{
+			name:         "stream after reconnect adds base",
+			offsets:      streamOffsets{base: 5 * time.Minute, lastFinalEnd: 5 * time.Minute},
+			result:       recognitionResult("hello", true, 2*time.Second, 500*time.Millisecond),
+			wantStart:    5*time.Minute + 500*time.Millisecond,
+			wantEnd:      5*time.Minute + 2*time.Second,
+			wantLastEnd:  5*time.Minute + 2*time.Second,
+			wantWordFrom: 5*time.Minute + 500*time.Millisecond,
+		},
+		{
+			name:        "result without words starts at last final end",
+			offsets:     streamOffsets{base: 5 * time.Minute, lastFinalEnd: 5*time.Minute + time.Second},
+			result:      recognitionResult("hello", true, 3*time.Second),
+			wantStart:   5*time.Minute + time.Second,
+			wantEnd:     5*time.Minute + 3*time.Second,
+			wantLastEnd: 5*time.Minute + 3*time.Second,
+		},
+		{
+			name:        "interim result keeps last final end",
+			offsets:     streamOffsets{lastFinalEnd: time.Second},
+			result:      recognitionResult("hel", false, 3*time.Second),
+			wantStart:   time.Second,
+			wantEnd:     3 * time.Second,
+			wantLastEnd: time.Second,
+		},
+		{
+			name:        "start never passes end",
+			offsets:     streamOffsets{lastFinalEnd: 4 * time.Second},
+			result:      recognitionResult("hello", true, 3*time.Second),
+			wantStart:   3 * time.Second,
+			wantEnd:     3 * time.Second,
+			wantLastEnd: 3 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offsets := tt.offsets
+			got := offsets.result(0, tt.result)
+			if got.StartOffset != tt.wantStart || got.EndOffset != tt.wantEnd {
+				t.Fatalf("offsets = %v-%v, want %v-%v", got.StartOffset, got.EndOffset, tt.wantStart, tt.wantEnd)
+			}
+			if offsets.lastFinalEnd != tt.wantLastEnd {
+				t.Fatalf("lastFinalEnd = %v, want %v", offsets.lastFinalEnd, tt.wantLastEnd)
+			}
+			if tt.wantWordFrom != 0 && (len(got.Words) != 1 || got.Words[0].StartOffset != tt.wantWordFrom) {
+				t.Fatalf("unexpected words: %+v", got.Words)
+			}
+		})
+	}
+}
+
+// fakeRecognizeStream は送信した回数に応じて失敗させ、用意した応答を CloseSend まで返す
+type fakeRecognizeStream struct {
+	grpc.ClientStream
+
+	mu        sync.Mutex
+	sent      int
+	sendErrAt int
+	sendErr   error
+	responses chan *speechpb.StreamingRecognizeResponse
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newFakeRecognizeStream(responses ...*speechpb.StreamingRecognizeResponse) *fakeRecognizeStream {
+	ch := make(chan *speechpb.StreamingRecognizeResponse, len(responses))
+	for _, resp := range responses {
+		ch <- resp
+	}
+	return &fakeRecognizeStream{responses: ch, closed: make(chan struct{})}
+}
+
+func (s *fakeRecognizeStream) Send(*speechpb.StreamingRecognizeRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent++
+	if s.sendErr != nil && s.sent >= s.sendErrAt {
+		return s.sendErr
+	}
+	return nil
+}
+
+func (s *fakeRecognizeStream) Recv() (*speechpb.StreamingRecognizeResponse, error) {
+	select {
+	case resp := <-s.responses:
+		return resp, nil
+	default:
+	}
+	select {
+	case resp := <-s.responses:
+		return resp, nil
+	case <-s.closed:
+		return nil, io.EOF
+	}
+}
+
+func (s *fakeRecognizeStream) CloseSend() error {
+	s.closeOnce.Do(func() { close(s.closed) })
+	return nil
+}
+
+type recordingReceiver struct {
+	mu      sync.Mutex
+	results []transcriber.Result
+	errs    []error
+}
+
+func (r *recordingReceiver) OnResult(result transcriber.Result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, result)
+}
+
+func (r *recordingReceiver) OnError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errs = append(r.errs, err)
+}
+
+func TestStreamWriter_ReconnectOffsetsResultsByWrittenAudio(t *testing.T) {
+	first := newFakeRecognizeStream()
+	first.sendErrAt = 2
+	first.sendErr = status.Error(codes.Aborted, "Exceeded maximum allowed stream duration of 305 seconds. max duration of 5 minutes")
+	second := newFakeRecognizeStream(&speechpb.StreamingRecognizeResponse{
+		Results: []*speechpb.StreamingRecognitionResult{
+			recognitionResult("hello", true, 800*time.Millisecond, 200*time.Millisecond),
+		},
+	}, &speechpb.StreamingRecognizeResponse{
+		Results: []*speechpb.StreamingRecognitionResult{
+			recognitionResult("world", true, 1500*time.Millisecond),
+		},
+	})
+	receiver := &recordingReceiver{}
+	reconnects := 0
+	w := &streamWriter{
+		stream:   first,
+		receiver: receiver,
+		newStreamFn: func() (speechpb.Speech_StreamingRecognizeClient, error) {
+			return second, nil
+		},
+		closeFn:       func() error { return nil },
+		onReconnected: func() { reconnects++ },
+	}
+	w.startReceiver(first, receiver)
+
+	oneSecond := make([]byte, audioBytesPerSecond)
+	if err := w.Write(oneSecond); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.Write(oneSecond); err != nil {
+		t.Fatalf("unexpected error after reconnect: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+
+	if reconnects != 1 || w.stream != second {
+		t.Fatalf("expected one reconnect, got %d", reconnects)
+	}
+	if w.written != 2*time.Second {
+		t.Fatalf("written = %v", w.written)
+	}
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+	if len(receiver.errs) != 0 {
+		t.Fatalf("unexpected errors: %v", receiver.errs)
+	}
+	if len(receiver.results) != 2 {
+		t.Fatalf("unexpected results: %+v", receiver.results)
+	}
+	if got := receiver.results[0]; got.Text != "hello" || got.StartOffset != 1200*time.Millisecond || got.EndOffset != 1800*time.Millisecond {
+		t.Fatalf("unexpected first result after reconnect: %+v", got)
+	}
+	if got := receiver.results[1]; got.Text != "world" || got.StartOffset != 1800*time.Millisecond || got.EndOffset != 2500*time.Millisecond {
+		t.Fatalf("unexpected wordless result after reconnect: %+v", got)
+	}
+}
@@ -2,6 +2,7 @@ package transcriber
 
 import (
 	"github.com/foxseedlab/mojiokoshin/internal/config"
+	"github.com/foxseedlab/mojiokoshin/internal/metrics"
 	"github.com/foxseedlab/mojiokoshin/internal/transcriber"
 	"github.com/samber/do/v2"
 )
@@ -15,6 +16,6 @@ func RegisterDI(injector do.Injector) {
 			Language:        c.DefaultTranscribeLanguage,
 			Location:        c.GoogleCloudSpeechLocation,
 			Model:           c.GoogleCloudSpeechModel,
-		}), nil
+		}, do.MustInvoke[metrics.Recorder](i)), nil
 	})
 }
@@ -0,0 +1,101 @@
+package translator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"cloud.google.com/go/auth/credentials"
+	"cloud.google.com/go/auth/httptransport"
+	"github.com/foxseedlab/mojiokoshin/internal/translator"
+)
+
+const cloudTranslationEndpoint = "https://translation.googleapis.com"
+
+var errEmptyTranslation = errors.New("translation response is empty")
+
+type CloudTranslationConfig struct {
+	ProjectID       string
+	CredentialsJSON string
+}
+
+type CloudTranslationTranslator struct {
+	projectID string
+	endpoint  string
+	client    *http.Client
+}
+
+type cloudTranslateRequest struct {
+	Contents           []string `json:"contents"`
+	MimeType           string   `json:"mimeType"`
+	SourceLanguageCode string   `json:"sourceLanguageCode,omitempty"`
+	TargetLanguageCode string   `json:"targetLanguageCode"`
+}
+
+type cloudTranslateResponse struct {
+	Translations []struct {
+		TranslatedText string `json:"translatedText"`
+	} `json:"translations"`
+}
+
+func NewCloudTranslationTranslator(cfg CloudTranslationConfig) (translator.Translator, error) {
+	creds, err := credentials.DetectDefault(&credentials.DetectOptions{
+		CredentialsJSON: []byte(cfg.CredentialsJSON),
+		Scopes:          []string{"https://www.googleapis.com/auth/cloud-translation"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("detect credentials: %w", err)
+	}
+	client, err := httptransport.NewClient(&httptransport.Options{Credentials: creds})
+	if err != nil {
+		return nil, fmt.Errorf("create translation client: %w", err)
+	}
+	return &CloudTranslationTranslator{
+		projectID: cfg.ProjectID,
+		endpoint:  cloudTranslationEndpoint,
+		client:    client,
+	}, nil
+}
+
+func (t *CloudTranslationTranslator) Translate(ctx context.Context, text, sourceLanguage, targetLanguage string) (string, error) {
+	b, err := json.Marshal(cloudTranslateRequest{
+		Contents:           []string{text},
+		MimeType:           "text/plain",
+		SourceLanguageCode: sourceLanguage,
+		TargetLanguageCode: targetLanguage,
+	})
+	if err != nil {
+		return "", err
+	}
+	url := fmt.Sprintf("%s/v3/projects/%s/locations/global:translateText", t.endpoint, t.projectID)
+	var resp cloudTranslateResponse
+	if err := postJSON(ctx, t.client, url, b, &resp); err != nil {
+		return "", err
+	}
+	if len(resp.Translations) == 0 {
+		return "", errEmptyTranslation
+	}
+	return resp.Translations[0].TranslatedText, nil
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("translation API returned status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,32 @@
+package translator
+
+import (
+	"context"
+
+	"github.com/foxseedlab/mojiokoshin/internal/config"
+	"github.com/foxseedlab/mojiokoshin/internal/translator"
+	"github.com/samber/do/v2"
+)
+
+func RegisterDI(injector do.Injector) {
+	do.Provide(injector, func(i do.Injector) (translator.Translator, error) {
+		c := do.MustInvoke[*config.Config](i)
+		switch c.TranslationProvider {
+		case config.TranslationProviderGoogle:
+			return NewCloudTranslationTranslator(CloudTranslationConfig{
+				ProjectID:       c.GoogleCloudProjectID,
+				CredentialsJSON: c.GoogleCloudCredentialsJSON,
+			})
+		case config.TranslationProviderLibreTranslate:
+			return NewLibreTranslateTranslator(c.LibreTranslateURL, c.LibreTranslateAPIKey), nil
+		default:
+			return noopTranslator{}, nil
+		}
+	})
+}
+
+type noopTranslator struct{}
+
+func (noopTranslator) Translate(context.Context, string, string, string) (string, error) {
+	return "", translator.ErrDisabled
+}
@@ -0,0 +1,66 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/foxseedlab/mojiokoshin/internal/translator"
+)
+
+const libreTranslateAutoSource = "auto"
+
+type LibreTranslateTranslator struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+type libreTranslateRequest struct {
+	Q      string `json:"q"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Format string `json:"format"`
+	APIKey string `json:"api_key,omitempty"`
+}
+
+type libreTranslateResponse struct {
+	TranslatedText string `json:"translatedText"`
+}
+
+func NewLibreTranslateTranslator(baseURL, apiKey string) translator.Translator {
+	return &LibreTranslateTranslator{
+		baseURL: strings.TrimRight(strings.TrimSpace(baseURL), "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{},
+	}
+}
+
+// LibreTranslate は ja-JP のような地域付きのコードを受け付けないため、言語部分だけを送る
+func (t *LibreTranslateTranslator) Translate(ctx context.Context, text, sourceLanguage, targetLanguage string) (string, error) {
+	source := baseLanguage(sourceLanguage)
+	if source == "" {
+		source = libreTranslateAutoSource
+	}
+	b, err := json.Marshal(libreTranslateRequest{
+		Q:      text,
+		Source: source,
+		Target: baseLanguage(targetLanguage),
+		Format: "text",
+		APIKey: t.apiKey,
+	})
+	if err != nil {
+		return "", err
+	}
+	var resp libreTranslateResponse
+	if err := postJSON(ctx, t.client, t.baseURL+"/translate", b, &resp); err != nil {
+		return "", err
+	}
+	return resp.TranslatedText, nil
+}
+
+func baseLanguage(code string) string {
+	base, _, _ := strings.Cut(strings.TrimSpace(code), "-")
+	return strings.ToLower(base)
+}
@@ -0,0 +1,69 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLibreTranslateTranslator_Translate(t *testing.T) {
+	var got libreTranslateRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/translate" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		_ = json.NewEncoder(w).Encode(libreTranslateResponse{TranslatedText: "Good morning"})
+	}))
+	defer server.Close()
+
+	tr := NewLibreTranslateTranslator(server.URL+"/", "secret")
+	translated, err := tr.Translate(context.Background(), "おはよう", "", "en-US")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if translated != "Good morning" {
+		t.Fatalf("unexpected translation: %q", translated)
+	}
+	if got.Source != libreTranslateAutoSource || got.Target != "en" || got.APIKey != "secret" || got.Q != "おはよう" {
+		t.Fatalf("unexpected request: %+v", got)
+	}
+}
+
+func TestCloudTranslationTranslator_Translate(t *testing.T) {
+	var got cloudTranslateRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/projects/project-1/locations/global:translateText" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"translations":[{"translatedText":"Hello"}]}`))
+	}))
+	defer server.Close()
+
+	tr := &CloudTranslationTranslator{projectID: "project-1", endpoint: server.URL, client: server.Client()}
+	translated, err := tr.Translate(context.Background(), "こんにちは", "ja-JP", "en")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if translated != "Hello" || got.SourceLanguageCode != "ja-JP" || got.TargetLanguageCode != "en" {
+		t.Fatalf("unexpected translation %q for request %+v", translated, got)
+	}
+}
+
+func TestLibreTranslateTranslator_Non2xx(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	if _, err := NewLibreTranslateTranslator(server.URL, "").Translate(context.Background(), "text", "ja", "en"); err == nil {
+		t.Fatal("expected error for non-2xx response")
+	}
+}
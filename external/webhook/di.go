@@ -2,6 +2,7 @@ package webhook
 
 import (
 	"github.com/foxseedlab/mojiokoshin/internal/config"
+	"github.com/foxseedlab/mojiokoshin/internal/metrics"
 	"github.com/foxseedlab/mojiokoshin/internal/webhook"
 	"github.com/samber/do/v2"
 )
@@ -9,6 +10,6 @@ import (
 func RegisterDI(injector do.Injector) {
 	do.Provide(injector, func(i do.Injector) (webhook.Sender, error) {
 		c := do.MustInvoke[*config.Config](i)
-		return NewHTTPSender(c.TranscriptWebhookURL), nil
+		return NewHTTPSender(c.TranscriptWebhookURL, do.MustInvoke[metrics.Recorder](i)), nil
 	})
 }
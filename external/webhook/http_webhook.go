@@ -7,18 +7,21 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/foxseedlab/mojiokoshin/internal/metrics"
 	"github.com/foxseedlab/mojiokoshin/internal/webhook"
 )
 
 type HTTPSender struct {
 	webhookURL string
 	client     *http.Client
+	metrics    metrics.Recorder
 }
 
-func NewHTTPSender(webhookURL string) webhook.Sender {
+func NewHTTPSender(webhookURL string, recorder metrics.Recorder) webhook.Sender {
 	return &HTTPSender{
 		webhookURL: webhookURL,
 		client:     &http.Client{},
+		metrics:    recorder,
 	}
 }
 
@@ -26,6 +29,12 @@ func (s *HTTPSender) SendTranscript(ctx context.Context, payload webhook.Transcr
 	if s.webhookURL == "" {
 		return nil
 	}
+	err := s.send(ctx, payload)
+	s.metrics.WebhookSent(payload.DiscordServerID, err)
+	return err
+}
+
+func (s *HTTPSender) send(ctx context.Context, payload webhook.TranscriptWebhookPayload) error {
 
 	b, err := json.Marshal(payload)
 	if err != nil {
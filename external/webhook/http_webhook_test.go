@@ -8,11 +8,12 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/foxseedlab/mojiokoshin/internal/metrics"
 	internalwebhook "github.com/foxseedlab/mojiokoshin/internal/webhook"
 )
 
 func TestSendTranscript_EmptyWebhookURL(t *testing.T) {
-	sender := NewHTTPSender("")
+	sender := NewHTTPSender("", metrics.Nop)
 	if err := sender.SendTranscript(context.Background(), internalwebhook.TranscriptWebhookPayload{}); err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -67,7 +68,7 @@ func TestSendTranscript_Success(t *testing.T) {
 		Transcript: "hello world",
 	}
 
-	sender := NewHTTPSender(server.URL)
+	sender := NewHTTPSender(server.URL, metrics.Nop)
 	if err := sender.SendTranscript(context.Background(), payload); err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -88,7 +89,7 @@ func TestSendTranscript_Non2xx(t *testing.T) {
 	}))
 	defer server.Close()
 
-	sender := NewHTTPSender(server.URL)
+	sender := NewHTTPSender(server.URL, metrics.Nop)
 	if err := sender.SendTranscript(context.Background(), internalwebhook.TranscriptWebhookPayload{SessionID: "session-1"}); err == nil {
 		t.Fatal("expected error for non-2xx response")
 	}
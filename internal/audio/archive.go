@@ -0,0 +1,15 @@
+package audio
+
+import (
+	"errors"
+	"io"
+)
+
+var ErrArchiveNotFound = errors.New("audio archive not found")
+
+type Archive interface {
+	Create(sessionID string) (io.WriteCloser, error)
+	Open(sessionID string) (io.ReadCloser, error)
+	// 保存されていないセッションでもエラーにしない
+	Delete(sessionID string) error
+}
@@ -0,0 +1,66 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+)
+
+const (
+	DefaultVADThresholdDBFS = -45.0
+	silenceFloorDBFS        = -120.0
+)
+
+type VADConfig struct {
+	Enabled       bool
+	ThresholdDBFS float64
+	Hangover      time.Duration
+}
+
+// VAD は 16bit リトルエンディアン PCM のフレームを音量で判定し、
+// 発話が途切れた後も Hangover の間はフレームを通して語尾の欠けを防ぐ。
+type VAD struct {
+	cfg            VADConfig
+	hangoverFrames int
+	remaining      int
+}
+
+func NewVAD(cfg VADConfig, frameDuration time.Duration) *VAD {
+	hangoverFrames := 0
+	if frameDuration > 0 && cfg.Hangover > 0 {
+		hangoverFrames = int((cfg.Hangover + frameDuration - 1) / frameDuration)
+	}
+	return &VAD{cfg: cfg, hangoverFrames: hangoverFrames}
+}
+
+func (v *VAD) Accept(pcm []byte) bool {
+	if !v.cfg.Enabled {
+		return true
+	}
+	if FrameLevelDBFS(pcm) >= v.cfg.ThresholdDBFS {
+		v.remaining = v.hangoverFrames
+		return true
+	}
+	if v.remaining > 0 {
+		v.remaining--
+		return true
+	}
+	return false
+}
+
+func FrameLevelDBFS(pcm []byte) float64 {
+	samples := len(pcm) / 2
+	if samples == 0 {
+		return silenceFloorDBFS
+	}
+	var sum float64
+	for i := 0; i+1 < len(pcm); i += 2 {
+		s := float64(int16(binary.LittleEndian.Uint16(pcm[i:])))
+		sum += s * s
+	}
+	rms := math.Sqrt(sum / float64(samples))
+	if rms == 0 {
+		return silenceFloorDBFS
+	}
+	return math.Max(20*math.Log10(rms/math.MaxInt16), silenceFloorDBFS)
+}
@@ -0,0 +1,58 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+)
+
+func pcmFrame(amplitude int16, samples int) []byte {
+	buf := make([]byte, samples*2)
+	for i := 0; i < samples; i++ {
+		v := amplitude
+		if i%2 == 1 {
+			v = -amplitude
+		}
+		binary.LittleEndian.PutUint16(buf[i*2:], uint16(v))
+	}
+	return buf
+}
+
+func TestFrameLevelDBFS(t *testing.T) {
+	if got := FrameLevelDBFS(pcmFrame(0, 960)); got != silenceFloorDBFS {
+		t.Fatalf("expected silence floor for zero frame, got %f", got)
+	}
+	if got := FrameLevelDBFS(pcmFrame(math.MaxInt16, 960)); math.Abs(got) > 0.01 {
+		t.Fatalf("expected full scale frame to be ~0 dBFS, got %f", got)
+	}
+	if got := FrameLevelDBFS(pcmFrame(328, 960)); math.Abs(got+40) > 0.1 {
+		t.Fatalf("expected ~-40 dBFS, got %f", got)
+	}
+}
+
+func TestVAD_DropsSilenceAfterHangover(t *testing.T) {
+	vad := NewVAD(VADConfig{Enabled: true, ThresholdDBFS: -45, Hangover: 40 * time.Millisecond}, 20*time.Millisecond)
+	speech := pcmFrame(3000, 960)
+	noise := pcmFrame(20, 960)
+
+	if vad.Accept(noise) {
+		t.Fatal("expected leading noise to be dropped")
+	}
+	if !vad.Accept(speech) {
+		t.Fatal("expected speech to pass")
+	}
+	if !vad.Accept(noise) || !vad.Accept(noise) {
+		t.Fatal("expected hangover frames to pass after speech")
+	}
+	if vad.Accept(noise) {
+		t.Fatal("expected noise after hangover to be dropped")
+	}
+}
+
+func TestVAD_DisabledPassesEverything(t *testing.T) {
+	vad := NewVAD(VADConfig{Enabled: false, ThresholdDBFS: -45}, 20*time.Millisecond)
+	if !vad.Accept(pcmFrame(0, 960)) {
+		t.Fatal("expected disabled VAD to pass silent frames")
+	}
+}
@@ -1,7 +1,11 @@
 package config
 
 import (
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 	_ "time/tzdata"
 )
@@ -22,9 +26,37 @@ type Config struct {
 	DiscordCountOtherBots      bool
 	TranscriptTimezone         string
 	TranscriptWebhookURL       string
+	TranscriptLowConfidence    float64
 	DiscordShowPoweredBy       bool
+	AudioArchiveDir            string
+	VADEnabled                 bool
+	VADThresholdDBFS           float64
+	VADHangoverMS              int
+	RedactPhoneNumbers         bool
+	RedactEmails               bool
+	RedactCreditCards          bool
+	RedactBannedWords          []string
+	RedactStoreOriginal        bool
+	TranslationProvider        string
+	TranslationTargetLanguages []string
+	LibreTranslateURL          string
+	LibreTranslateAPIKey       string
+	SummarizerBaseURL          string
+	SummarizerAPIKey           string
+	SummarizerModel            string
+	SummarizerTimeoutSec       int
+	RetentionIntervalMin       int
+	RetentionBatchSize         int
+	EncryptionKeys             []string
+	HTTPAddr                   string
+	OTLPEndpoint               string
 }
 
+const (
+	TranslationProviderGoogle         = "google"
+	TranslationProviderLibreTranslate = "libretranslate"
+)
+
 func (c *Config) Validate() error {
 	for _, req := range c.requiredFieldChecks() {
 		if req.value == "" {
@@ -37,6 +69,24 @@ func (c *Config) Validate() error {
 	if c.MaxTranscribeDurationMin <= 0 {
 		return fmt.Errorf("MAX_TRANSCRIBE_DURATION_MIN must be positive, got %d", c.MaxTranscribeDurationMin)
 	}
+	if err := c.validateVAD(); err != nil {
+		return err
+	}
+	if err := c.validateTranslation(); err != nil {
+		return err
+	}
+	if err := c.validateSummarizer(); err != nil {
+		return err
+	}
+	if err := c.validateRetention(); err != nil {
+		return err
+	}
+	if _, err := ParseEncryptionKeys(c.EncryptionKeys); err != nil {
+		return fmt.Errorf("ENCRYPTION_KEYS is invalid: %w", err)
+	}
+	if c.TranscriptLowConfidence < 0 || c.TranscriptLowConfidence > 1 {
+		return fmt.Errorf("TRANSCRIPT_LOW_CONFIDENCE_THRESHOLD must be between 0 and 1, got %g", c.TranscriptLowConfidence)
+	}
 	if c.TranscriptTimezone == "" {
 		return fmt.Errorf("TRANSCRIPT_TIMEZONE is required")
 	}
@@ -46,6 +96,99 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+func (c *Config) validateVAD() error {
+	if !c.VADEnabled {
+		return nil
+	}
+	if c.VADThresholdDBFS >= 0 || c.VADThresholdDBFS < -120 {
+		return fmt.Errorf("VAD_THRESHOLD_DBFS must be between -120 and 0, got %g", c.VADThresholdDBFS)
+	}
+	if c.VADHangoverMS < 0 {
+		return fmt.Errorf("VAD_HANGOVER_MS must not be negative, got %d", c.VADHangoverMS)
+	}
+	return nil
+}
+
+func (c *Config) validateTranslation() error {
+	switch c.TranslationProvider {
+	case "":
+		if len(c.TranslationTargetLanguages) > 0 {
+			return fmt.Errorf("TRANSLATION_PROVIDER is required when TRANSLATION_TARGET_LANGUAGES is set")
+		}
+		return nil
+	case TranslationProviderGoogle:
+	case TranslationProviderLibreTranslate:
+		if c.LibreTranslateURL == "" {
+			return fmt.Errorf("LIBRETRANSLATE_URL is required when TRANSLATION_PROVIDER=%s", TranslationProviderLibreTranslate)
+		}
+	default:
+		return fmt.Errorf("TRANSLATION_PROVIDER must be %q or %q, got %q", TranslationProviderGoogle, TranslationProviderLibreTranslate, c.TranslationProvider)
+	}
+	if len(c.TranslationTargetLanguages) == 0 {
+		return fmt.Errorf("TRANSLATION_TARGET_LANGUAGES is required when TRANSLATION_PROVIDER is set")
+	}
+	return nil
+}
+
+func (c *Config) validateSummarizer() error {
+	if c.SummarizerBaseURL == "" {
+		return nil
+	}
+	if c.SummarizerModel == "" {
+		return fmt.Errorf("SUMMARIZER_MODEL is required when SUMMARIZER_BASE_URL is set")
+	}
+	if c.SummarizerTimeoutSec <= 0 {
+		return fmt.Errorf("SUMMARIZER_TIMEOUT_SEC must be positive, got %d", c.SummarizerTimeoutSec)
+	}
+	return nil
+}
+
+func (c *Config) validateRetention() error {
+	if c.RetentionIntervalMin < 0 {
+		return fmt.Errorf("RETENTION_INTERVAL_MIN must not be negative, got %d", c.RetentionIntervalMin)
+	}
+	if c.RetentionIntervalMin > 0 && c.RetentionBatchSize <= 0 {
+		return fmt.Errorf("RETENTION_BATCH_SIZE must be positive, got %d", c.RetentionBatchSize)
+	}
+	return nil
+}
+
+// AES-256-GCM の鍵長
+const encryptionKeySize = 32
+
+var encryptionKeyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+type EncryptionKey struct {
+	ID  string
+	Key []byte
+}
+
+// ParseEncryptionKeys は "<鍵ID>:<base64 の32バイト鍵>" の一覧を解釈する。先頭の鍵で暗号化し、残りの鍵は復号にのみ使う
+func ParseEncryptionKeys(entries []string) ([]EncryptionKey, error) {
+	keys := make([]EncryptionKey, 0, len(entries))
+	seen := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || !encryptionKeyIDPattern.MatchString(id) {
+			return nil, errors.New("each key must be \"<key id>:<base64 key>\" with a key id of letters, digits, '_' or '-'")
+		}
+		if _, dup := seen[id]; dup {
+			return nil, fmt.Errorf("key id %q is duplicated", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != encryptionKeySize {
+			return nil, fmt.Errorf("key %q must be %d bytes encoded in base64", id, encryptionKeySize)
+		}
+		seen[id] = struct{}{}
+		keys = append(keys, EncryptionKey{ID: id, Key: key})
+	}
+	return keys, nil
+}
+
 type requiredEnvField struct {
 	name  string
 	value string
@@ -1,27 +1,152 @@
 package discord
 
-import "context"
+import (
+	"context"
+	"strconv"
+	"strings"
+)
 
 type FileMessage struct {
 	ChannelID string
 	Content   string
 	Filename  string
 	FileBody  []byte
+	Embeds    []Embed
+	Buttons   []Button
 }
 
+type EmbedField struct {
+	Name   string
+	Value  string
+	Inline bool
+}
+
+type Embed struct {
+	Title       string
+	Description string
+	Color       int
+	Fields      []EmbedField
+	Footer      string
+}
+
+type ButtonStyle string
+
+const (
+	ButtonStylePrimary   ButtonStyle = "primary"
+	ButtonStyleSecondary ButtonStyle = "secondary"
+	ButtonStyleDanger    ButtonStyle = "danger"
+)
+
+type Button struct {
+	CustomID string
+	Label    string
+	Style    ButtonStyle
+	Disabled bool
+}
+
+// Buttons は1行にまとめて表示する（Discord の上限は1行5個）
+type EmbedMessage struct {
+	ChannelID string
+	Content   string
+	Embeds    []Embed
+	Buttons   []Button
+}
+
+type SlashCommandOptionType string
+
+const (
+	SlashCommandOptionString SlashCommandOptionType = "string"
+)
+
+type SlashCommandOptionChoice struct {
+	Name  string
+	Value string
+}
+
+type SlashCommandOption struct {
+	Name        string
+	Description string
+	Type        SlashCommandOptionType
+	Required    bool
+	Choices     []SlashCommandOptionChoice
+}
+
+type SlashCommandType string
+
+const (
+	SlashCommandTypeChatInput SlashCommandType = ""
+	SlashCommandTypeMessage   SlashCommandType = "message"
+)
+
 type SlashCommandDefinition struct {
+	Type        SlashCommandType
 	Name        string
 	Description string
+	Options     []SlashCommandOption
 }
 
 type SlashCommandEvent struct {
+	GuildID     string
+	ChannelID   string
+	CommandName string
+	UserID      string
+	// コマンドを実行したメンバーのロール
+	RoleIDs []string
+	// Administrator または Manage Server の権限を持っている
+	CanManageGuild   bool
+	Options          map[string]string
+	TargetMessageID  string
+	RespondEphemeral func(content string) error
+	RespondModal     func(modal Modal) error
+}
+
+func (e SlashCommandEvent) Option(name string) string {
+	return strings.TrimSpace(e.Options[name])
+}
+
+type ModalTextInput struct {
+	CustomID  string
+	Label     string
+	Value     string
+	Required  bool
+	MaxLength int
+}
+
+type Modal struct {
+	CustomID string
+	Title    string
+	Inputs   []ModalTextInput
+}
+
+type ModalSubmitEvent struct {
 	GuildID          string
 	ChannelID        string
-	CommandName      string
+	CustomID         string
 	UserID           string
+	Values           map[string]string
 	RespondEphemeral func(content string) error
 }
 
+func (e ModalSubmitEvent) Value(customID string) string {
+	return strings.TrimSpace(e.Values[customID])
+}
+
+// ボタンやセレクトメニューの操作
+type ComponentEvent struct {
+	GuildID   string
+	ChannelID string
+	MessageID string
+	CustomID  string
+	UserID    string
+	// 操作したメンバーのロール
+	RoleIDs        []string
+	CanManageGuild bool
+	// セレクトメニューで選ばれた値
+	Values               []string
+	RespondEphemeral     func(content string) error
+	RespondEphemeralFile func(msg FileMessage) error
+}
+
 type VoiceStateEvent struct {
 	GuildID         string
 	UserID          string
@@ -53,19 +178,41 @@ type Client interface {
 	Connect(ctx context.Context) error
 	Close() error
 	JoinVoiceChannel(guildID, channelID string) (VoiceConnection, error)
-	SendChannelMessage(channelID, content string) error
+	SendChannelMessage(channelID, content string) (string, error)
 	SendChannelMessageWithFile(msg FileMessage) error
+	SendChannelEmbedMessage(msg EmbedMessage) (string, error)
+	// msg.ChannelID は無視し、ユーザーとの DM に送る
+	SendDirectMessageWithFile(userID string, msg FileMessage) error
+	EditChannelMessage(channelID, messageID, content string) error
+	// 埋め込みとボタンを msg の内容で置き換える。ボタンが空の場合はボタンを取り除く
+	EditChannelEmbedMessage(messageID string, msg EmbedMessage) error
 	RegisterVoiceStateUpdateHandler(handler func(VoiceStateEvent))
 	RegisterSlashCommandHandler(handler func(SlashCommandEvent))
+	RegisterModalSubmitHandler(handler func(ModalSubmitEvent))
+	RegisterComponentHandler(handler func(ComponentEvent))
 	UpsertGuildSlashCommands(guildID string, defs []SlashCommandDefinition) error
 	GetUserVoiceChannelID(guildID, userID string) (string, error)
 	ListVoiceChannelParticipants(guildID, channelID string) ([]VoiceParticipant, error)
 	GetBotUserID() (string, error)
 	ResolveTranscriptMetadata(ctx context.Context, guildID, channelID string, participantUserIDs []string) (TranscriptMetadata, error)
+	// GatewayConnected は Gateway に接続して READY を受け取った状態かを返す
+	GatewayConnected() bool
 	Run() error
 }
 
 type VoiceConnection interface {
 	Disconnect() error
+	// 話者がまだ分からない音声は、userID に UnidentifiedSpeakerID の値を渡す
 	ReceiveAudio(callback func(userID string, opusPCM []byte))
 }
+
+// unidentifiedSpeakerPrefix の後ろに SSRC を続けた値を、話者を特定できない音声の userID にする
+const unidentifiedSpeakerPrefix = "ssrc:"
+
+func UnidentifiedSpeakerID(ssrc uint32) string {
+	return unidentifiedSpeakerPrefix + strconv.FormatUint(uint64(ssrc), 10)
+}
+
+func IsUnidentifiedSpeaker(userID string) bool {
+	return strings.HasPrefix(userID, unidentifiedSpeakerPrefix)
+}
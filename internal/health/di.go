@@ -0,0 +1,19 @@
+package health
+
+import (
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+	"github.com/foxseedlab/mojiokoshin/internal/session"
+	"github.com/foxseedlab/mojiokoshin/internal/transcriber"
+	"github.com/samber/do/v2"
+)
+
+func RegisterDI(injector do.Injector) {
+	do.Provide(injector, func(i do.Injector) (*Checker, error) {
+		dc := do.MustInvoke[discord.Client](i)
+		repo := do.MustInvoke[repository.Repository](i)
+		stt := do.MustInvoke[transcriber.Transcriber](i)
+		manager := do.MustInvoke[*session.Manager](i)
+		return NewChecker(dc, repo, stt, manager), nil
+	})
+}
@@ -0,0 +1,104 @@
+package health
+
+import (
+	"context"
+	"time"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+	"github.com/foxseedlab/mojiokoshin/internal/transcriber"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+
+	checkDiscordGateway = "discord_gateway"
+	checkPostgres       = "postgres"
+	checkTranscriber    = "transcriber"
+
+	checkTimeout = 3 * time.Second
+)
+
+type SessionCounter interface {
+	RunningSessionCount() int
+}
+
+// Report の Checks は確認した項目ごとに ok か失敗の理由を持つ
+type Report struct {
+	Status          string            `json:"status"`
+	RunningSessions int               `json:"running_sessions"`
+	Checks          map[string]string `json:"checks"`
+}
+
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+type Checker struct {
+	discord     discord.Client
+	repo        repository.HealthRepository
+	transcriber transcriber.Transcriber
+	sessions    SessionCounter
+}
+
+func NewChecker(dc discord.Client, repo repository.HealthRepository, stt transcriber.Transcriber, sessions SessionCounter) *Checker {
+	return &Checker{
+		discord:     dc,
+		repo:        repo,
+		transcriber: stt,
+		sessions:    sessions,
+	}
+}
+
+// Liveness は Gateway から切断されていて実行中のセッションもない場合だけ unavailable を返す。
+// 文字起こし中はデータベースなどが落ちていても再起動させないため、ほかの項目は見ない
+func (c *Checker) Liveness() Report {
+	report := c.newReport()
+	gateway := c.discord.GatewayConnected()
+	report.Checks[checkDiscordGateway] = gatewayStatus(gateway)
+	if !gateway && report.RunningSessions == 0 {
+		report.Status = StatusUnavailable
+	}
+	return report
+}
+
+// Readiness は Gateway・データベース・音声認識のすべてが使える場合だけ ok を返す
+func (c *Checker) Readiness(ctx context.Context) Report {
+	report := c.newReport()
+	gateway := c.discord.GatewayConnected()
+	report.Checks[checkDiscordGateway] = gatewayStatus(gateway)
+	if !gateway {
+		report.Status = StatusUnavailable
+	}
+	for name, ping := range map[string]func(context.Context) error{
+		checkPostgres:    c.repo.Ping,
+		checkTranscriber: c.transcriber.Ping,
+	} {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		err := ping(checkCtx)
+		cancel()
+		if err != nil {
+			report.Checks[name] = err.Error()
+			report.Status = StatusUnavailable
+			continue
+		}
+		report.Checks[name] = StatusOK
+	}
+	return report
+}
+
+func (c *Checker) newReport() Report {
+	return Report{
+		Status:          StatusOK,
+		RunningSessions: c.sessions.RunningSessionCount(),
+		Checks:          map[string]string{},
+	}
+}
+
+func gatewayStatus(connected bool) string {
+	if connected {
+		return StatusOK
+	}
+	return "disconnected"
+}
@@ -0,0 +1,72 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/transcriber"
+)
+
+type fakeDiscord struct {
+	discord.Client
+	connected bool
+}
+
+func (f fakeDiscord) GatewayConnected() bool { return f.connected }
+
+type fakePinger struct{ err error }
+
+func (f fakePinger) Ping(context.Context) error { return f.err }
+
+type fakeTranscriber struct {
+	transcriber.Transcriber
+	err error
+}
+
+func (f fakeTranscriber) Ping(context.Context) error { return f.err }
+
+type fakeSessions int
+
+func (f fakeSessions) RunningSessionCount() int { return int(f) }
+
+func TestLiveness_StaysOKWhileSessionsRun(t *testing.T) {
+	tests := []struct {
+		name      string
+		connected bool
+		sessions  int
+		want      string
+	}{
+		{name: "connected", connected: true, want: StatusOK},
+		{name: "disconnected during session", sessions: 2, want: StatusOK},
+		{name: "disconnected and idle", want: StatusUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker(fakeDiscord{connected: tt.connected}, fakePinger{}, fakeTranscriber{}, fakeSessions(tt.sessions))
+			got := checker.Liveness()
+			if got.Status != tt.want || got.RunningSessions != tt.sessions {
+				t.Fatalf("unexpected report: %+v", got)
+			}
+		})
+	}
+}
+
+func TestReadiness_ReportsEachCheck(t *testing.T) {
+	checker := NewChecker(fakeDiscord{connected: true}, fakePinger{}, fakeTranscriber{}, fakeSessions(1))
+	if got := checker.Readiness(context.Background()); !got.OK() || len(got.Checks) != 3 {
+		t.Fatalf("expected ready: %+v", got)
+	}
+
+	checker = NewChecker(fakeDiscord{connected: true}, fakePinger{err: errors.New("connection refused")}, fakeTranscriber{}, fakeSessions(0))
+	got := checker.Readiness(context.Background())
+	if got.OK() || got.Checks[checkPostgres] != "connection refused" || got.Checks[checkTranscriber] != StatusOK {
+		t.Fatalf("unexpected report: %+v", got)
+	}
+
+	checker = NewChecker(fakeDiscord{}, fakePinger{}, fakeTranscriber{}, fakeSessions(0))
+	if got := checker.Readiness(context.Background()); got.OK() || got.Checks[checkDiscordGateway] != "disconnected" {
+		t.Fatalf("unexpected report: %+v", got)
+	}
+}
@@ -0,0 +1,32 @@
+package metrics
+
+import "time"
+
+// Recorder は文字起こしの処理状況を記録する。ラベルにはサーバーIDを使い、分からない場合は空にする
+type Recorder interface {
+	SessionStarted(guildID string)
+	SessionStopped(guildID string)
+	AddOpusPackets(guildID string, n int)
+	AddMixedFrames(guildID string, n int)
+	AddWrittenFrames(guildID string, n int)
+	STTReconnected(guildID string)
+	// ObserveSegmentLatency は発話の終わりから文字起こしをチャンネルに投稿するまでの時間を記録する
+	ObserveSegmentLatency(guildID string, latency time.Duration)
+	WebhookSent(guildID string, err error)
+	DiscordAPIFailed(guildID, operation string)
+}
+
+// Nop は何も記録しない
+var Nop Recorder = nopRecorder{}
+
+type nopRecorder struct{}
+
+func (nopRecorder) SessionStarted(string)                       {}
+func (nopRecorder) SessionStopped(string)                       {}
+func (nopRecorder) AddOpusPackets(string, int)                  {}
+func (nopRecorder) AddMixedFrames(string, int)                  {}
+func (nopRecorder) AddWrittenFrames(string, int)                {}
+func (nopRecorder) STTReconnected(string)                       {}
+func (nopRecorder) ObserveSegmentLatency(string, time.Duration) {}
+func (nopRecorder) WebhookSent(string, error)                   {}
+func (nopRecorder) DiscordAPIFailed(string, string)             {}
@@ -9,32 +9,139 @@ const (
 	SessionStatusCompleted SessionStatus = "completed"
 )
 
+type RecordingConsent string
+
+const (
+	RecordingConsentUnknown RecordingConsent = ""
+	RecordingConsentGranted RecordingConsent = "granted"
+	RecordingConsentDenied  RecordingConsent = "denied"
+)
+
+// ConsentMode は同意を確認していない参加者の扱い
+type ConsentMode string
+
+const (
+	// 案内を出したうえで文字起こしする
+	ConsentModeAnnounce ConsentMode = "announce"
+	// 同意するまで音声を文字起こしに使わない
+	ConsentModeExclude ConsentMode = "exclude"
+)
+
+// StopPolicy は実行中のセッションを止められるメンバー。モデレーターはどの方針でも止められる
+type StopPolicy string
+
+const (
+	// セッションの参加者なら誰でも止められる
+	StopPolicyParticipants StopPolicy = "participants"
+	// 開始した人だけが止められる。自動で開始したセッションは参加者が止められる
+	StopPolicyStarter    StopPolicy = "starter"
+	StopPolicyModerators StopPolicy = "moderators"
+)
+
+// StartTrigger はセッションを開始したきっかけ
+type StartTrigger string
+
+const (
+	StartTriggerSlash StartTrigger = "slash"
+	// 自動文字起こしの対象チャンネルへの参加
+	StartTriggerAuto StartTrigger = "auto"
+)
+
 type Session struct {
-	ID              string
-	GuildID         string
-	GuildName       string
-	ChannelID       string
-	ChannelName     string
-	StartedAt       time.Time
-	EndedAt         *time.Time
-	Status          SessionStatus
-	StopReason      string
-	Timezone        string
-	DurationSeconds int64
-	SegmentCount    int
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
+	ID                 string
+	GuildID            string
+	GuildName          string
+	ChannelID          string
+	ChannelName        string
+	StartedAt          time.Time
+	EndedAt            *time.Time
+	Status             SessionStatus
+	StopReason         string
+	Timezone           string
+	DurationSeconds    int64
+	SegmentCount       int
+	TranscriptRevision int
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	StartedByUserID    string
+	StartTrigger       StartTrigger
+	// 自動で終了した場合は空になる
+	StoppedByUserID string
+	// 保存期間を過ぎて削除した日時。削除していない場合は nil になる
+	ArtifactsPurgedAt   *time.Time
+	TranscriptsPurgedAt *time.Time
 }
 
 type TranscriptSegment struct {
-	ID           string
-	SessionID    string
-	Content      string
-	SegmentIndex int
-	SpokenAt     time.Time
+	ID        string
+	SessionID string
+	Content   string
+	// RawContent は置換などの後処理をする前の音声認識結果
+	RawContent       string
+	SegmentIndex     int
+	Revision         int
+	DiscordMessageID string
+	SpokenAt         time.Time
+	EndedAt          *time.Time
+	Confidence       *float64
+	Words            []SegmentWord
+	// Language は音声認識が検出した言語で、検出しない場合は空になる
+	Language     string
+	Translations []SegmentTranslation
+	CorrectedAt  *time.Time
 	CreatedAt    time.Time
 }
 
+type SegmentWord struct {
+	Text       string    `json:"text"`
+	StartAt    time.Time `json:"start_at"`
+	EndAt      time.Time `json:"end_at"`
+	Confidence float64   `json:"confidence"`
+}
+
+type SegmentTranslation struct {
+	Language string `json:"language"`
+	Text     string `json:"text"`
+}
+
+type PhraseHint struct {
+	GuildID         string
+	Phrase          string
+	CreatedByUserID string
+	CreatedAt       time.Time
+}
+
+type ReplacementRule struct {
+	GuildID         string
+	Pattern         string
+	Replacement     string
+	IsRegex         bool
+	CreatedByUserID string
+	CreatedAt       time.Time
+}
+
+type GuildSettings struct {
+	GuildID              string
+	AutomaticPunctuation bool
+	SpokenPunctuation    bool
+	ProfanityFilter      bool
+	NumberNormalization  bool
+	TextNormalization    bool
+	// Languages は認識の候補言語で、先頭が主言語になる。空の場合は既定の言語を使う
+	Languages   []string
+	ConsentMode ConsentMode
+	// 保存期間（日数）で、0 の場合は無期限に保存する
+	TranscriptRetentionDays int
+	ArtifactRetentionDays   int
+	// StartRoleIDs が空の場合は誰でも開始できる
+	StartRoleIDs []string
+	// モデレーターはどのセッションも止められ、設定を変更できる
+	ModeratorRoleIDs []string
+	StopPolicy       StopPolicy
+	UpdatedByUserID  string
+	UpdatedAt        time.Time
+}
+
 type SessionParticipant struct {
 	SessionID   string
 	UserID      string
@@ -43,3 +150,55 @@ type SessionParticipant struct {
 	FirstSeenAt time.Time
 	LastSeenAt  time.Time
 }
+
+type UserParticipation struct {
+	SessionID   string
+	GuildID     string
+	GuildName   string
+	ChannelID   string
+	ChannelName string
+	StartedAt   time.Time
+	EndedAt     *time.Time
+	DisplayName string
+	IsBot       bool
+	FirstSeenAt time.Time
+	LastSeenAt  time.Time
+}
+
+type UserSegmentEdit struct {
+	SessionID    string
+	SegmentIndex int
+	Revision     int
+	Version      int
+	Content      string
+	EditedAt     time.Time
+}
+
+type UserGuildPreference struct {
+	GuildID              string
+	TranscriptSubscribed bool
+	RecordingConsent     RecordingConsent
+}
+
+type AuditAction string
+
+const (
+	AuditActionSessionStart   AuditAction = "session_start"
+	AuditActionSessionStop    AuditAction = "session_stop"
+	AuditActionConfigUpdate   AuditAction = "config_update"
+	AuditActionSessionDelete  AuditAction = "session_delete"
+	AuditActionRetentionPurge AuditAction = "retention_purge"
+	AuditActionUserErase      AuditAction = "user_erase"
+)
+
+// AuditEvent はボットの操作の記録。自動で行った操作は ActorUserID が空になる
+type AuditEvent struct {
+	ID          string
+	GuildID     string
+	ChannelID   string
+	SessionID   string
+	ActorUserID string
+	Action      AuditAction
+	Reason      string
+	CreatedAt   time.Time
+}
@@ -6,9 +6,11 @@ import (
 )
 
 type CreateSessionInput struct {
-	GuildID   string
-	ChannelID string
-	StartedAt time.Time
+	GuildID         string
+	ChannelID       string
+	StartedAt       time.Time
+	StartedByUserID string
+	StartTrigger    StartTrigger
 }
 
 type CompleteSessionInput struct {
@@ -28,6 +30,7 @@ type SaveSessionOutputInput struct {
 	SessionID          string
 	EndedAt            time.Time
 	StopReason         string
+	StoppedByUserID    string
 	GuildName          string
 	ChannelName        string
 	Timezone           string
@@ -37,28 +40,193 @@ type SaveSessionOutputInput struct {
 	TranscriptFilename string
 	TranscriptText     string
 	WebhookPayloadJSON []byte
+	SummaryJSON        []byte
+}
+
+type SaveTranscriptRevisionInput struct {
+	SessionID          string
+	Revision           int
+	SegmentCount       int
+	TranscriptFilename string
+	TranscriptText     string
+	WebhookPayloadJSON []byte
+}
+
+type SaveTranscriptArtifactInput struct {
+	SessionID          string
+	TranscriptFilename string
+	TranscriptText     string
+	WebhookPayloadJSON []byte
+	// nil のときは保存済みの要約を維持する
+	SummaryJSON []byte
+}
+
+type TranscriptArtifact struct {
+	SessionID          string
+	TranscriptFilename string
+	TranscriptText     string
+	UpdatedAt          time.Time
+}
+
+type CorrectSegmentInput struct {
+	SegmentID      string
+	Content        string
+	EditedByUserID string
+	EditedAt       time.Time
+	// Translations は修正後の本文を翻訳し直したもので、翻訳しない場合は空になる
+	Translations []SegmentTranslation
 }
 
 type InsertSegmentInput struct {
 	SessionID    string
 	Content      string
+	RawContent   string
 	SegmentIndex int
+	Revision     int
 	SpokenAt     time.Time
+	EndedAt      time.Time
+	// Confidence が 0 の場合は信頼度なしとして保存する
+	Confidence   float64
+	Words        []SegmentWord
+	Language     string
+	Translations []SegmentTranslation
 }
 
 type SessionRepository interface {
 	CreateSession(ctx context.Context, input CreateSessionInput) (*Session, error)
 	UpdateSessionCompleted(ctx context.Context, input CompleteSessionInput) error
 	SaveSessionOutput(ctx context.Context, input SaveSessionOutputInput) error
+	SaveTranscriptRevision(ctx context.Context, input SaveTranscriptRevisionInput) error
+	SaveTranscriptArtifact(ctx context.Context, input SaveTranscriptArtifactInput) error
+	// 終了処理が済んでいないセッションでは nil を返す
+	GetTranscriptArtifact(ctx context.Context, sessionID string) (*TranscriptArtifact, error)
 	GetRunningSessionByChannel(ctx context.Context, guildID, channelID string) (*Session, error)
+	GetSessionByID(ctx context.Context, sessionID string) (*Session, error)
+	ListSessionParticipants(ctx context.Context, sessionID string) ([]SessionParticipant, error)
 }
 
 type TranscriptRepository interface {
 	InsertSegment(ctx context.Context, input InsertSegmentInput) error
 	ListSegmentsBySessionID(ctx context.Context, sessionID string) ([]TranscriptSegment, error)
+	SetSegmentDiscordMessageID(ctx context.Context, sessionID string, segmentIndex int, messageID string) error
+	// 修正済みの発言は修正時に翻訳し直しているため更新せず、false を返す
+	SetSegmentTranslations(ctx context.Context, sessionID string, segmentIndex int, translations []SegmentTranslation) (bool, error)
+	GetSegment(ctx context.Context, sessionID string, segmentIndex int) (*TranscriptSegment, error)
+	GetSegmentByDiscordMessageID(ctx context.Context, messageID string) (*TranscriptSegment, error)
+	CorrectSegment(ctx context.Context, input CorrectSegmentInput) error
+}
+
+type AddPhraseHintInput struct {
+	GuildID         string
+	Phrase          string
+	CreatedByUserID string
+}
+
+type SaveReplacementRuleInput struct {
+	GuildID         string
+	Pattern         string
+	Replacement     string
+	IsRegex         bool
+	CreatedByUserID string
+}
+
+type GuildSettingsRepository interface {
+	// 既に登録済みの語句の場合は false を返す
+	AddPhraseHint(ctx context.Context, input AddPhraseHintInput) (bool, error)
+	RemovePhraseHint(ctx context.Context, guildID, phrase string) (bool, error)
+	ListPhraseHints(ctx context.Context, guildID string) ([]PhraseHint, error)
+	// 同じ語句のルールが既にある場合は置換後の文字列と種別を上書きする
+	SaveReplacementRule(ctx context.Context, input SaveReplacementRuleInput) error
+	RemoveReplacementRule(ctx context.Context, guildID, pattern string) (bool, error)
+	ListReplacementRules(ctx context.Context, guildID string) ([]ReplacementRule, error)
+	// 未設定のギルドでは nil を返す
+	GetGuildSettings(ctx context.Context, guildID string) (*GuildSettings, error)
+	SaveGuildSettings(ctx context.Context, settings GuildSettings) error
+}
+
+type UserPreferencesRepository interface {
+	SetTranscriptSubscription(ctx context.Context, guildID, userID string, subscribed bool) error
+	IsTranscriptSubscribed(ctx context.Context, guildID, userID string) (bool, error)
+	// userIDs のうち DM での受け取りを希望しているユーザーだけを返す
+	ListTranscriptSubscribers(ctx context.Context, guildID string, userIDs []string) ([]string, error)
+	SetRecordingConsent(ctx context.Context, guildID, userID string, consent RecordingConsent) error
+	// 同意の記録がないユーザーは結果に含めない
+	ListRecordingConsents(ctx context.Context, guildID string, userIDs []string) (map[string]RecordingConsent, error)
+}
+
+type PurgeExpiredInput struct {
+	GuildID     string
+	EndedBefore time.Time
+	Limit       int
+}
+
+type RetentionRepository interface {
+	// 期限切れのセッションの添付ファイル・Webhook Payload・要約を削除し、対象のセッションIDを返す
+	PurgeExpiredArtifacts(ctx context.Context, input PurgeExpiredInput) ([]string, error)
+	// 期限切れのセッションの文字起こしと参加者を削除し、セッションは名前を消して件数と時間だけ残す
+	PurgeExpiredTranscripts(ctx context.Context, input PurgeExpiredInput) ([]string, error)
+	// 存在しないセッションの場合は false を返す
+	DeleteSession(ctx context.Context, sessionID string) (bool, error)
+}
+
+// UserArtifact は書き換えの対象になる、ユーザーが参加したセッションの保存済みの出力
+type UserArtifact struct {
+	SessionID          string
+	DisplayName        string
+	TranscriptText     string
+	WebhookPayloadJSON []byte
+}
+
+type EraseUserInput struct {
+	UserID string
+	// 空の場合は参加記録を削除し、空でない場合は ID と表示名をこの値に置き換える
+	Pseudonym string
+	// 参加者の名前を書き換えた後の出力
+	Artifacts []UserArtifact
+}
+
+type UserDataRepository interface {
+	ListUserParticipations(ctx context.Context, userID string) ([]UserParticipation, error)
+	ListUserSegmentEdits(ctx context.Context, userID string) ([]UserSegmentEdit, error)
+	ListUserGuildPreferences(ctx context.Context, userID string) ([]UserGuildPreference, error)
+	ListUserArtifacts(ctx context.Context, userID string) ([]UserArtifact, error)
+	// 録音を拒否した記録は、以降も音声を使わないために残す
+	EraseUser(ctx context.Context, input EraseUserInput) error
+}
+
+type ListAuditEventsInput struct {
+	// 空の場合はすべてのサーバーを対象にする
+	GuildID     string
+	SessionID   string
+	ActorUserID string
+	// 0 の場合はすべて返す
+	Limit int
+}
+
+type AuditRepository interface {
+	InsertAuditEvent(ctx context.Context, event AuditEvent) error
+	// 新しい順に返す。空の条件では絞り込まない
+	ListAuditEvents(ctx context.Context, input ListAuditEventsInput) ([]AuditEvent, error)
+}
+
+type EncryptionRepository interface {
+	// 現在の鍵以外で暗号化された値と平文の値を、現在の鍵で暗号化し直す。テーブルごとに最大 limit 行を処理し、更新した行数を返す
+	ReencryptBatch(ctx context.Context, limit int) (int, error)
+}
+
+type HealthRepository interface {
+	// Ping はデータベースに接続できるかを確かめる
+	Ping(ctx context.Context) error
 }
 
 type Repository interface {
 	SessionRepository
 	TranscriptRepository
+	GuildSettingsRepository
+	UserPreferencesRepository
+	RetentionRepository
+	UserDataRepository
+	AuditRepository
+	EncryptionRepository
+	HealthRepository
 }
@@ -0,0 +1,49 @@
+package session
+
+import (
+	"io"
+	"log/slog"
+)
+
+type sessionAudioArchive struct {
+	sessionID string
+	w         io.WriteCloser
+	silence   []byte
+	failed    bool
+}
+
+func (m *Manager) openSessionAudioArchive(sessionID string) *sessionAudioArchive {
+	w, err := m.archive.Create(sessionID)
+	if err != nil {
+		slog.Warn("failed to create audio archive; continuing without it", "error", err, "session_id", sessionID)
+		return nil
+	}
+	return &sessionAudioArchive{sessionID: sessionID, w: w, silence: make([]byte, audioFrameBytes)}
+}
+
+func (a *sessionAudioArchive) writeFrame(pcm []byte) {
+	if a == nil || a.failed {
+		return
+	}
+	if _, err := a.w.Write(pcm); err != nil {
+		a.failed = true
+		slog.Error("failed to write audio archive; archiving disabled for this session", "error", err, "session_id", a.sessionID)
+	}
+}
+
+// 再文字起こし時に経過時間を復元できるよう、無音のティックもゼロ埋めで書き込む
+func (a *sessionAudioArchive) writeSilence() {
+	if a == nil {
+		return
+	}
+	a.writeFrame(a.silence)
+}
+
+func (a *sessionAudioArchive) close() {
+	if a == nil {
+		return
+	}
+	if err := a.w.Close(); err != nil {
+		slog.Error("failed to close audio archive", "error", err, "session_id", a.sessionID)
+	}
+}
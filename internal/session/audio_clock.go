@@ -0,0 +1,101 @@
+package session
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+	"github.com/foxseedlab/mojiokoshin/internal/transcriber"
+)
+
+const pcmBytesPerSecond = 48000 * 2 * 2
+
+type audioClockAnchor struct {
+	offset time.Duration
+	at     time.Time
+}
+
+// audioClock は音声認識に送った音声の通算位置と、その音声が話された時刻を対応付ける。
+// VAD などで送らなかった区間があると位置と時刻がずれるため、送信が途切れるたびに基準点を追加する。
+type audioClock struct {
+	mu      sync.Mutex
+	anchors []audioClockAnchor
+	written time.Duration
+	gap     bool
+}
+
+func (c *audioClock) wrote(n int, end time.Time) {
+	d := pcmDuration(n)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.anchors) == 0 || c.gap {
+		c.anchors = append(c.anchors, audioClockAnchor{offset: c.written, at: end.Add(-d)})
+		c.gap = false
+	}
+	c.written += d
+}
+
+func (c *audioClock) skipped() {
+	c.mu.Lock()
+	c.gap = true
+	c.mu.Unlock()
+}
+
+// 区切り位置ちょうどのオフセットは、終了位置なら途切れる前の区間、開始位置なら後の区間として扱う
+func (c *audioClock) timeAt(offset time.Duration, isEnd bool) (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.anchors) == 0 {
+		return time.Time{}, false
+	}
+	i := sort.Search(len(c.anchors), func(i int) bool {
+		if isEnd {
+			return c.anchors[i].offset >= offset
+		}
+		return c.anchors[i].offset > offset
+	}) - 1
+	if i < 0 {
+		i = 0
+	}
+	a := c.anchors[i]
+	return a.at.Add(offset - a.offset), true
+}
+
+// 結果のオフセットを時刻に変換できないときは、結果を受け取った時刻で代用する
+func (c *audioClock) span(startOffset, endOffset time.Duration, fallback time.Time) (time.Time, time.Time) {
+	start, ok := c.timeAt(startOffset, false)
+	if !ok {
+		return fallback, fallback
+	}
+	end, _ := c.timeAt(endOffset, true)
+	if end.Before(start) {
+		end = start
+	}
+	return start, end
+}
+
+type segmentTiming struct {
+	spokenAt time.Time
+	endedAt  time.Time
+	words    []repository.SegmentWord
+}
+
+func (c *audioClock) timing(result transcriber.Result, fallback time.Time) segmentTiming {
+	spokenAt, endedAt := c.span(result.StartOffset, result.EndOffset, fallback)
+	timing := segmentTiming{spokenAt: spokenAt, endedAt: endedAt}
+	for _, w := range result.Words {
+		start, end := c.span(w.StartOffset, w.EndOffset, fallback)
+		timing.words = append(timing.words, repository.SegmentWord{
+			Text:       w.Text,
+			StartAt:    start,
+			EndAt:      end,
+			Confidence: w.Confidence,
+		})
+	}
+	return timing
+}
+
+func pcmDuration(n int) time.Duration {
+	return time.Duration(n) * time.Second / pcmBytesPerSecond
+}
@@ -0,0 +1,37 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAudioClock_MapsOffsetsAcrossSkippedFrames(t *testing.T) {
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	frame := audioFrameBytes
+	clock := &audioClock{}
+
+	clock.wrote(frame, base.Add(audioMixInterval))
+	clock.wrote(frame, base.Add(2*audioMixInterval))
+	for i := 0; i < 50; i++ {
+		clock.skipped()
+	}
+	resumedAt := base.Add(10 * time.Second)
+	clock.wrote(frame, resumedAt.Add(audioMixInterval))
+
+	start, end := clock.span(0, 2*audioMixInterval, time.Time{})
+	if !start.Equal(base) || !end.Equal(base.Add(2*audioMixInterval)) {
+		t.Fatalf("unexpected span before gap: %v - %v", start, end)
+	}
+	start, end = clock.span(2*audioMixInterval, 3*audioMixInterval, time.Time{})
+	if !start.Equal(resumedAt) || !end.Equal(resumedAt.Add(audioMixInterval)) {
+		t.Fatalf("unexpected span after gap: %v - %v", start, end)
+	}
+}
+
+func TestAudioClock_FallsBackBeforeAnyAudio(t *testing.T) {
+	fallback := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	start, end := (&audioClock{}).span(time.Second, 2*time.Second, fallback)
+	if !start.Equal(fallback) || !end.Equal(fallback) {
+		t.Fatalf("expected fallback time, got %v - %v", start, end)
+	}
+}
@@ -0,0 +1,134 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+)
+
+const (
+	auditListLimit  = 20
+	auditTimeLayout = "2006-01-02 15:04"
+)
+
+var ErrInvalidUserID = errors.New("invalid user id")
+
+// <@ユーザーID> の形式のメンションと、ユーザーIDそのものを受け付ける
+var userIDPattern = regexp.MustCompile(`^(?:<@!?)?([0-9]{1,20})>?$`)
+
+// 監査ログの記録に失敗しても操作そのものは止めない
+func (m *Manager) recordAudit(ctx context.Context, event repository.AuditEvent) {
+	if err := m.repo.InsertAuditEvent(ctx, event); err != nil {
+		slog.Error("failed to record audit event", "error", err, "action", event.Action, "guild_id", event.GuildID, "session_id", event.SessionID)
+	}
+}
+
+func (m *Manager) auditCommand(event discord.SlashCommandEvent, action repository.AuditAction, sessionID string) {
+	m.recordAudit(context.Background(), repository.AuditEvent{
+		GuildID:     event.GuildID,
+		ChannelID:   event.ChannelID,
+		SessionID:   sessionID,
+		ActorUserID: event.UserID,
+		Action:      action,
+		Reason:      commandAuditReason(event),
+	})
+}
+
+// コマンド名と指定されたオプションを "name key=value" の形で並べる
+func commandAuditReason(event discord.SlashCommandEvent) string {
+	keys := make([]string, 0, len(event.Options))
+	for key := range event.Options {
+		if event.Option(key) != "" {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	parts := []string{event.CommandName}
+	for _, key := range keys {
+		parts = append(parts, key+"="+event.Option(key))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (m *Manager) ListAuditEvents(ctx context.Context, input repository.ListAuditEventsInput) ([]repository.AuditEvent, error) {
+	return m.repo.ListAuditEvents(ctx, input)
+}
+
+func parseUserID(value string) (string, error) {
+	match := userIDPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return "", ErrInvalidUserID
+	}
+	return match[1], nil
+}
+
+func (m *Manager) handleAuditCommand(event discord.SlashCommandEvent) {
+	input := repository.ListAuditEventsInput{
+		GuildID:   event.GuildID,
+		SessionID: event.Option(optionSessionID),
+		Limit:     auditListLimit,
+	}
+	if user := event.Option(optionUser); user != "" {
+		userID, err := parseUserID(user)
+		if err != nil {
+			m.respondEphemeral(event, messageEphemeralUserInvalid)
+			return
+		}
+		input.ActorUserID = userID
+	}
+	events, err := m.ListAuditEvents(context.Background(), input)
+	if err != nil {
+		slog.Error("failed to list audit events", "error", err, "guild_id", event.GuildID)
+		m.respondEphemeral(event, messageEphemeralAuditFailed)
+		return
+	}
+	if len(events) == 0 {
+		m.respondEphemeral(event, messageAuditListEmpty)
+		return
+	}
+	lines := make([]string, 0, len(events))
+	for _, e := range events {
+		lines = append(lines, m.auditEventLine(e))
+	}
+	m.respondEphemeral(event, strings.Join([]string{messageAuditListTitle, joinListWithinLimit(lines, "\n")}, "\n"))
+}
+
+func (m *Manager) auditEventLine(e repository.AuditEvent) string {
+	parts := []string{
+		e.CreatedAt.In(safeLocation(m.transcriptLocation)).Format(auditTimeLayout),
+		"**" + auditActionLabel(e.Action) + "**",
+	}
+	if e.ActorUserID != "" {
+		parts = append(parts, userMention(e.ActorUserID))
+	} else {
+		parts = append(parts, messageAuditActorSystem)
+	}
+	if e.ChannelID != "" {
+		parts = append(parts, "<#"+e.ChannelID+">")
+	}
+	if e.SessionID != "" {
+		parts = append(parts, fmt.Sprintf("`%s`", e.SessionID))
+	}
+	if reason := auditReasonText(e); reason != "" {
+		parts = append(parts, "（"+reason+"）")
+	}
+	return "- " + strings.Join(parts, " ")
+}
+
+func auditReasonText(e repository.AuditEvent) string {
+	switch e.Action {
+	case repository.AuditActionSessionStart:
+		return startTriggerLabel(repository.StartTrigger(e.Reason))
+	case repository.AuditActionSessionStop:
+		return strings.TrimSuffix(stopReasonDetail(e.Reason), "。")
+	default:
+		return e.Reason
+	}
+}
@@ -0,0 +1,122 @@
+package session
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+)
+
+func auditCommandEvent(options map[string]string, got *string) discord.SlashCommandEvent {
+	return discord.SlashCommandEvent{
+		GuildID:        "guild-1",
+		CommandName:    commandMojiokoshiAudit,
+		UserID:         "admin-1",
+		CanManageGuild: true,
+		Options:        options,
+		RespondEphemeral: func(content string) error {
+			*got = content
+			return nil
+		},
+	}
+}
+
+func TestAudit_RecordsStartAndStop(t *testing.T) {
+	repo := &mockRepository{}
+	dc := &mockDiscordClient{userVoiceChannelByID: map[string]string{"user-1": "vc-1"}}
+	manager := newTestManager(repo, dc)
+	manager.SetBotUserID("bot-self")
+
+	for _, name := range []string{commandMojiokoshi, commandMojiokoshiStop} {
+		manager.HandleSlashCommand(discord.SlashCommandEvent{
+			GuildID:          "guild-1",
+			CommandName:      name,
+			UserID:           "user-1",
+			RespondEphemeral: func(string) error { return nil },
+		})
+	}
+
+	want := []repository.AuditAction{repository.AuditActionSessionStart, repository.AuditActionSessionStop}
+	if got := repo.auditActions(); !slices.Equal(got, want) {
+		t.Fatalf("unexpected audit actions: %v", got)
+	}
+	stop := repo.auditEvents[1]
+	if stop.ActorUserID != "user-1" || stop.ChannelID != "vc-1" || stop.SessionID == "" || stop.Reason != stopReasonManualSlash {
+		t.Fatalf("unexpected stop event: %+v", stop)
+	}
+}
+
+func TestAudit_RecordsSettingsChange(t *testing.T) {
+	repo := &mockRepository{}
+	manager := newTestManager(repo, &mockDiscordClient{})
+
+	var got string
+	manager.HandleSlashCommand(settingsCommand(map[string]string{optionStopPolicy: string(repository.StopPolicyStarter)}, &got))
+	if len(repo.auditEvents) != 1 {
+		t.Fatalf("expected one audit event, got %+v", repo.auditEvents)
+	}
+	e := repo.auditEvents[0]
+	if e.Action != repository.AuditActionConfigUpdate || e.ActorUserID != "user-1" || e.Reason != "mojiokoshi-settings stop_policy=starter" {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+}
+
+func TestHandleAuditCommand_ListsAndFilters(t *testing.T) {
+	repo := &mockRepository{auditEvents: []repository.AuditEvent{
+		{GuildID: "guild-1", ChannelID: "vc-1", SessionID: "session-1", ActorUserID: "user-1", Action: repository.AuditActionSessionStart},
+		{GuildID: "guild-1", SessionID: "session-1", Action: repository.AuditActionSessionStop, Reason: stopReasonParticipantsLeft},
+		{GuildID: "guild-2", ActorUserID: "user-1", Action: repository.AuditActionConfigUpdate},
+	}}
+	manager := newTestManager(repo, &mockDiscordClient{})
+
+	var got string
+	manager.HandleSlashCommand(auditCommandEvent(nil, &got))
+	if !strings.HasPrefix(got, messageAuditListTitle) || strings.Count(got, "\n- ") != 2 ||
+		!strings.Contains(got, "<@user-1>") || !strings.Contains(got, messageAuditActorSystem) {
+		t.Fatalf("unexpected list: %q", got)
+	}
+	if strings.Index(got, auditActionLabel(repository.AuditActionSessionStop)) > strings.Index(got, auditActionLabel(repository.AuditActionSessionStart)) {
+		t.Fatalf("expected newest first: %q", got)
+	}
+
+	manager.HandleSlashCommand(auditCommandEvent(map[string]string{optionUser: "<@user-2>"}, &got))
+	if got != messageEphemeralUserInvalid {
+		t.Fatalf("unexpected response: %q", got)
+	}
+	repo.auditEvents = append(repo.auditEvents, repository.AuditEvent{GuildID: "guild-1", ActorUserID: "123", Action: repository.AuditActionSessionDelete})
+	manager.HandleSlashCommand(auditCommandEvent(map[string]string{optionUser: "<@!123>"}, &got))
+	if strings.Count(got, "\n- ") != 1 || !strings.Contains(got, "<@123>") {
+		t.Fatalf("unexpected filtered list: %q", got)
+	}
+	manager.HandleSlashCommand(auditCommandEvent(map[string]string{optionSessionID: "session-9"}, &got))
+	if got != messageAuditListEmpty {
+		t.Fatalf("unexpected response: %q", got)
+	}
+}
+
+func TestHandleAuditCommand_RequiresModerator(t *testing.T) {
+	manager := newTestManager(&mockRepository{}, &mockDiscordClient{})
+
+	var got string
+	event := auditCommandEvent(nil, &got)
+	event.CanManageGuild = false
+	manager.HandleSlashCommand(event)
+	if got != messageEphemeralAdminOnly {
+		t.Fatalf("expected admin only response: %q", got)
+	}
+}
+
+func TestParseUserID(t *testing.T) {
+	for _, value := range []string{"123", "<@123>", "<@!123>", " 123 "} {
+		if got, err := parseUserID(value); err != nil || got != "123" {
+			t.Fatalf("parseUserID(%q) = %q, %v", value, got, err)
+		}
+	}
+	for _, value := range []string{"", "user-1", "<@&123>"} {
+		if _, err := parseUserID(value); err == nil {
+			t.Fatalf("expected error for %q", value)
+		}
+	}
+}
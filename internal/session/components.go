@@ -0,0 +1,209 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+)
+
+// ボタンの custom ID は "<action>:<session ID>" の形式で、再起動後に押されてもセッションを特定できる
+const (
+	buttonActionStop               = "mojiokoshi-stop"
+	buttonActionDownloadTranscript = "mojiokoshi-download"
+	buttonActionResendTranscriptDM = "mojiokoshi-resend-dm"
+)
+
+var (
+	ErrSessionNotRunning   = errors.New("session is not running")
+	ErrTranscriptNotReady  = errors.New("transcript artifact is not ready yet")
+	ErrDirectMessageFailed = errors.New("failed to send direct message")
+)
+
+func sessionButtonCustomID(action, sessionID string) string {
+	return action + ":" + sessionID
+}
+
+func parseSessionButtonCustomID(customID string) (string, string, bool) {
+	action, sessionID, ok := strings.Cut(customID, ":")
+	if !ok || sessionID == "" {
+		return "", "", false
+	}
+	return action, sessionID, true
+}
+
+func (m *Manager) HandleComponent(event discord.ComponentEvent) {
+	slog.Info("component interaction received by manager", "guild_id", event.GuildID, "channel_id", event.ChannelID, "component", event.CustomID, "user_id", event.UserID)
+	if event.GuildID != m.cfg.DiscordGuildID {
+		m.respondComponentEphemeral(event, messageEphemeralWrongGuild)
+		return
+	}
+	action, sessionID, ok := parseSessionButtonCustomID(event.CustomID)
+	if !ok {
+		m.respondUnknownComponent(event)
+		return
+	}
+	switch action {
+	case buttonActionStop:
+		m.handleStopButton(event, sessionID)
+	case buttonActionDownloadTranscript:
+		m.handleDownloadTranscriptButton(event, sessionID)
+	case buttonActionResendTranscriptDM:
+		m.handleResendTranscriptDMButton(event, sessionID)
+	default:
+		m.respondUnknownComponent(event)
+	}
+}
+
+func (m *Manager) respondUnknownComponent(event discord.ComponentEvent) {
+	slog.Warn("unknown component interaction", "component", event.CustomID, "guild_id", event.GuildID, "user_id", event.UserID)
+	m.respondComponentEphemeral(event, messageEphemeralUnknownCommand)
+}
+
+// stopSessionByID は実行中のセッションをメンバーの操作で止め、止めたボイスチャンネルの ID を返す
+func (m *Manager) stopSessionByID(guildID, sessionID string, mb member) (string, error) {
+	target, ok := m.runningSessionByID(guildID, sessionID)
+	if !ok {
+		return "", ErrSessionNotRunning
+	}
+	_, participated := target.participants[mb.userID]
+	if err := m.authorizeStop(context.Background(), guildID, mb, target.startedByUserID, participated); err != nil {
+		if !participated {
+			return "", ErrNotSessionParticipant
+		}
+		return "", err
+	}
+	channelID := target.channelID
+	stopped, err := m.stopSessionBy(guildID, channelID, mb.userID, stopReasonManualButton)
+	if err != nil {
+		return "", err
+	}
+	if !stopped {
+		return "", ErrSessionNotRunning
+	}
+	return channelID, nil
+}
+
+type runningSessionTarget struct {
+	channelID       string
+	startedByUserID string
+	participants    map[string]participantState
+}
+
+func (m *Manager) runningSessionByID(guildID, sessionID string) (runningSessionTarget, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, rs := range m.sessions {
+		if rs == nil || rs.repoSession == nil || rs.repoSession.ID != sessionID || rs.repoSession.GuildID != guildID {
+			continue
+		}
+		participants := make(map[string]participantState, len(rs.allParticipants))
+		for userID, state := range rs.allParticipants {
+			participants[userID] = state
+		}
+		return runningSessionTarget{channelID: rs.repoSession.ChannelID, startedByUserID: rs.stopOwnerUserID(), participants: participants}, true
+	}
+	return runningSessionTarget{}, false
+}
+
+func (m *Manager) handleStopButton(event discord.ComponentEvent, sessionID string) {
+	channelID, err := m.stopSessionByID(event.GuildID, sessionID, componentMember(event))
+	if err != nil {
+		slog.Warn("stop button rejected", "error", err, "guild_id", event.GuildID, "user_id", event.UserID, "session_id", sessionID)
+		content := messageEphemeralStopFailed
+		if errors.Is(err, ErrSessionNotRunning) || errors.Is(err, ErrNotSessionParticipant) || errors.Is(err, ErrNotAllowedToStop) {
+			content = componentErrorMessage(err)
+		}
+		m.respondComponentEphemeral(event, content)
+		return
+	}
+	m.respondComponentEphemeral(event, m.stopEphemeralMessage(channelID))
+}
+
+// LoadTranscriptForParticipant は終了済みセッションの添付ファイルを参加者に渡すために読み出す
+func (m *Manager) LoadTranscriptForParticipant(ctx context.Context, guildID, userID, sessionID string) (*repository.Session, *repository.TranscriptArtifact, error) {
+	s, err := m.authorizeSessionParticipant(ctx, guildID, userID, sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	artifact, err := m.repo.GetTranscriptArtifact(ctx, s.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if artifact == nil || artifact.TranscriptFilename == "" {
+		return nil, nil, ErrTranscriptNotReady
+	}
+	return s, artifact, nil
+}
+
+func (m *Manager) handleDownloadTranscriptButton(event discord.ComponentEvent, sessionID string) {
+	s, artifact, err := m.LoadTranscriptForParticipant(context.Background(), event.GuildID, event.UserID, sessionID)
+	if err != nil {
+		slog.Warn("transcript download rejected", "error", err, "guild_id", event.GuildID, "user_id", event.UserID, "session_id", sessionID)
+		m.respondComponentEphemeral(event, componentErrorMessage(err))
+		return
+	}
+	if event.RespondEphemeralFile == nil {
+		return
+	}
+	if err := event.RespondEphemeralFile(discord.FileMessage{
+		Content:  transcriptDMMessage(s.ChannelID),
+		Filename: artifact.TranscriptFilename,
+		FileBody: []byte(artifact.TranscriptText),
+	}); err != nil {
+		slog.Error("failed to respond transcript file", "error", err, "guild_id", event.GuildID, "user_id", event.UserID, "session_id", sessionID)
+	}
+}
+
+func (m *Manager) handleResendTranscriptDMButton(event discord.ComponentEvent, sessionID string) {
+	s, artifact, err := m.LoadTranscriptForParticipant(context.Background(), event.GuildID, event.UserID, sessionID)
+	if err == nil {
+		err = m.sendTranscriptDM(event.UserID, s, artifact)
+	}
+	if err != nil {
+		slog.Warn("failed to resend transcript to DM", "error", err, "guild_id", event.GuildID, "user_id", event.UserID, "session_id", sessionID)
+		m.respondComponentEphemeral(event, componentErrorMessage(err))
+		return
+	}
+	m.respondComponentEphemeral(event, messageEphemeralTranscriptSentDM)
+}
+
+func (m *Manager) sendTranscriptDM(userID string, s *repository.Session, artifact *repository.TranscriptArtifact) error {
+	if err := m.discord.SendDirectMessageWithFile(userID, discord.FileMessage{
+		Content:  transcriptDMMessage(s.ChannelID),
+		Filename: artifact.TranscriptFilename,
+		FileBody: []byte(artifact.TranscriptText),
+	}); err != nil {
+		return errors.Join(ErrDirectMessageFailed, err)
+	}
+	return nil
+}
+
+func (m *Manager) respondComponentEphemeral(event discord.ComponentEvent, content string) {
+	if event.RespondEphemeral == nil {
+		return
+	}
+	if err := event.RespondEphemeral(content); err != nil {
+		slog.Error("failed to respond ephemeral message", "error", err, "guild_id", event.GuildID, "channel_id", event.ChannelID, "component", event.CustomID, "user_id", event.UserID)
+	}
+}
+
+func componentErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrSessionNotRunning):
+		return messageEphemeralSessionAlreadyStopped
+	case errors.Is(err, ErrTranscriptNotReady):
+		return messageEphemeralTranscriptNotReady
+	case errors.Is(err, ErrNotSessionParticipant):
+		return messageEphemeralNotSessionOperator
+	case errors.Is(err, ErrNotAllowedToStop):
+		return messageEphemeralStopNotAllowed
+	case errors.Is(err, ErrDirectMessageFailed):
+		return messageEphemeralTranscriptDMFailed
+	default:
+		return messageEphemeralTranscriptLoadFailed
+	}
+}
@@ -0,0 +1,110 @@
+package session
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+)
+
+func pressButton(manager *Manager, userID, customID string) (string, *discord.FileMessage) {
+	var content string
+	var file *discord.FileMessage
+	manager.HandleComponent(discord.ComponentEvent{
+		GuildID:  "guild-1",
+		CustomID: customID,
+		UserID:   userID,
+		RespondEphemeral: func(c string) error {
+			content = c
+			return nil
+		},
+		RespondEphemeralFile: func(msg discord.FileMessage) error {
+			file = &msg
+			return nil
+		},
+	})
+	return content, file
+}
+
+func TestHandleComponent_StopButtonStopsSessionForParticipant(t *testing.T) {
+	repo := &mockRepository{}
+	dc := &mockDiscordClient{}
+	manager := newTestManager(repo, dc)
+	manager.sessions[manager.sessionKey("guild-1", "vc-1")] = &runningSession{
+		repoSession:        &repository.Session{ID: "session-1", GuildID: "guild-1", ChannelID: "vc-1", StartedAt: time.Now(), Status: repository.SessionStatusRunning},
+		activeParticipants: map[string]participantState{"user-1": {}},
+		allParticipants:    map[string]participantState{"user-1": {}},
+	}
+	customID := sessionButtonCustomID(buttonActionStop, "session-1")
+
+	if got, _ := pressButton(manager, "user-2", customID); got != messageEphemeralNotSessionOperator {
+		t.Fatalf("unexpected response for non-participant: %q", got)
+	}
+	if !manager.isSessionRunning("guild-1", "vc-1") {
+		t.Fatal("expected session to keep running")
+	}
+	if got, _ := pressButton(manager, "user-1", customID); got != manager.stopEphemeralMessage("vc-1") {
+		t.Fatalf("unexpected stop response: %q", got)
+	}
+	if manager.isSessionRunning("guild-1", "vc-1") {
+		t.Fatal("expected session to stop")
+	}
+	waitUntil(t, time.Second, func() bool { return len(dc.fileCalls) == 1 }, "expected transcript attachment")
+	if !isStopEmbed(dc.embedCalls[0], stopReasonManualButton) {
+		t.Fatalf("unexpected stop message: %+v", dc.embedCalls[0])
+	}
+	if got, _ := pressButton(manager, "user-1", customID); got != messageEphemeralSessionAlreadyStopped {
+		t.Fatalf("unexpected response after stop: %q", got)
+	}
+}
+
+func TestHandleComponent_DownloadTranscript(t *testing.T) {
+	repo := newCorrectionFixture(repository.SessionStatusCompleted)
+	manager := newTestManager(repo, &mockDiscordClient{})
+	customID := sessionButtonCustomID(buttonActionDownloadTranscript, "session-1")
+
+	if got, _ := pressButton(manager, "user-1", customID); got != messageEphemeralTranscriptNotReady {
+		t.Fatalf("unexpected response before finalize: %q", got)
+	}
+	repo.savedArtifactCalls = append(repo.savedArtifactCalls, repository.SaveTranscriptArtifactInput{SessionID: "session-1", TranscriptFilename: "transcript.txt", TranscriptText: "本文"})
+
+	if got, _ := pressButton(manager, "user-2", customID); got != messageEphemeralNotSessionOperator {
+		t.Fatalf("unexpected response for non-participant: %q", got)
+	}
+	_, file := pressButton(manager, "user-1", customID)
+	if file == nil || file.Filename != "transcript.txt" || string(file.FileBody) != "本文" {
+		t.Fatalf("unexpected file response: %+v", file)
+	}
+}
+
+func TestHandleComponent_ResendTranscriptDM(t *testing.T) {
+	repo := newCorrectionFixture(repository.SessionStatusCompleted)
+	repo.savedArtifactCalls = append(repo.savedArtifactCalls, repository.SaveTranscriptArtifactInput{SessionID: "session-1", TranscriptFilename: "transcript.txt", TranscriptText: "本文"})
+	dc := &mockDiscordClient{}
+	manager := newTestManager(repo, dc)
+	customID := sessionButtonCustomID(buttonActionResendTranscriptDM, "session-1")
+
+	if got, _ := pressButton(manager, "user-1", customID); got != messageEphemeralTranscriptSentDM {
+		t.Fatalf("unexpected response: %q", got)
+	}
+	if len(dc.dmCalls) != 1 || dc.dmCalls[0].ChannelID != "dm-user-1" || dc.dmCalls[0].Filename != "transcript.txt" {
+		t.Fatalf("unexpected DMs: %+v", dc.dmCalls)
+	}
+
+	dc.dmErr = errors.New("cannot send messages to this user")
+	if got, _ := pressButton(manager, "user-1", customID); got != messageEphemeralTranscriptDMFailed {
+		t.Fatalf("unexpected response when DM fails: %q", got)
+	}
+}
+
+func TestHandleComponent_RejectsUnknownButtons(t *testing.T) {
+	manager := newTestManager(&mockRepository{}, &mockDiscordClient{})
+
+	for _, customID := range []string{"mojiokoshi-stop", "mojiokoshi-stop:", "other:session-1"} {
+		if got, _ := pressButton(manager, "user-1", customID); got != messageEphemeralUnknownCommand {
+			t.Fatalf("unexpected response for %q: %q", customID, got)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+)
+
+var ErrInvalidConsentMode = errors.New("invalid consent mode")
+
+// 同意を読み込めなかった参加者を読み込み直す間隔
+const consentRetryInterval = 30 * time.Second
+
+// consentGate は音声パケットごとに参照されるため、Manager のロックとは分けて持つ。
+// 同意をまだ読み込めていない参加者は unresolved に入れ、拒否した人と同じく音声を使わない
+type consentGate struct {
+	mu         sync.RWMutex
+	mode       repository.ConsentMode
+	consents   map[string]repository.RecordingConsent
+	unresolved map[string]struct{}
+}
+
+func newConsentGate(mode repository.ConsentMode, consents map[string]repository.RecordingConsent) *consentGate {
+	g := &consentGate{
+		mode:       consentModeOrDefault(mode),
+		consents:   make(map[string]repository.RecordingConsent, len(consents)),
+		unresolved: make(map[string]struct{}),
+	}
+	for userID, consent := range consents {
+		g.consents[userID] = consent
+	}
+	return g
+}
+
+// 同意を読み込んでいない人の音声は使わない。
+// 話者を特定できない音声は、拒否した人か同意を読み込めていない参加者がいる場合はその人の声かもしれないため使わない
+func (g *consentGate) allows(userID string) bool {
+	if g == nil {
+		return true
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if discord.IsUnidentifiedSpeaker(userID) {
+		return g.mode != repository.ConsentModeExclude && len(g.unresolved) == 0 && !g.anyDeniedLocked()
+	}
+	consent, ok := g.consents[userID]
+	if !ok {
+		return false
+	}
+	switch consent {
+	case repository.RecordingConsentDenied:
+		return false
+	case repository.RecordingConsentGranted:
+		return true
+	default:
+		return g.mode != repository.ConsentModeExclude
+	}
+}
+
+func (g *consentGate) anyDeniedLocked() bool {
+	for _, consent := range g.consents {
+		if consent == repository.RecordingConsentDenied {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *consentGate) knows(userID string) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	_, ok := g.consents[userID]
+	return ok
+}
+
+// markUnresolved は同意を読み込む前の参加者を登録し、読み込めるまで音声を使わないようにする
+func (g *consentGate) markUnresolved(userIDs ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, userID := range userIDs {
+		if _, ok := g.consents[userID]; !ok {
+			g.unresolved[userID] = struct{}{}
+		}
+	}
+}
+
+func (g *consentGate) unresolvedUserIDs() []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	userIDs := make([]string, 0, len(g.unresolved))
+	for userID := range g.unresolved {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+	return userIDs
+}
+
+func (g *consentGate) set(userID string, consent repository.RecordingConsent) {
+	g.mu.Lock()
+	g.consents[userID] = consent
+	delete(g.unresolved, userID)
+	g.mu.Unlock()
+}
+
+func (g *consentGate) consent(userID string) repository.RecordingConsent {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.consents[userID]
+}
+
+func consentModeOrDefault(mode repository.ConsentMode) repository.ConsentMode {
+	if mode == "" {
+		return repository.ConsentModeAnnounce
+	}
+	return mode
+}
+
+func parseConsentMode(value string) (repository.ConsentMode, error) {
+	switch mode := repository.ConsentMode(value); mode {
+	case repository.ConsentModeAnnounce, repository.ConsentModeExclude:
+		return mode, nil
+	default:
+		return "", ErrInvalidConsentMode
+	}
+}
+
+// 同意の読み込みに失敗した参加者は読み込めるまで音声を使わず、retryUnresolvedConsents で読み込み直す
+func (m *Manager) loadConsentGate(ctx context.Context, guildID string, participants map[string]participantState) *consentGate {
+	settings, _ := m.guildSettingsBestEffort(ctx, guildID)
+	userIDs := make([]string, 0, len(participants))
+	for userID := range participants {
+		userIDs = append(userIDs, userID)
+	}
+	gate := newConsentGate(settings.ConsentMode, nil)
+	gate.markUnresolved(userIDs...)
+	consents, err := m.repo.ListRecordingConsents(ctx, guildID, userIDs)
+	if err != nil {
+		slog.Warn("failed to load recording consents; excluding participants until loaded", "error", err, "guild_id", guildID)
+		return gate
+	}
+	for _, userID := range userIDs {
+		gate.set(userID, consents[userID])
+	}
+	return gate
+}
+
+// 途中から参加した人の同意を読み込み、未確認であればチャンネルで案内する。
+// 読み込むまでの間と読み込みに失敗した場合は、その人の音声を使わない
+func (m *Manager) applyJoinedParticipantConsent(guildID, channelID, userID string, userIsBot bool) {
+	if strings.TrimSpace(channelID) == "" || strings.TrimSpace(userID) == "" {
+		return
+	}
+	m.mu.Lock()
+	rs, ok := m.sessions[m.sessionKey(guildID, channelID)]
+	m.mu.Unlock()
+	if !ok || rs.consent == nil || rs.consent.knows(userID) {
+		return
+	}
+	rs.consent.markUnresolved(userID)
+	consents, err := m.repo.ListRecordingConsents(context.Background(), guildID, []string{userID})
+	if err != nil {
+		slog.Warn("failed to load recording consent for joined participant; excluding until loaded", "error", err, "guild_id", guildID, "user_id", userID)
+		return
+	}
+	consent := consents[userID]
+	rs.consent.set(userID, consent)
+	if consent != repository.RecordingConsentUnknown || userIsBot {
+		return
+	}
+	m.sendConsentJoinNotice(rs, channelID, userID)
+}
+
+func (m *Manager) sendConsentJoinNotice(rs *runningSession, channelID, userID string) {
+	if _, err := m.discord.SendChannelMessage(channelID, consentJoinNotice(rs.consent.mode, userID)); err != nil {
+		slog.Warn("failed to send consent notice", "error", err, "session_id", rs.repoSession.ID, "user_id", userID)
+	}
+}
+
+// retryUnresolvedConsents はセッションが終わるまで、読み込めていない同意を定期的に読み込み直す
+func (m *Manager) retryUnresolvedConsents(ctx context.Context, rs *runningSession, channelID string) {
+	ticker := time.NewTicker(consentRetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.resolveUnresolvedConsents(ctx, rs, channelID)
+		}
+	}
+}
+
+func (m *Manager) resolveUnresolvedConsents(ctx context.Context, rs *runningSession, channelID string) {
+	userIDs := rs.consent.unresolvedUserIDs()
+	if len(userIDs) == 0 {
+		return
+	}
+	consents, err := m.repo.ListRecordingConsents(ctx, rs.repoSession.GuildID, userIDs)
+	if err != nil {
+		slog.Warn("failed to reload recording consents", "error", err, "session_id", rs.repoSession.ID, "users", len(userIDs))
+		return
+	}
+	for _, userID := range userIDs {
+		consent := consents[userID]
+		rs.consent.set(userID, consent)
+		m.mu.Lock()
+		state, joined := rs.allParticipants[userID]
+		m.mu.Unlock()
+		if consent == repository.RecordingConsentUnknown && joined && !state.isBot {
+			m.sendConsentJoinNotice(rs, channelID, userID)
+		}
+	}
+	slog.Info("reloaded recording consents", "session_id", rs.repoSession.ID, "users", len(userIDs))
+}
+
+func (m *Manager) handleConsentCommand(event discord.SlashCommandEvent, consent repository.RecordingConsent) {
+	if err := m.repo.SetRecordingConsent(context.Background(), event.GuildID, event.UserID, consent); err != nil {
+		slog.Error("failed to save recording consent", "error", err, "guild_id", event.GuildID, "user_id", event.UserID, "consent", consent)
+		m.respondEphemeral(event, messageEphemeralConsentFailed)
+		return
+	}
+	m.updateRunningConsents(event.GuildID, event.UserID, consent)
+	if consent == repository.RecordingConsentDenied {
+		m.respondEphemeral(event, messageEphemeralOptedOut)
+		return
+	}
+	m.respondEphemeral(event, messageEphemeralOptedIn)
+}
+
+// 実行中のセッションにもすぐに反映し、以降の音声から除外または対象にする
+func (m *Manager) updateRunningConsents(guildID, userID string, consent repository.RecordingConsent) {
+	prefix := guildID + ":"
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, rs := range m.sessions {
+		if strings.HasPrefix(key, prefix) && rs != nil && rs.consent != nil {
+			rs.consent.set(userID, consent)
+		}
+	}
+}
+
+func (m *Manager) SetGuildConsentMode(ctx context.Context, guildID, value, userID string) (repository.GuildSettings, error) {
+	mode, err := parseConsentMode(value)
+	if err != nil {
+		return repository.GuildSettings{}, err
+	}
+	settings, err := m.GuildSettings(ctx, guildID)
+	if err != nil {
+		return settings, err
+	}
+	settings.ConsentMode = mode
+	settings.UpdatedByUserID = userID
+	if err := m.repo.SaveGuildSettings(ctx, settings); err != nil {
+		return settings, err
+	}
+	return settings, nil
+}
+
+// 開始メッセージに載せる、文字起こしの対象外の参加者と同意を確認していない参加者
+func (m *Manager) consentParticipantLists(rs *runningSession) (excluded, unconfirmed []string) {
+	if rs.consent == nil {
+		return nil, nil
+	}
+	m.mu.Lock()
+	userIDs := make([]string, 0, len(rs.allParticipants))
+	for userID, state := range rs.allParticipants {
+		if !state.isBot {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	m.mu.Unlock()
+	sort.Strings(userIDs)
+	for _, userID := range userIDs {
+		switch {
+		case !rs.consent.allows(userID):
+			excluded = append(excluded, userMention(userID))
+		case rs.consent.consent(userID) == repository.RecordingConsentUnknown:
+			unconfirmed = append(unconfirmed, userMention(userID))
+		}
+	}
+	return excluded, unconfirmed
+}
+
+func (m *Manager) consentEmbedFields(rs *runningSession) []discord.EmbedField {
+	mode := repository.ConsentModeAnnounce
+	if rs.consent != nil {
+		mode = rs.consent.mode
+	}
+	fields := []discord.EmbedField{{Name: messageEmbedFieldConsent, Value: consentPolicyText(mode)}}
+	excluded, unconfirmed := m.consentParticipantLists(rs)
+	if len(excluded) > 0 {
+		fields = append(fields, discord.EmbedField{Name: messageEmbedFieldExcluded, Value: embedFieldList(excluded, " ")})
+	}
+	if len(unconfirmed) > 0 {
+		fields = append(fields, discord.EmbedField{Name: messageEmbedFieldUnconfirmed, Value: embedFieldList(unconfirmed, " ")})
+	}
+	return fields
+}
+
+func consentPolicyText(mode repository.ConsentMode) string {
+	if mode == repository.ConsentModeExclude {
+		return messageConsentPolicyExclude
+	}
+	return messageConsentPolicyAnnounce
+}
+
+func consentJoinNotice(mode repository.ConsentMode, userID string) string {
+	if mode == repository.ConsentModeExclude {
+		return fmt.Sprintf(messageConsentJoinExcludeFormat, userID)
+	}
+	return fmt.Sprintf(messageConsentJoinAnnounceFormat, userID)
+}
@@ -0,0 +1,273 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/foxseedlab/mojiokoshin/internal/audio"
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+)
+
+func TestConsentGate_Allows(t *testing.T) {
+	consents := map[string]repository.RecordingConsent{
+		"granted": repository.RecordingConsentGranted,
+		"denied":  repository.RecordingConsentDenied,
+		"unknown": repository.RecordingConsentUnknown,
+	}
+	unidentified := discord.UnidentifiedSpeakerID(1234)
+	tests := []struct {
+		mode   repository.ConsentMode
+		userID string
+		want   bool
+	}{
+		{repository.ConsentModeAnnounce, "granted", true},
+		{repository.ConsentModeAnnounce, "denied", false},
+		{repository.ConsentModeAnnounce, "unknown", true},
+		{repository.ConsentModeAnnounce, "not-loaded", false},
+		{repository.ConsentModeAnnounce, unidentified, false},
+		{repository.ConsentModeExclude, "granted", true},
+		{repository.ConsentModeExclude, "denied", false},
+		{repository.ConsentModeExclude, "unknown", false},
+		{repository.ConsentModeExclude, unidentified, false},
+		{"", "unknown", true},
+	}
+	for _, tt := range tests {
+		if got := newConsentGate(tt.mode, consents).allows(tt.userID); got != tt.want {
+			t.Errorf("mode=%q user=%q: got %v, want %v", tt.mode, tt.userID, got, tt.want)
+		}
+	}
+
+	gate := newConsentGate(repository.ConsentModeAnnounce, map[string]repository.RecordingConsent{"granted": repository.RecordingConsentGranted})
+	if !gate.allows(unidentified) {
+		t.Fatal("expected unidentified audio to be allowed when nobody opted out")
+	}
+	gate.markUnresolved("late")
+	if gate.allows("late") || gate.allows(unidentified) {
+		t.Fatal("expected audio to be dropped while a participant's consent is unresolved")
+	}
+	gate.set("late", repository.RecordingConsentUnknown)
+	if !gate.allows("late") || !gate.allows(unidentified) {
+		t.Fatal("expected audio to be allowed once consent is resolved")
+	}
+	var nilGate *consentGate
+	if !nilGate.allows("anyone") {
+		t.Fatal("expected nil gate to allow audio")
+	}
+}
+
+func runConsentCommand(manager *Manager, command string) string {
+	var got string
+	manager.HandleSlashCommand(discord.SlashCommandEvent{
+		GuildID:     "guild-1",
+		CommandName: command,
+		UserID:      "user-1",
+		RespondEphemeral: func(content string) error {
+			got = content
+			return nil
+		},
+	})
+	return got
+}
+
+func TestHandleSlashCommand_OptOutAndOptInUpdateRunningSession(t *testing.T) {
+	repo := &mockRepository{}
+	manager := newTestManager(repo, &mockDiscordClient{})
+	gate := newConsentGate(repository.ConsentModeAnnounce, nil)
+	manager.sessions[manager.sessionKey("guild-1", "vc-1")] = &runningSession{
+		repoSession: &repository.Session{ID: "session-1"},
+		consent:     gate,
+	}
+
+	if got := runConsentCommand(manager, commandMojiokoshiOptOut); got != messageEphemeralOptedOut {
+		t.Fatalf("unexpected opt-out response: %q", got)
+	}
+	if repo.consents["guild-1:user-1"] != repository.RecordingConsentDenied {
+		t.Fatalf("expected denied consent to be stored: %+v", repo.consents)
+	}
+	if gate.allows("user-1") {
+		t.Fatal("expected running session to drop audio after opt-out")
+	}
+
+	if got := runConsentCommand(manager, commandMojiokoshiOptIn); got != messageEphemeralOptedIn {
+		t.Fatalf("unexpected opt-in response: %q", got)
+	}
+	if repo.consents["guild-1:user-1"] != repository.RecordingConsentGranted || !gate.allows("user-1") {
+		t.Fatal("expected consent to be granted")
+	}
+}
+
+func TestHandleSlashCommand_OptOutFailureKeepsRunningSession(t *testing.T) {
+	repo := &mockRepository{consentErr: errors.New("db down")}
+	manager := newTestManager(repo, &mockDiscordClient{})
+	gate := newConsentGate(repository.ConsentModeAnnounce, map[string]repository.RecordingConsent{"user-1": repository.RecordingConsentUnknown})
+	manager.sessions[manager.sessionKey("guild-1", "vc-1")] = &runningSession{
+		repoSession: &repository.Session{ID: "session-1"},
+		consent:     gate,
+	}
+
+	if got := runConsentCommand(manager, commandMojiokoshiOptOut); got != messageEphemeralConsentFailed {
+		t.Fatalf("unexpected response: %q", got)
+	}
+	if !gate.allows("user-1") {
+		t.Fatal("expected gate to be unchanged when saving fails")
+	}
+}
+
+func TestHandleSettingsCommand_ConsentMode(t *testing.T) {
+	repo := &mockRepository{}
+	manager := newTestManager(repo, &mockDiscordClient{})
+
+	var got string
+	manager.HandleSlashCommand(settingsCommand(map[string]string{optionConsentMode: string(repository.ConsentModeExclude)}, &got))
+	if !strings.Contains(got, messageSettingsUpdated) || !strings.Contains(got, slashChoiceConsentExclude) {
+		t.Fatalf("unexpected response: %q", got)
+	}
+	if repo.guildSettings["guild-1"].ConsentMode != repository.ConsentModeExclude {
+		t.Fatalf("expected consent mode to be saved: %+v", repo.guildSettings["guild-1"])
+	}
+	manager.HandleSlashCommand(settingsCommand(map[string]string{optionConsentMode: "invalid"}, &got))
+	if got != messageEphemeralConsentModeInvalid {
+		t.Fatalf("unexpected response for invalid mode: %q", got)
+	}
+}
+
+func TestApplyJoinedParticipantConsent_AnnouncesUnconfirmedParticipant(t *testing.T) {
+	repo := &mockRepository{consents: map[string]repository.RecordingConsent{"guild-1:user-2": repository.RecordingConsentDenied}}
+	dc := &mockDiscordClient{}
+	manager := newTestManager(repo, dc)
+	gate := newConsentGate(repository.ConsentModeExclude, nil)
+	manager.sessions[manager.sessionKey("guild-1", "vc-1")] = &runningSession{
+		repoSession: &repository.Session{ID: "session-1"},
+		consent:     gate,
+	}
+
+	manager.applyJoinedParticipantConsent("guild-1", "vc-1", "user-2", false)
+	manager.applyJoinedParticipantConsent("guild-1", "vc-1", "user-3", false)
+	manager.applyJoinedParticipantConsent("guild-1", "vc-1", "user-3", false)
+
+	if gate.allows("user-2") || gate.allows("user-3") {
+		t.Fatal("expected opted-out and unconfirmed participants to be excluded")
+	}
+	if len(dc.sendCalls) != 1 || dc.sendCalls[0] != consentJoinNotice(repository.ConsentModeExclude, "user-3") {
+		t.Fatalf("expected a single notice for the unconfirmed participant: %+v", dc.sendCalls)
+	}
+}
+
+func TestStartChannelEmbed_ListsConsentPolicyAndParticipants(t *testing.T) {
+	manager := newTestManager(&mockRepository{}, &mockDiscordClient{})
+	rs := &runningSession{
+		repoSession:     &repository.Session{ID: "session-1", StartedAt: time.Now()},
+		startedByUserID: "user-1",
+		allParticipants: map[string]participantState{"user-1": {}, "user-2": {}, "user-3": {}, "bot-1": {isBot: true}},
+		consent: newConsentGate(repository.ConsentModeAnnounce, map[string]repository.RecordingConsent{
+			"user-1": repository.RecordingConsentGranted,
+			"user-2": repository.RecordingConsentDenied,
+			"user-3": repository.RecordingConsentUnknown,
+		}),
+	}
+
+	fields := manager.startChannelEmbed(rs, "vc-1", false).Embeds[0].Fields
+	values := make(map[string]string, len(fields))
+	for _, f := range fields {
+		values[f.Name] = f.Value
+	}
+	if values[messageEmbedFieldConsent] != messageConsentPolicyAnnounce {
+		t.Fatalf("unexpected consent policy: %q", values[messageEmbedFieldConsent])
+	}
+	if values[messageEmbedFieldExcluded] != "<@user-2>" {
+		t.Fatalf("unexpected excluded participants: %q", values[messageEmbedFieldExcluded])
+	}
+	if values[messageEmbedFieldUnconfirmed] != "<@user-3>" {
+		t.Fatalf("unexpected unconfirmed participants: %q", values[messageEmbedFieldUnconfirmed])
+	}
+}
+
+type recordingMixer struct {
+	mockMixer
+	mu    sync.Mutex
+	users []string
+}
+
+func (m *recordingMixer) WriteOpusPacket(userID string, _ []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.users = append(m.users, userID)
+}
+
+func (m *recordingMixer) writtenUsers() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.users)
+}
+
+func TestStartSession_DropsUnidentifiedSpeakerWhenParticipantOptedOut(t *testing.T) {
+	unidentified := discord.UnidentifiedSpeakerID(1234)
+	tests := []struct {
+		name     string
+		consents map[string]repository.RecordingConsent
+		want     []string
+	}{
+		{name: "opted out", consents: map[string]repository.RecordingConsent{"guild-1:user-1": repository.RecordingConsentDenied}, want: nil},
+		{name: "nobody opted out", want: []string{unidentified, "user-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockRepository{consents: tt.consents}
+			dc := &mockDiscordClient{
+				voicePackets: []voicePacket{{userID: unidentified, opus: []byte{1}}, {userID: "user-1", opus: []byte{2}}},
+				voiceDone:    make(chan struct{}),
+			}
+			manager := newTestManager(repo, dc)
+			mixer := &recordingMixer{}
+			manager.newMixer = func() audio.Mixer { return mixer }
+
+			if err := manager.startSession("guild-1", "vc-1", "user-1", false, manager.defaultSessionOptions()); err != nil {
+				t.Fatalf("start session: %v", err)
+			}
+			defer manager.StopAllSessions(stopReasonServerClosed)
+			select {
+			case <-dc.voiceDone:
+			case <-time.After(time.Second):
+				t.Fatal("expected voice packets to be delivered")
+			}
+			if got := mixer.writtenUsers(); !slices.Equal(got, tt.want) {
+				t.Fatalf("unexpected mixed speakers: %v", got)
+			}
+		})
+	}
+}
+
+func TestLoadConsentGate_ExcludesParticipantsUntilConsentsLoad(t *testing.T) {
+	repo := &mockRepository{consentErr: errors.New("db down")}
+	dc := &mockDiscordClient{}
+	manager := newTestManager(repo, dc)
+	participants := map[string]participantState{"user-1": {}}
+	rs := &runningSession{
+		repoSession:     &repository.Session{ID: "session-1", GuildID: "guild-1"},
+		allParticipants: participants,
+		consent:         manager.loadConsentGate(context.Background(), "guild-1", participants),
+	}
+	if rs.consent.allows("user-1") || rs.consent.allows(discord.UnidentifiedSpeakerID(1)) {
+		t.Fatal("expected audio to be dropped when consents cannot be loaded")
+	}
+
+	manager.resolveUnresolvedConsents(context.Background(), rs, "vc-1")
+	if rs.consent.allows("user-1") {
+		t.Fatal("expected participant to stay excluded while loading keeps failing")
+	}
+
+	repo.consentErr = nil
+	manager.resolveUnresolvedConsents(context.Background(), rs, "vc-1")
+	if !rs.consent.allows("user-1") || len(rs.consent.unresolvedUserIDs()) != 0 {
+		t.Fatal("expected consent to be resolved after a successful retry")
+	}
+	if len(dc.sendCalls) != 1 || dc.sendCalls[0] != consentJoinNotice(repository.ConsentModeAnnounce, "user-1") {
+		t.Fatalf("expected a notice for the unconfirmed participant: %+v", dc.sendCalls)
+	}
+}
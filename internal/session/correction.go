@@ -0,0 +1,298 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+)
+
+const (
+	fixTranscriptModalPrefix  = "mojiokoshi-fix-transcript:"
+	fixTranscriptInputContent = "content"
+	// Discord のメッセージ本文の上限に合わせる
+	maxCorrectionLength = 2000
+)
+
+var (
+	ErrSegmentNotFound       = errors.New("transcript segment not found")
+	ErrSegmentSuperseded     = errors.New("transcript segment was replaced by a newer revision")
+	ErrNotSessionParticipant = errors.New("user did not participate in the session")
+	ErrEmptyCorrection       = errors.New("corrected text is empty")
+	ErrCorrectionTooLong     = errors.New("corrected text is too long")
+	ErrSessionPurged         = errors.New("session data was purged by the retention policy")
+)
+
+type CorrectSegmentInput struct {
+	SessionID    string
+	SegmentIndex int
+	GuildID      string
+	UserID       string
+	Content      string
+}
+
+func (m *Manager) CorrectSegment(ctx context.Context, input CorrectSegmentInput) (*repository.TranscriptSegment, error) {
+	content, err := normalizeCorrection(input.Content)
+	if err != nil {
+		return nil, err
+	}
+	s, err := m.authorizeSessionParticipant(ctx, input.GuildID, input.UserID, strings.TrimSpace(input.SessionID))
+	if err != nil {
+		return nil, err
+	}
+	if sessionPurged(s) {
+		return nil, ErrSessionPurged
+	}
+	seg, err := m.repo.GetSegment(ctx, s.ID, input.SegmentIndex)
+	if err != nil {
+		return nil, err
+	}
+	if seg == nil {
+		return nil, ErrSegmentNotFound
+	}
+	return m.applyCorrection(ctx, s, seg, input.UserID, content)
+}
+
+func (m *Manager) correctSegmentByMessage(ctx context.Context, guildID, userID, messageID, content string) (*repository.TranscriptSegment, error) {
+	content, err := normalizeCorrection(content)
+	if err != nil {
+		return nil, err
+	}
+	s, seg, err := m.loadCorrectableMessage(ctx, guildID, userID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	return m.applyCorrection(ctx, s, seg, userID, content)
+}
+
+func (m *Manager) loadCorrectableMessage(ctx context.Context, guildID, userID, messageID string) (*repository.Session, *repository.TranscriptSegment, error) {
+	if strings.TrimSpace(messageID) == "" {
+		return nil, nil, ErrSegmentNotFound
+	}
+	seg, err := m.repo.GetSegmentByDiscordMessageID(ctx, messageID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if seg == nil {
+		return nil, nil, ErrSegmentNotFound
+	}
+	s, err := m.authorizeSessionParticipant(ctx, guildID, userID, seg.SessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sessionPurged(s) {
+		return nil, nil, ErrSessionPurged
+	}
+	if seg.Revision != s.TranscriptRevision {
+		return nil, nil, ErrSegmentSuperseded
+	}
+	return s, seg, nil
+}
+
+func (m *Manager) authorizeSessionParticipant(ctx context.Context, guildID, userID, sessionID string) (*repository.Session, error) {
+	s, err := m.repo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil || (guildID != "" && s.GuildID != guildID) {
+		return nil, ErrSegmentNotFound
+	}
+	participant, err := m.isSessionParticipant(ctx, s.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !participant {
+		return nil, ErrNotSessionParticipant
+	}
+	return s, nil
+}
+
+func (m *Manager) isSessionParticipant(ctx context.Context, sessionID, userID string) (bool, error) {
+	if strings.TrimSpace(userID) == "" {
+		return false, nil
+	}
+	if participants, running := m.runningSessionParticipants(sessionID); running {
+		_, ok := participants[userID]
+		return ok, nil
+	}
+	participants, err := m.repo.ListSessionParticipants(ctx, sessionID)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range participants {
+		if p.UserID == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *Manager) runningSessionParticipants(sessionID string) (map[string]struct{}, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, rs := range m.sessions {
+		if rs == nil || rs.repoSession == nil || rs.repoSession.ID != sessionID {
+			continue
+		}
+		out := make(map[string]struct{}, len(rs.allParticipants))
+		for userID := range rs.allParticipants {
+			out[userID] = struct{}{}
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+func (m *Manager) applyCorrection(ctx context.Context, s *repository.Session, seg *repository.TranscriptSegment, userID, content string) (*repository.TranscriptSegment, error) {
+	content, _ = m.redactor.Redact(content)
+	if seg.Content == content {
+		return seg, nil
+	}
+	editedAt := time.Now()
+	translations := m.translateSegment(ctx, content, seg.Language)
+	if err := m.repo.CorrectSegment(ctx, repository.CorrectSegmentInput{
+		SegmentID:      seg.ID,
+		Content:        content,
+		EditedByUserID: userID,
+		EditedAt:       editedAt,
+		Translations:   translations,
+	}); err != nil {
+		return nil, fmt.Errorf("correct segment: %w", err)
+	}
+	corrected := *seg
+	corrected.Content = content
+	corrected.CorrectedAt = &editedAt
+	corrected.Translations = translations
+	corrected.Confidence = nil
+	corrected.Words = nil
+	slog.Info("transcript segment corrected", "session_id", s.ID, "segment_index", seg.SegmentIndex, "revision", seg.Revision, "user_id", userID)
+
+	if corrected.DiscordMessageID != "" {
+		if err := m.discord.EditChannelMessage(s.ChannelID, corrected.DiscordMessageID, segmentChannelMessage(content, translations)); err != nil {
+			slog.Warn("failed to edit corrected transcript message", "error", err, "session_id", s.ID, "message_id", corrected.DiscordMessageID)
+		}
+	}
+	// 実行中のセッションは終了時に修正後の本文で成果物が作られるため、終了済みのときだけ作り直す
+	if s.Status != repository.SessionStatusRunning {
+		m.refreshCorrectedArtifactBestEffort(ctx, s)
+	}
+	return &corrected, nil
+}
+
+// 保存期間を過ぎて成果物を削除したセッションは、修正や再文字起こしで作り直すと削除されないまま残るため変更を受け付けない
+func sessionPurged(s *repository.Session) bool {
+	return s.ArtifactsPurgedAt != nil || s.TranscriptsPurgedAt != nil
+}
+
+func (m *Manager) refreshCorrectedArtifactBestEffort(ctx context.Context, s *repository.Session) {
+	segments, err := m.repo.ListSegmentsBySessionID(ctx, s.ID)
+	if err != nil {
+		slog.Error("failed to list segments for corrected transcript", "error", err, "session_id", s.ID)
+		return
+	}
+	src := m.transcriptSourceFromRepository(ctx, s, segments, transcriptRevisionInfo{Number: s.TranscriptRevision})
+	src.IsCorrection = true
+	body := buildTranscriptText(src)
+	payload := buildTranscriptWebhookPayload(src)
+	if err := m.repo.SaveTranscriptArtifact(ctx, repository.SaveTranscriptArtifactInput{
+		SessionID:          s.ID,
+		TranscriptFilename: transcriptFilename(s.ID, s.TranscriptRevision),
+		TranscriptText:     string(body),
+		WebhookPayloadJSON: marshalPayloadBestEffort(payload, s.ID),
+	}); err != nil {
+		slog.Error("failed to save corrected transcript artifact", "error", err, "session_id", s.ID)
+	}
+	m.sendWebhookBestEffort(ctx, s.ID, payload)
+}
+
+func normalizeCorrection(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", ErrEmptyCorrection
+	}
+	if utf8.RuneCountInString(content) > maxCorrectionLength {
+		return "", ErrCorrectionTooLong
+	}
+	return content, nil
+}
+
+func (m *Manager) handleFixTranscriptCommand(event discord.SlashCommandEvent) {
+	_, seg, err := m.loadCorrectableMessage(context.Background(), event.GuildID, event.UserID, event.TargetMessageID)
+	if err != nil {
+		slog.Warn("transcript correction rejected", "error", err, "guild_id", event.GuildID, "user_id", event.UserID, "message_id", event.TargetMessageID)
+		m.respondEphemeral(event, correctionErrorMessage(err))
+		return
+	}
+	if event.RespondModal == nil {
+		return
+	}
+	if err := event.RespondModal(discord.Modal{
+		CustomID: fixTranscriptModalPrefix + event.TargetMessageID,
+		Title:    messageFixTranscriptModalTitle,
+		Inputs: []discord.ModalTextInput{
+			{
+				CustomID:  fixTranscriptInputContent,
+				Label:     messageFixTranscriptInputLabel,
+				Value:     seg.Content,
+				Required:  true,
+				MaxLength: maxCorrectionLength,
+			},
+		},
+	}); err != nil {
+		slog.Error("failed to open transcript correction modal", "error", err, "guild_id", event.GuildID, "user_id", event.UserID, "message_id", event.TargetMessageID)
+	}
+}
+
+func (m *Manager) HandleModalSubmit(event discord.ModalSubmitEvent) {
+	slog.Info("modal submit received by manager", "guild_id", event.GuildID, "channel_id", event.ChannelID, "modal", event.CustomID, "user_id", event.UserID)
+	if event.GuildID != m.cfg.DiscordGuildID {
+		m.respondModalEphemeral(event, messageEphemeralWrongGuild)
+		return
+	}
+	messageID, ok := strings.CutPrefix(event.CustomID, fixTranscriptModalPrefix)
+	if !ok {
+		slog.Warn("unknown modal submitted", "modal", event.CustomID, "guild_id", event.GuildID, "user_id", event.UserID)
+		m.respondModalEphemeral(event, messageEphemeralUnknownCommand)
+		return
+	}
+	if _, err := m.correctSegmentByMessage(context.Background(), event.GuildID, event.UserID, messageID, event.Values[fixTranscriptInputContent]); err != nil {
+		slog.Warn("failed to correct transcript segment", "error", err, "guild_id", event.GuildID, "user_id", event.UserID, "message_id", messageID)
+		m.respondModalEphemeral(event, correctionErrorMessage(err))
+		return
+	}
+	m.respondModalEphemeral(event, messageEphemeralCorrectionSaved)
+}
+
+func (m *Manager) respondModalEphemeral(event discord.ModalSubmitEvent, content string) {
+	if event.RespondEphemeral == nil {
+		return
+	}
+	if err := event.RespondEphemeral(content); err != nil {
+		slog.Error("failed to respond ephemeral message", "error", err, "guild_id", event.GuildID, "channel_id", event.ChannelID, "modal", event.CustomID, "user_id", event.UserID)
+	}
+}
+
+func correctionErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrSegmentNotFound):
+		return messageEphemeralNotTranscriptMessage
+	case errors.Is(err, ErrSegmentSuperseded):
+		return messageEphemeralSegmentSuperseded
+	case errors.Is(err, ErrSessionPurged):
+		return messageEphemeralSessionPurged
+	case errors.Is(err, ErrNotSessionParticipant):
+		return messageEphemeralNotSessionParticipant
+	case errors.Is(err, ErrEmptyCorrection):
+		return messageEphemeralCorrectionEmpty
+	case errors.Is(err, ErrCorrectionTooLong):
+		return messageEphemeralCorrectionTooLong
+	default:
+		return messageEphemeralCorrectionFailed
+	}
+}
@@ -0,0 +1,169 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+	"github.com/foxseedlab/mojiokoshin/internal/transcriber"
+)
+
+func newCorrectionFixture(status repository.SessionStatus) *mockRepository {
+	startedAt := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	endedAt := startedAt.Add(10 * time.Minute)
+	return &mockRepository{
+		sessionsByID: map[string]*repository.Session{
+			"session-1": {ID: "session-1", GuildID: "guild-1", ChannelID: "vc-1", StartedAt: startedAt, EndedAt: &endedAt, Status: status},
+		},
+		participantsBySession: map[string][]repository.SessionParticipant{
+			"session-1": {{SessionID: "session-1", UserID: "user-1", DisplayName: "Alice"}},
+		},
+		segments: []repository.TranscriptSegment{
+			{ID: "seg-0", SessionID: "session-1", Content: "こんにちわ", SegmentIndex: 0, DiscordMessageID: "message-10", SpokenAt: startedAt.Add(time.Minute),
+				Words: []repository.SegmentWord{{Text: "こんにちわ", StartAt: startedAt.Add(time.Minute), EndAt: startedAt.Add(time.Minute + time.Second), Confidence: 0.6}}},
+		},
+	}
+}
+
+func TestHandleTranscriptionResult_LinksPostedMessageToSegment(t *testing.T) {
+	repo := &mockRepository{}
+	dc := &mockDiscordClient{}
+	manager := newTestManager(repo, dc)
+
+	manager.handleTranscriptionResult("guild-1", "session-1", "vc-1", 3, transcriber.Result{Text: "hello", IsFinal: true}, segmentTiming{spokenAt: time.Now(), endedAt: time.Now()})
+
+	if repo.linkedMessageIDs[3] != "message-1" {
+		t.Fatalf("expected segment 3 to be linked to posted message, got %+v", repo.linkedMessageIDs)
+	}
+}
+
+func TestHandleSlashCommand_FixTranscriptOpensModalWithCurrentText(t *testing.T) {
+	repo := newCorrectionFixture(repository.SessionStatusCompleted)
+	manager := newTestManager(repo, &mockDiscordClient{})
+
+	var opened discord.Modal
+	manager.HandleSlashCommand(discord.SlashCommandEvent{
+		GuildID:         "guild-1",
+		CommandName:     commandFixTranscript,
+		UserID:          "user-1",
+		TargetMessageID: "message-10",
+		RespondModal: func(modal discord.Modal) error {
+			opened = modal
+			return nil
+		},
+	})
+
+	if opened.CustomID != fixTranscriptModalPrefix+"message-10" {
+		t.Fatalf("unexpected modal custom id: %q", opened.CustomID)
+	}
+	if len(opened.Inputs) != 1 || opened.Inputs[0].Value != "こんにちわ" {
+		t.Fatalf("expected modal prefilled with current text, got %+v", opened.Inputs)
+	}
+}
+
+func TestHandleSlashCommand_FixTranscriptRejectsNonParticipant(t *testing.T) {
+	repo := newCorrectionFixture(repository.SessionStatusCompleted)
+	manager := newTestManager(repo, &mockDiscordClient{})
+
+	var response string
+	manager.HandleSlashCommand(discord.SlashCommandEvent{
+		GuildID:          "guild-1",
+		CommandName:      commandFixTranscript,
+		UserID:           "user-2",
+		TargetMessageID:  "message-10",
+		RespondEphemeral: func(content string) error { response = content; return nil },
+		RespondModal: func(discord.Modal) error {
+			t.Fatal("modal must not open for non-participants")
+			return nil
+		},
+	})
+
+	if response != messageEphemeralNotSessionParticipant {
+		t.Fatalf("unexpected response: %q", response)
+	}
+}
+
+func TestHandleModalSubmit_CorrectsSegmentAndRefreshesCompletedArtifact(t *testing.T) {
+	repo := newCorrectionFixture(repository.SessionStatusCompleted)
+	dc := &mockDiscordClient{}
+	manager := newTestManager(repo, dc)
+	wh := manager.webhook.(*mockWebhookSender)
+
+	var response string
+	manager.HandleModalSubmit(discord.ModalSubmitEvent{
+		GuildID:          "guild-1",
+		CustomID:         fixTranscriptModalPrefix + "message-10",
+		UserID:           "user-1",
+		Values:           map[string]string{fixTranscriptInputContent: " こんにちは "},
+		RespondEphemeral: func(content string) error { response = content; return nil },
+	})
+
+	if response != messageEphemeralCorrectionSaved {
+		t.Fatalf("unexpected response: %q", response)
+	}
+	if len(repo.correctCalls) != 1 || repo.correctCalls[0].Content != "こんにちは" || repo.correctCalls[0].EditedByUserID != "user-1" {
+		t.Fatalf("unexpected correction calls: %+v", repo.correctCalls)
+	}
+	if len(dc.editCalls) != 1 || dc.editCalls[0] != "message-10:こんにちは" {
+		t.Fatalf("expected posted message to be edited, got %+v", dc.editCalls)
+	}
+	if len(repo.savedArtifactCalls) != 1 || !strings.Contains(repo.savedArtifactCalls[0].TranscriptText, "こんにちは") {
+		t.Fatalf("expected refreshed artifact with corrected text, got %+v", repo.savedArtifactCalls)
+	}
+	if len(wh.payloads) != 1 || !wh.payloads[0].IsCorrection || !wh.payloads[0].TranscriptSegments[0].Corrected {
+		t.Fatalf("expected correction webhook, got %+v", wh.payloads)
+	}
+	if words := wh.payloads[0].TranscriptSegments[0].Words; len(words) != 0 {
+		t.Fatalf("expected original words to be dropped after correction, got %+v", words)
+	}
+}
+
+func TestCorrectSegment_RunningSessionDefersArtifactToFinalize(t *testing.T) {
+	repo := newCorrectionFixture(repository.SessionStatusRunning)
+	manager := newTestManager(repo, &mockDiscordClient{})
+
+	if _, err := manager.CorrectSegment(context.Background(), CorrectSegmentInput{
+		SessionID:    "session-1",
+		SegmentIndex: 0,
+		UserID:       "user-1",
+		Content:      "こんにちは",
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.savedArtifactCalls) != 0 {
+		t.Fatalf("expected no artifact refresh for running session, got %d", len(repo.savedArtifactCalls))
+	}
+}
+
+func TestCorrectSegment_RejectsSupersededMessage(t *testing.T) {
+	repo := newCorrectionFixture(repository.SessionStatusCompleted)
+	repo.sessionsByID["session-1"].TranscriptRevision = 1
+	manager := newTestManager(repo, &mockDiscordClient{})
+
+	_, err := manager.correctSegmentByMessage(context.Background(), "guild-1", "user-1", "message-10", "こんにちは")
+	if !errors.Is(err, ErrSegmentSuperseded) {
+		t.Fatalf("expected ErrSegmentSuperseded, got %v", err)
+	}
+	if len(repo.correctCalls) != 0 {
+		t.Fatalf("expected no correction, got %+v", repo.correctCalls)
+	}
+}
+
+func TestCorrectSegment_RejectsPurgedSession(t *testing.T) {
+	repo := newCorrectionFixture(repository.SessionStatusCompleted)
+	purgedAt := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	repo.sessionsByID["session-1"].ArtifactsPurgedAt = &purgedAt
+	manager := newTestManager(repo, &mockDiscordClient{})
+
+	_, err := manager.correctSegmentByMessage(context.Background(), "guild-1", "user-1", "message-10", "こんにちは")
+	if !errors.Is(err, ErrSessionPurged) {
+		t.Fatalf("expected ErrSessionPurged, got %v", err)
+	}
+	if len(repo.correctCalls) != 0 || len(repo.savedArtifactCalls) != 0 {
+		t.Fatalf("expected purged session to stay untouched, corrections=%+v artifacts=%d", repo.correctCalls, len(repo.savedArtifactCalls))
+	}
+}
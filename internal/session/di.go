@@ -4,8 +4,11 @@ import (
 	"github.com/foxseedlab/mojiokoshin/internal/audio"
 	"github.com/foxseedlab/mojiokoshin/internal/config"
 	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/metrics"
 	"github.com/foxseedlab/mojiokoshin/internal/repository"
+	"github.com/foxseedlab/mojiokoshin/internal/summarizer"
 	"github.com/foxseedlab/mojiokoshin/internal/transcriber"
+	"github.com/foxseedlab/mojiokoshin/internal/translator"
 	"github.com/foxseedlab/mojiokoshin/internal/webhook"
 	"github.com/samber/do/v2"
 )
@@ -18,6 +21,10 @@ func RegisterDI(injector do.Injector) {
 		stt := do.MustInvoke[transcriber.Transcriber](i)
 		wh := do.MustInvoke[webhook.Sender](i)
 		newMixer := do.MustInvoke[audio.MixerFactory](i)
-		return NewManager(cfg, repo, dc, stt, wh, newMixer), nil
+		archive := do.MustInvoke[audio.Archive](i)
+		tr := do.MustInvoke[translator.Translator](i)
+		sum := do.MustInvoke[summarizer.Summarizer](i)
+		recorder := do.MustInvoke[metrics.Recorder](i)
+		return NewManager(cfg, repo, dc, stt, wh, newMixer, archive, tr, sum, recorder), nil
 	})
 }
@@ -0,0 +1,154 @@
+package session
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+)
+
+const (
+	startEmbedColor      = 0x57F287
+	stopEmbedColor       = 0xED4245
+	transcriptEmbedColor = 0x5865F2
+	// 「ほか◯件」を付け足してもフィールドの上限に収まるように余白を残す
+	maxEmbedFieldListLength = maxEmbedFieldValueRunes - 24
+)
+
+func (m *Manager) sendDiscordStartMessage(rs *runningSession, channelID string) {
+	messageID, err := m.discord.SendChannelEmbedMessage(m.startChannelEmbed(rs, channelID, false))
+	if err != nil {
+		slog.Error("failed to send start message", "error", err, "session_id", rs.repoSession.ID, "channel_id", channelID)
+		return
+	}
+	m.mu.Lock()
+	rs.startMessageID = messageID
+	m.mu.Unlock()
+}
+
+// 終了後に押されても意味がないため、開始メッセージの中止ボタンを無効にする
+func (m *Manager) disableStartMessageStopButton(rs *runningSession, channelID string) {
+	m.mu.Lock()
+	messageID := rs.startMessageID
+	m.mu.Unlock()
+	if messageID == "" {
+		return
+	}
+	if err := m.discord.EditChannelEmbedMessage(messageID, m.startChannelEmbed(rs, channelID, true)); err != nil {
+		slog.Warn("failed to disable stop button on start message", "error", err, "session_id", rs.repoSession.ID, "message_id", messageID)
+	}
+}
+
+func (m *Manager) startChannelEmbed(rs *runningSession, channelID string, stopped bool) discord.EmbedMessage {
+	fields := []discord.EmbedField{
+		{Name: messageEmbedFieldChannel, Value: channelMention(channelID), Inline: true},
+		{Name: messageEmbedFieldStartedBy, Value: userMention(rs.startedByUserID), Inline: true},
+	}
+	return discord.EmbedMessage{
+		ChannelID: channelID,
+		Embeds: []discord.Embed{{
+			Title:       messageStartChannelTitle,
+			Description: strings.Join(m.withPoweredByForBrand([]string{messageStartChannelHint}), "\n"),
+			Color:       startEmbedColor,
+			Fields:      append(fields, m.consentEmbedFields(rs)...),
+		}},
+		Buttons: []discord.Button{{
+			CustomID: sessionButtonCustomID(buttonActionStop, rs.repoSession.ID),
+			Label:    messageButtonStop,
+			Style:    discord.ButtonStyleDanger,
+			Disabled: stopped,
+		}},
+	}
+}
+
+func (m *Manager) stopChannelEmbed(rs *runningSession, channelID, reason string, endedAt time.Time) discord.EmbedMessage {
+	restart := messageStopRestart
+	if stopReasonNeedsRestartAgain(reason) {
+		restart = messageStopRestartAgain
+	}
+	s := rs.repoSession
+	return discord.EmbedMessage{
+		ChannelID: channelID,
+		Embeds: []discord.Embed{{
+			Title:       messageStopChannelTitle,
+			Description: "-# " + restart,
+			Color:       stopEmbedColor,
+			Fields: []discord.EmbedField{
+				{Name: messageEmbedFieldChannel, Value: channelMention(channelID), Inline: true},
+				{Name: messageEmbedFieldStartedBy, Value: userMention(rs.startedByUserID), Inline: true},
+				{Name: messageEmbedFieldDuration, Value: formatElapsedHMS(endedAt.Sub(s.StartedAt)), Inline: true},
+				{Name: messageEmbedFieldParticipants, Value: participantMentions(rs.allParticipants)},
+				{Name: messageEmbedFieldStopReason, Value: stopReasonDetail(reason)},
+			},
+		}},
+		Buttons: []discord.Button{{
+			CustomID: sessionButtonCustomID(buttonActionDownloadTranscript, s.ID),
+			Label:    messageButtonDownloadTranscript,
+			Style:    discord.ButtonStyleSecondary,
+		}},
+	}
+}
+
+func (m *Manager) transcriptAttachmentEmbed(src transcriptSource) discord.Embed {
+	participants := canonicalParticipants(src.Meta.Participants)
+	names := make([]string, 0, len(participants))
+	for _, p := range participants {
+		if !p.IsBot {
+			names = append(names, p.DisplayName)
+		}
+	}
+	embed := discord.Embed{
+		Title: messageAttachmentTitle,
+		Color: transcriptEmbedColor,
+		Fields: []discord.EmbedField{
+			{Name: messageEmbedFieldChannel, Value: channelMention(src.Meta.DiscordVoiceChannelID), Inline: true},
+			{Name: messageEmbedFieldDuration, Value: formatElapsedHMS(src.EndedAt.Sub(src.StartedAt)), Inline: true},
+			{Name: messageEmbedFieldSegmentCount, Value: strconv.Itoa(len(src.Segments)), Inline: true},
+			{Name: messageEmbedFieldParticipants, Value: embedFieldList(names, "、")},
+		},
+	}
+	if m.cfg.DiscordShowPoweredBy {
+		embed.Description = messagePoweredByLine
+	}
+	return embed
+}
+
+func participantMentions(states map[string]participantState) string {
+	userIDs := make([]string, 0, len(states))
+	for userID, state := range states {
+		if !state.isBot {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	sort.Strings(userIDs)
+	mentions := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		mentions = append(mentions, userMention(userID))
+	}
+	return embedFieldList(mentions, " ")
+}
+
+func embedFieldList(items []string, sep string) string {
+	if len(items) == 0 {
+		return messageEmbedFieldNone
+	}
+	return joinListWithin(items, sep, maxEmbedFieldListLength)
+}
+
+func channelMention(channelID string) string {
+	if channelID == "" {
+		return messageEmbedFieldNone
+	}
+	return fmt.Sprintf("<#%s>", channelID)
+}
+
+func userMention(userID string) string {
+	if userID == "" {
+		return messageEmbedFieldNone
+	}
+	return fmt.Sprintf("<@%s>", userID)
+}
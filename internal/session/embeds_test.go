@@ -0,0 +1,61 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+)
+
+func TestSessionMessages_UseEmbedsWithButtons(t *testing.T) {
+	repo := &mockRepository{}
+	dc := &mockDiscordClient{userVoiceChannelByID: map[string]string{"user-1": "vc-1"}}
+	manager := newTestManager(repo, dc)
+	manager.SetBotUserID("bot-self")
+
+	manager.HandleSlashCommand(discord.SlashCommandEvent{GuildID: "guild-1", CommandName: commandMojiokoshi, UserID: "user-1"})
+	if !manager.isSessionRunning("guild-1", "vc-1") {
+		t.Fatal("expected session to start")
+	}
+	if len(dc.embedCalls) != 1 {
+		t.Fatalf("expected start embed, got %+v", dc.embedCalls)
+	}
+	start := dc.embedCalls[0]
+	if start.Embeds[0].Title != messageStartChannelTitle || start.Embeds[0].Fields[1].Value != "<@user-1>" {
+		t.Fatalf("unexpected start embed: %+v", start.Embeds[0])
+	}
+	if len(start.Buttons) != 1 || start.Buttons[0].CustomID != sessionButtonCustomID(buttonActionStop, "session-1") || start.Buttons[0].Disabled {
+		t.Fatalf("unexpected start buttons: %+v", start.Buttons)
+	}
+
+	manager.HandleSlashCommand(discord.SlashCommandEvent{GuildID: "guild-1", CommandName: commandMojiokoshiStop, UserID: "user-1"})
+	waitUntil(t, time.Second, func() bool { return len(dc.fileCalls) == 1 }, "expected transcript attachment")
+
+	if len(dc.embedEditCalls) != 1 || !dc.embedEditCalls[0].Buttons[0].Disabled {
+		t.Fatalf("expected stop button to be disabled, got %+v", dc.embedEditCalls)
+	}
+	if len(dc.embedCalls) != 2 || !isStopEmbed(dc.embedCalls[1], stopReasonManualSlash) {
+		t.Fatalf("unexpected stop embed: %+v", dc.embedCalls)
+	}
+	if dc.embedCalls[1].Buttons[0].CustomID != sessionButtonCustomID(buttonActionDownloadTranscript, "session-1") {
+		t.Fatalf("unexpected stop buttons: %+v", dc.embedCalls[1].Buttons)
+	}
+	attachment := dc.fileCalls[0]
+	if len(attachment.Embeds) != 1 || attachment.Embeds[0].Title != messageAttachmentTitle {
+		t.Fatalf("unexpected attachment embed: %+v", attachment.Embeds)
+	}
+}
+
+func TestParticipantMentions_ExcludesBots(t *testing.T) {
+	got := participantMentions(map[string]participantState{
+		"user-2":   {},
+		"user-1":   {},
+		"bot-self": {isBot: true},
+	})
+	if got != "<@user-1> <@user-2>" {
+		t.Fatalf("unexpected mentions: %q", got)
+	}
+	if got := participantMentions(nil); got != messageEmbedFieldNone {
+		t.Fatalf("unexpected empty mentions: %q", got)
+	}
+}
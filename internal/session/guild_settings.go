@@ -0,0 +1,260 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+	"github.com/foxseedlab/mojiokoshin/internal/textfilter"
+	"github.com/foxseedlab/mojiokoshin/internal/transcriber"
+)
+
+const (
+	featureAutomaticPunctuation = "automatic_punctuation"
+	featureSpokenPunctuation    = "spoken_punctuation"
+	featureProfanityFilter      = "profanity_filter"
+	featureNumberNormalization  = "number_normalization"
+	featureTextNormalization    = "text_normalization"
+
+	settingChoiceOn  = "on"
+	settingChoiceOff = "off"
+)
+
+var ErrUnknownGuildFeature = errors.New("unknown guild feature")
+
+var guildFeatures = []string{
+	featureAutomaticPunctuation,
+	featureSpokenPunctuation,
+	featureProfanityFilter,
+	featureNumberNormalization,
+	featureTextNormalization,
+}
+
+func guildFeatureChoices() []discord.SlashCommandOptionChoice {
+	choices := make([]discord.SlashCommandOptionChoice, 0, len(guildFeatures))
+	for _, feature := range guildFeatures {
+		choices = append(choices, discord.SlashCommandOptionChoice{Name: guildFeatureLabel(feature), Value: feature})
+	}
+	return choices
+}
+
+func defaultGuildSettings(guildID string) repository.GuildSettings {
+	return repository.GuildSettings{GuildID: guildID, AutomaticPunctuation: true, ConsentMode: repository.ConsentModeAnnounce, StopPolicy: repository.StopPolicyParticipants}
+}
+
+func (m *Manager) GuildSettings(ctx context.Context, guildID string) (repository.GuildSettings, error) {
+	settings, err := m.repo.GetGuildSettings(ctx, guildID)
+	if err != nil {
+		return defaultGuildSettings(guildID), err
+	}
+	if settings == nil {
+		return defaultGuildSettings(guildID), nil
+	}
+	settings.ConsentMode = consentModeOrDefault(settings.ConsentMode)
+	settings.StopPolicy = stopPolicyOrDefault(settings.StopPolicy)
+	return *settings, nil
+}
+
+func (m *Manager) SetGuildFeature(ctx context.Context, guildID, feature string, enabled bool, userID string) (repository.GuildSettings, error) {
+	settings, err := m.GuildSettings(ctx, guildID)
+	if err != nil {
+		return settings, err
+	}
+	target := guildFeatureField(&settings, feature)
+	if target == nil {
+		return settings, ErrUnknownGuildFeature
+	}
+	*target = enabled
+	settings.UpdatedByUserID = userID
+	if err := m.repo.SaveGuildSettings(ctx, settings); err != nil {
+		return settings, err
+	}
+	m.invalidateTextProcessor(guildID)
+	return settings, nil
+}
+
+// languagesResetKeyword を指定すると既定の言語に戻す
+func (m *Manager) SetGuildLanguages(ctx context.Context, guildID, value, userID string) (repository.GuildSettings, error) {
+	var languages []string
+	if strings.TrimSpace(value) != languagesResetKeyword {
+		parsed, err := parseLanguageCandidates(value)
+		if err != nil || len(parsed) == 0 {
+			return repository.GuildSettings{}, ErrInvalidLanguages
+		}
+		languages = parsed
+	}
+	settings, err := m.GuildSettings(ctx, guildID)
+	if err != nil {
+		return settings, err
+	}
+	settings.Languages = languages
+	settings.UpdatedByUserID = userID
+	if err := m.repo.SaveGuildSettings(ctx, settings); err != nil {
+		return settings, err
+	}
+	return settings, nil
+}
+
+func guildFeatureField(settings *repository.GuildSettings, feature string) *bool {
+	switch feature {
+	case featureAutomaticPunctuation:
+		return &settings.AutomaticPunctuation
+	case featureSpokenPunctuation:
+		return &settings.SpokenPunctuation
+	case featureProfanityFilter:
+		return &settings.ProfanityFilter
+	case featureNumberNormalization:
+		return &settings.NumberNormalization
+	case featureTextNormalization:
+		return &settings.TextNormalization
+	default:
+		return nil
+	}
+}
+
+// 設定の読み込みに失敗しても文字起こしは止めず、既定値で続ける
+func (m *Manager) guildSettingsBestEffort(ctx context.Context, guildID string) (repository.GuildSettings, bool) {
+	settings, err := m.GuildSettings(ctx, guildID)
+	if err != nil {
+		slog.Warn("failed to load guild settings; using defaults", "error", err, "guild_id", guildID)
+		return settings, false
+	}
+	return settings, true
+}
+
+func recognitionFeatures(settings repository.GuildSettings) transcriber.RecognitionFeatures {
+	return transcriber.RecognitionFeatures{
+		AutomaticPunctuation: settings.AutomaticPunctuation,
+		SpokenPunctuation:    settings.SpokenPunctuation,
+		ProfanityFilter:      settings.ProfanityFilter,
+	}
+}
+
+func normalizeOptions(settings repository.GuildSettings) textfilter.NormalizeOptions {
+	return textfilter.NormalizeOptions{
+		Width:   settings.TextNormalization,
+		Spacing: settings.TextNormalization,
+		Numbers: settings.NumberNormalization,
+	}
+}
+
+func (m *Manager) handleSettingsCommand(event discord.SlashCommandEvent) {
+	ctx := context.Background()
+	update := m.settingsUpdate(event)
+	if update == nil {
+		settings, err := m.GuildSettings(ctx, event.GuildID)
+		if err != nil {
+			slog.Error("failed to load guild settings", "error", err, "guild_id", event.GuildID)
+			m.respondEphemeral(event, messageEphemeralSettingsFailed)
+			return
+		}
+		m.respondEphemeral(event, m.guildSettingsMessage(settings))
+		return
+	}
+	settings, err := update(ctx)
+	if err != nil {
+		slog.Warn("failed to update guild settings", "error", err, "guild_id", event.GuildID, "user_id", event.UserID,
+			"feature", event.Option(optionFeature), "languages", event.Option(optionLanguages), "consent_mode", event.Option(optionConsentMode))
+		m.respondEphemeral(event, settingsErrorMessage(err))
+		return
+	}
+	m.auditCommand(event, repository.AuditActionConfigUpdate, "")
+	m.respondEphemeral(event, strings.Join([]string{messageSettingsUpdated, m.guildSettingsMessage(settings)}, "\n"))
+}
+
+// 変更する項目が指定されていない場合は nil を返し、現在の設定を表示する
+func (m *Manager) settingsUpdate(event discord.SlashCommandEvent) func(ctx context.Context) (repository.GuildSettings, error) {
+	feature := event.Option(optionFeature)
+	value := event.Option(optionValue)
+	languages := event.Option(optionLanguages)
+	consentMode := event.Option(optionConsentMode)
+	transcriptRetention := event.Option(optionTranscriptRetention)
+	artifactRetention := event.Option(optionArtifactRetention)
+	if update := m.permissionSettingsUpdate(event); update != nil {
+		return update
+	}
+	switch {
+	case languages != "":
+		return func(ctx context.Context) (repository.GuildSettings, error) {
+			return m.SetGuildLanguages(ctx, event.GuildID, languages, event.UserID)
+		}
+	case consentMode != "":
+		return func(ctx context.Context) (repository.GuildSettings, error) {
+			return m.SetGuildConsentMode(ctx, event.GuildID, consentMode, event.UserID)
+		}
+	case transcriptRetention != "":
+		return func(ctx context.Context) (repository.GuildSettings, error) {
+			return m.SetGuildRetention(ctx, event.GuildID, retentionKindTranscripts, transcriptRetention, event.UserID)
+		}
+	case artifactRetention != "":
+		return func(ctx context.Context) (repository.GuildSettings, error) {
+			return m.SetGuildRetention(ctx, event.GuildID, retentionKindArtifacts, artifactRetention, event.UserID)
+		}
+	case feature != "" && value != "":
+		return func(ctx context.Context) (repository.GuildSettings, error) {
+			return m.SetGuildFeature(ctx, event.GuildID, feature, value == settingChoiceOn, event.UserID)
+		}
+	default:
+		return nil
+	}
+}
+
+func (m *Manager) permissionSettingsUpdate(event discord.SlashCommandEvent) func(ctx context.Context) (repository.GuildSettings, error) {
+	startRoles := event.Option(optionStartRoles)
+	moderatorRoles := event.Option(optionModeratorRoles)
+	stopPolicy := event.Option(optionStopPolicy)
+	switch {
+	case startRoles != "":
+		return func(ctx context.Context) (repository.GuildSettings, error) {
+			return m.SetGuildStartRoles(ctx, event.GuildID, startRoles, event.UserID)
+		}
+	case moderatorRoles != "":
+		return func(ctx context.Context) (repository.GuildSettings, error) {
+			return m.SetGuildModeratorRoles(ctx, event.GuildID, moderatorRoles, event.UserID)
+		}
+	case stopPolicy != "":
+		return func(ctx context.Context) (repository.GuildSettings, error) {
+			return m.SetGuildStopPolicy(ctx, event.GuildID, stopPolicy, event.UserID)
+		}
+	default:
+		return nil
+	}
+}
+
+func settingsErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidRoles):
+		return messageEphemeralRolesInvalid
+	case errors.Is(err, ErrInvalidStopPolicy):
+		return messageEphemeralStopPolicyInvalid
+	case errors.Is(err, ErrInvalidLanguages):
+		return messageEphemeralLanguagesInvalid
+	case errors.Is(err, ErrInvalidConsentMode):
+		return messageEphemeralConsentModeInvalid
+	case errors.Is(err, ErrInvalidRetentionDays):
+		return messageEphemeralRetentionInvalid
+	default:
+		return messageEphemeralSettingsFailed
+	}
+}
+
+func (m *Manager) guildSettingsMessage(settings repository.GuildSettings) string {
+	lines := []string{messageSettingsTitle}
+	for _, feature := range guildFeatures {
+		lines = append(lines, guildSettingLine(feature, *guildFeatureField(&settings, feature)))
+	}
+	lines = append(lines,
+		guildLanguagesLine(settings.Languages, m.cfg.DefaultTranscribeLanguage),
+		guildConsentModeLine(settings.ConsentMode),
+		guildRetentionLine(messageSettingsTranscriptRetentionLabel, settings.TranscriptRetentionDays),
+		guildRetentionLine(messageSettingsArtifactRetentionLabel, settings.ArtifactRetentionDays),
+		guildRolesLine(messageSettingsStartRolesLabel, settings.StartRoleIDs, messageSettingsRolesEveryone),
+		guildRolesLine(messageSettingsModeratorRolesLabel, settings.ModeratorRoleIDs, messageSettingsRolesAdminsOnly),
+		guildStopPolicyLine(settings.StopPolicy),
+		messageSettingsHint,
+	)
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,88 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/transcriber"
+)
+
+func settingsCommand(options map[string]string, got *string) discord.SlashCommandEvent {
+	return discord.SlashCommandEvent{
+		GuildID:        "guild-1",
+		CommandName:    commandMojiokoshiSettings,
+		UserID:         "user-1",
+		CanManageGuild: true,
+		Options:        options,
+		RespondEphemeral: func(content string) error {
+			*got = content
+			return nil
+		},
+	}
+}
+
+func TestHandleSlashCommand_SettingsShowsDefaults(t *testing.T) {
+	manager := newTestManager(&mockRepository{}, &mockDiscordClient{})
+	var got string
+
+	manager.HandleSlashCommand(settingsCommand(nil, &got))
+
+	if got != manager.guildSettingsMessage(defaultGuildSettings("guild-1")) {
+		t.Fatalf("unexpected response: %q", got)
+	}
+}
+
+func TestHandleSlashCommand_SettingsAppliesToStreamAndText(t *testing.T) {
+	repo := &mockRepository{}
+	manager := newTestManager(repo, &mockDiscordClient{})
+	var got string
+	now := time.Now()
+
+	manager.handleTranscriptionResult("guild-1", "session-1", "vc-1", 0, transcriber.Result{Text: "会議 は １５ 時", IsFinal: true}, segmentTiming{spokenAt: now, endedAt: now})
+	manager.HandleSlashCommand(settingsCommand(map[string]string{optionFeature: featureTextNormalization, optionValue: settingChoiceOn}, &got))
+	manager.HandleSlashCommand(settingsCommand(map[string]string{optionFeature: featureAutomaticPunctuation, optionValue: settingChoiceOff}, &got))
+	manager.HandleSlashCommand(settingsCommand(map[string]string{optionFeature: featureProfanityFilter, optionValue: settingChoiceOn}, &got))
+	manager.handleTranscriptionResult("guild-1", "session-1", "vc-1", 1, transcriber.Result{Text: "会議 は １５ 時", IsFinal: true}, segmentTiming{spokenAt: now, endedAt: now})
+
+	if repo.insertCalls[0].Content != "会議 は １５ 時" {
+		t.Fatalf("expected text untouched before enabling normalization, got %q", repo.insertCalls[0].Content)
+	}
+	if repo.insertCalls[1].Content != "会議は15時" || repo.insertCalls[1].RawContent != "会議 は １５ 時" {
+		t.Fatalf("unexpected normalized segment: %+v", repo.insertCalls[1])
+	}
+	opts := manager.liveStreamOptions(context.Background(), "guild-1", "vc-1", nil, nil)
+	if opts.Features != (transcriber.RecognitionFeatures{ProfanityFilter: true}) {
+		t.Fatalf("unexpected stream features: %+v", opts.Features)
+	}
+	if repo.guildSettings["guild-1"].UpdatedByUserID != "user-1" {
+		t.Fatalf("unexpected saved settings: %+v", repo.guildSettings)
+	}
+}
+
+func TestHandleSlashCommand_SettingsLanguages(t *testing.T) {
+	repo := &mockRepository{}
+	manager := newTestManager(repo, &mockDiscordClient{})
+	var got string
+
+	manager.HandleSlashCommand(settingsCommand(map[string]string{optionLanguages: "ja-JP, en-US"}, &got))
+	opts := manager.liveStreamOptions(context.Background(), "guild-1", "vc-1", nil, nil)
+	if opts.Language != "ja-JP" || len(opts.AlternativeLanguages) != 1 || opts.AlternativeLanguages[0] != "en-US" {
+		t.Fatalf("unexpected stream languages: %q %v", opts.Language, opts.AlternativeLanguages)
+	}
+	opts = manager.liveStreamOptions(context.Background(), "guild-1", "vc-1", nil, []string{"en-US"})
+	if opts.Language != "en-US" || len(opts.AlternativeLanguages) != 0 {
+		t.Fatalf("expected session languages to take precedence, got %q %v", opts.Language, opts.AlternativeLanguages)
+	}
+
+	manager.HandleSlashCommand(settingsCommand(map[string]string{optionLanguages: "ja-JP,en-US,ko-KR,zh-CN"}, &got))
+	if got != messageEphemeralLanguagesInvalid {
+		t.Fatalf("unexpected response for too many languages: %q", got)
+	}
+
+	manager.HandleSlashCommand(settingsCommand(map[string]string{optionLanguages: languagesResetKeyword}, &got))
+	if len(repo.guildSettings["guild-1"].Languages) != 0 {
+		t.Fatalf("expected languages to be reset, got %v", repo.guildSettings["guild-1"].Languages)
+	}
+}
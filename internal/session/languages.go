@@ -0,0 +1,58 @@
+package session
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	// Cloud Speech の多言語認識で指定できる言語数の上限に合わせる
+	maxTranscribeLanguages = 3
+	languagesResetKeyword  = "default"
+)
+
+var ErrInvalidLanguages = errors.New("language codes are invalid")
+
+var languageCodePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// カンマや空白区切りの言語コードを、重複を除いて指定順に返す
+func parseLanguageCandidates(value string) ([]string, error) {
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == '、' || unicode.IsSpace(r)
+	})
+	seen := make(map[string]struct{}, len(fields))
+	var languages []string
+	for _, code := range fields {
+		if !languageCodePattern.MatchString(code) {
+			return nil, ErrInvalidLanguages
+		}
+		key := strings.ToLower(code)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		languages = append(languages, code)
+	}
+	if len(languages) > maxTranscribeLanguages {
+		return nil, ErrInvalidLanguages
+	}
+	return languages, nil
+}
+
+// セッションでの指定、サーバーの設定、既定の言語の順に優先する
+func (m *Manager) streamLanguages(sessionLanguages, guildLanguages []string) (string, []string) {
+	languages := sessionLanguages
+	if len(languages) == 0 {
+		languages = guildLanguages
+	}
+	if len(languages) == 0 {
+		return m.cfg.DefaultTranscribeLanguage, nil
+	}
+	return languages[0], languages[1:]
+}
+
+func streamLanguageLabel(language string, alternatives []string) string {
+	return strings.Join(append([]string{language}, alternatives...), ", ")
+}
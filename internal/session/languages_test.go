@@ -0,0 +1,49 @@
+package session
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/foxseedlab/mojiokoshin/internal/transcriber"
+)
+
+func TestParseLanguageCandidates(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{value: "", want: nil},
+		{value: "ja-JP", want: []string{"ja-JP"}},
+		{value: " ja-JP, en-US ja-jp ", want: []string{"ja-JP", "en-US"}},
+		{value: "cmn-Hans-CN、en-US", want: []string{"cmn-Hans-CN", "en-US"}},
+		{value: "ja_JP", wantErr: true},
+		{value: "ja-JP,en-US,ko-KR,zh-CN", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseLanguageCandidates(tt.value)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidLanguages) {
+				t.Fatalf("parseLanguageCandidates(%q) error = %v, want ErrInvalidLanguages", tt.value, err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("parseLanguageCandidates(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestHandleTranscriptionResult_StoresDetectedLanguage(t *testing.T) {
+	repo := &mockRepository{}
+	manager := newTestManager(repo, &mockDiscordClient{})
+	now := time.Now()
+
+	manager.handleTranscriptionResult("guild-1", "session-1", "vc-1", 0, transcriber.Result{Text: "good morning", IsFinal: true, Language: "en-us"}, segmentTiming{spokenAt: now, endedAt: now})
+
+	if len(repo.insertCalls) != 1 || repo.insertCalls[0].Language != "en-us" {
+		t.Fatalf("unexpected inserted segments: %+v", repo.insertCalls)
+	}
+}
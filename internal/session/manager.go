@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"log/slog"
 	"sort"
 	"strings"
@@ -15,9 +14,16 @@ import (
 	"github.com/foxseedlab/mojiokoshin/internal/audio"
 	"github.com/foxseedlab/mojiokoshin/internal/config"
 	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/metrics"
 	"github.com/foxseedlab/mojiokoshin/internal/repository"
+	"github.com/foxseedlab/mojiokoshin/internal/summarizer"
+	"github.com/foxseedlab/mojiokoshin/internal/textfilter"
+	"github.com/foxseedlab/mojiokoshin/internal/tracing"
 	"github.com/foxseedlab/mojiokoshin/internal/transcriber"
+	"github.com/foxseedlab/mojiokoshin/internal/translator"
 	"github.com/foxseedlab/mojiokoshin/internal/webhook"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -25,11 +31,53 @@ const (
 	audioFrameBytes  = 960 * 2 * 2
 	stopAllWaitLimit = 25 * time.Second
 
-	commandMojiokoshi     = "mojiokoshi"
-	commandMojiokoshiStop = "mojiokoshi-stop"
+	commandMojiokoshi             = "mojiokoshi"
+	commandMojiokoshiStop         = "mojiokoshi-stop"
+	commandMojiokoshiRetranscribe = "mojiokoshi-retranscribe"
+	commandMojiokoshiPhrases      = "mojiokoshi-phrases"
+	commandMojiokoshiReplace      = "mojiokoshi-replace"
+	commandMojiokoshiSettings     = "mojiokoshi-settings"
+	commandMojiokoshiSubscribe    = "mojiokoshi-subscribe"
+	commandMojiokoshiOptOut       = "mojiokoshi-optout"
+	commandMojiokoshiOptIn        = "mojiokoshi-optin"
+	commandMojiokoshiDelete       = "mojiokoshi-delete"
+	commandMojiokoshiAudit        = "mojiokoshi-audit"
+	commandFixTranscript          = "Fix transcript"
+
+	optionSessionID   = "session_id"
+	optionLanguage    = "language"
+	optionModel       = "model"
+	optionVAD         = "vad"
+	optionAction      = "action"
+	optionPhrase      = "phrase"
+	optionPattern     = "pattern"
+	optionReplacement = "replacement"
+	optionRegex       = "regex"
+	optionFeature     = "feature"
+	optionLanguages   = "languages"
+	optionValue       = "value"
+	optionConsentMode = "consent_mode"
+	optionUser        = "user"
+
+	optionTranscriptRetention = "transcript_retention_days"
+	optionArtifactRetention   = "artifact_retention_days"
+	optionStartRoles          = "start_roles"
+	optionModeratorRoles      = "moderator_roles"
+	optionStopPolicy          = "stop_policy"
+
+	vadChoiceOn  = "on"
+	vadChoiceOff = "off"
+
+	regexChoiceOn  = "on"
+	regexChoiceOff = "off"
+
+	actionAdd    = "add"
+	actionRemove = "remove"
+	actionList   = "list"
 
 	stopReasonParticipantsLeft = "all participants left voice channel"
 	stopReasonManualSlash      = "stopped by slash command"
+	stopReasonManualButton     = "stopped by button"
 	stopReasonMaxDuration      = "maximum transcribe duration exceeded"
 	stopReasonBotRemoved       = "transcription bot was removed from voice channel"
 	stopReasonServerClosed     = "transcription server closed"
@@ -53,12 +101,19 @@ type Manager struct {
 	transcriber        transcriber.Transcriber
 	webhook            webhook.Sender
 	newMixer           audio.MixerFactory
+	archive            audio.Archive
 	transcriptLocation *time.Location
 
-	mu          sync.Mutex
-	sessions    map[string]*runningSession
-	stopReasons map[string]string
-	botUserID   string
+	mu             sync.Mutex
+	sessions       map[string]*runningSession
+	stopReasons    map[string]string
+	retranscribing map[string]struct{}
+	textProcessors map[string]guildTextProcessor
+	redactor       *textfilter.Redactor
+	translator     translator.Translator
+	summarizer     summarizer.Summarizer
+	metrics        metrics.Recorder
+	botUserID      string
 }
 
 type participantState struct {
@@ -75,17 +130,196 @@ type runningSession struct {
 	cancel             context.CancelFunc
 	activeParticipants map[string]participantState
 	allParticipants    map[string]participantState
+	// 開始メッセージの中止ボタンを終了時に無効にするために保持する
+	startedByUserID string
+	startMessageID  string
+	consent         *consentGate
+	// 投稿後に非同期で付け足している翻訳
+	translations *segmentTranslations
+	// メンバーの操作で止めた場合に設定する
+	stoppedByUserID string
+	// サーバーの終了で止めた場合は終了待ちの時間内に収めるため要約を作らない
+	shuttingDown bool
+}
+
+// stopOwnerUserID は開始した人だけが止められる設定で使う。自動で開始したセッションでは空になる
+func (rs *runningSession) stopOwnerUserID() string {
+	if rs.repoSession == nil || rs.repoSession.StartTrigger != repository.StartTriggerSlash {
+		return ""
+	}
+	return rs.startedByUserID
+}
+
+type sessionOptions struct {
+	trigger repository.StartTrigger
+	vad     audio.VADConfig
+	// languages が空の場合はサーバーの設定に従う
+	languages []string
 }
 
 var slashCommandDefs = []discord.SlashCommandDefinition{
 	{
 		Name:        commandMojiokoshi,
 		Description: slashCommandStartDescription,
+		Options: []discord.SlashCommandOption{
+			{
+				Name:        optionVAD,
+				Description: slashOptionVADDescription,
+				Type:        discord.SlashCommandOptionString,
+				Choices: []discord.SlashCommandOptionChoice{
+					{Name: slashChoiceVADOn, Value: vadChoiceOn},
+					{Name: slashChoiceVADOff, Value: vadChoiceOff},
+				},
+			},
+			{Name: optionLanguages, Description: slashOptionLanguagesDescription, Type: discord.SlashCommandOptionString},
+		},
 	},
 	{
 		Name:        commandMojiokoshiStop,
 		Description: slashCommandStopDescription,
 	},
+	{
+		Name:        commandMojiokoshiRetranscribe,
+		Description: slashCommandRetranscribeDescription,
+		Options: []discord.SlashCommandOption{
+			{Name: optionSessionID, Description: slashOptionSessionIDDescription, Type: discord.SlashCommandOptionString, Required: true},
+			{Name: optionLanguage, Description: slashOptionLanguageDescription, Type: discord.SlashCommandOptionString},
+			{Name: optionModel, Description: slashOptionModelDescription, Type: discord.SlashCommandOptionString},
+		},
+	},
+	{
+		Type: discord.SlashCommandTypeMessage,
+		Name: commandFixTranscript,
+	},
+	{
+		Name:        commandMojiokoshiPhrases,
+		Description: slashCommandPhrasesDescription,
+		Options: []discord.SlashCommandOption{
+			{
+				Name:        optionAction,
+				Description: slashOptionActionDescription,
+				Type:        discord.SlashCommandOptionString,
+				Required:    true,
+				Choices: []discord.SlashCommandOptionChoice{
+					{Name: slashChoiceActionAdd, Value: actionAdd},
+					{Name: slashChoiceActionRemove, Value: actionRemove},
+					{Name: slashChoiceActionList, Value: actionList},
+				},
+			},
+			{Name: optionPhrase, Description: slashOptionPhraseDescription, Type: discord.SlashCommandOptionString},
+		},
+	},
+	{
+		Name:        commandMojiokoshiReplace,
+		Description: slashCommandReplaceDescription,
+		Options: []discord.SlashCommandOption{
+			{
+				Name:        optionAction,
+				Description: slashOptionActionDescription,
+				Type:        discord.SlashCommandOptionString,
+				Required:    true,
+				Choices: []discord.SlashCommandOptionChoice{
+					{Name: slashChoiceActionAdd, Value: actionAdd},
+					{Name: slashChoiceActionRemove, Value: actionRemove},
+					{Name: slashChoiceActionList, Value: actionList},
+				},
+			},
+			{Name: optionPattern, Description: slashOptionPatternDescription, Type: discord.SlashCommandOptionString},
+			{Name: optionReplacement, Description: slashOptionReplacementDescription, Type: discord.SlashCommandOptionString},
+			{
+				Name:        optionRegex,
+				Description: slashOptionRegexDescription,
+				Type:        discord.SlashCommandOptionString,
+				Choices: []discord.SlashCommandOptionChoice{
+					{Name: slashChoiceRegexOn, Value: regexChoiceOn},
+					{Name: slashChoiceRegexOff, Value: regexChoiceOff},
+				},
+			},
+		},
+	},
+	{
+		Name:        commandMojiokoshiSettings,
+		Description: slashCommandSettingsDescription,
+		Options: []discord.SlashCommandOption{
+			{
+				Name:        optionFeature,
+				Description: slashOptionFeatureDescription,
+				Type:        discord.SlashCommandOptionString,
+				Choices:     guildFeatureChoices(),
+			},
+			{
+				Name:        optionValue,
+				Description: slashOptionValueDescription,
+				Type:        discord.SlashCommandOptionString,
+				Choices: []discord.SlashCommandOptionChoice{
+					{Name: slashChoiceSettingOn, Value: settingChoiceOn},
+					{Name: slashChoiceSettingOff, Value: settingChoiceOff},
+				},
+			},
+			{Name: optionLanguages, Description: slashOptionGuildLanguagesDescription, Type: discord.SlashCommandOptionString},
+			{
+				Name:        optionConsentMode,
+				Description: slashOptionConsentModeDescription,
+				Type:        discord.SlashCommandOptionString,
+				Choices: []discord.SlashCommandOptionChoice{
+					{Name: slashChoiceConsentAnnounce, Value: string(repository.ConsentModeAnnounce)},
+					{Name: slashChoiceConsentExclude, Value: string(repository.ConsentModeExclude)},
+				},
+			},
+			{Name: optionTranscriptRetention, Description: slashOptionTranscriptRetentionDescription, Type: discord.SlashCommandOptionString},
+			{Name: optionArtifactRetention, Description: slashOptionArtifactRetentionDescription, Type: discord.SlashCommandOptionString},
+			{Name: optionStartRoles, Description: slashOptionStartRolesDescription, Type: discord.SlashCommandOptionString},
+			{Name: optionModeratorRoles, Description: slashOptionModeratorRolesDescription, Type: discord.SlashCommandOptionString},
+			{
+				Name:        optionStopPolicy,
+				Description: slashOptionStopPolicyDescription,
+				Type:        discord.SlashCommandOptionString,
+				Choices: []discord.SlashCommandOptionChoice{
+					{Name: slashChoiceStopParticipants, Value: string(repository.StopPolicyParticipants)},
+					{Name: slashChoiceStopStarter, Value: string(repository.StopPolicyStarter)},
+					{Name: slashChoiceStopModerators, Value: string(repository.StopPolicyModerators)},
+				},
+			},
+		},
+	},
+	{
+		Name:        commandMojiokoshiSubscribe,
+		Description: slashCommandSubscribeDescription,
+		Options: []discord.SlashCommandOption{
+			{
+				Name:        optionValue,
+				Description: slashOptionSubscribeValueDescription,
+				Type:        discord.SlashCommandOptionString,
+				Choices: []discord.SlashCommandOptionChoice{
+					{Name: slashChoiceSettingOn, Value: settingChoiceOn},
+					{Name: slashChoiceSettingOff, Value: settingChoiceOff},
+				},
+			},
+		},
+	},
+	{
+		Name:        commandMojiokoshiOptOut,
+		Description: slashCommandOptOutDescription,
+	},
+	{
+		Name:        commandMojiokoshiOptIn,
+		Description: slashCommandOptInDescription,
+	},
+	{
+		Name:        commandMojiokoshiDelete,
+		Description: slashCommandDeleteDescription,
+		Options: []discord.SlashCommandOption{
+			{Name: optionSessionID, Description: slashOptionSessionIDDescription, Type: discord.SlashCommandOptionString, Required: true},
+		},
+	},
+	{
+		Name:        commandMojiokoshiAudit,
+		Description: slashCommandAuditDescription,
+		Options: []discord.SlashCommandOption{
+			{Name: optionSessionID, Description: slashOptionAuditSessionIDDescription, Type: discord.SlashCommandOptionString},
+			{Name: optionUser, Description: slashOptionAuditUserDescription, Type: discord.SlashCommandOptionString},
+		},
+	},
 }
 
 func SlashCommandDefinitions() []discord.SlashCommandDefinition {
@@ -94,7 +328,15 @@ func SlashCommandDefinitions() []discord.SlashCommandDefinition {
 	return defs
 }
 
-func NewManager(cfg *config.Config, repo repository.Repository, dc discord.Client, stt transcriber.Transcriber, wh webhook.Sender, newMixer audio.MixerFactory) *Manager {
+func SlashCommandNames() []string {
+	names := make([]string, 0, len(slashCommandDefs))
+	for _, def := range slashCommandDefs {
+		names = append(names, def.Name)
+	}
+	return names
+}
+
+func NewManager(cfg *config.Config, repo repository.Repository, dc discord.Client, stt transcriber.Transcriber, wh webhook.Sender, newMixer audio.MixerFactory, archive audio.Archive, tr translator.Translator, sum summarizer.Summarizer, recorder metrics.Recorder) *Manager {
 	loc, err := time.LoadLocation(cfg.TranscriptTimezone)
 	if err != nil {
 		slog.Warn("failed to load transcript timezone; falling back to UTC", "timezone", cfg.TranscriptTimezone, "error", err)
@@ -107,9 +349,16 @@ func NewManager(cfg *config.Config, repo repository.Repository, dc discord.Clien
 		transcriber:        stt,
 		webhook:            wh,
 		newMixer:           newMixer,
+		archive:            archive,
 		transcriptLocation: loc,
 		sessions:           make(map[string]*runningSession),
 		stopReasons:        make(map[string]string),
+		retranscribing:     make(map[string]struct{}),
+		textProcessors:     make(map[string]guildTextProcessor),
+		redactor:           newRedactor(cfg),
+		translator:         tr,
+		summarizer:         sum,
+		metrics:            recorder,
 	}
 }
 
@@ -129,12 +378,35 @@ func (m *Manager) HandleSlashCommand(event discord.SlashCommandEvent) {
 		m.respondEphemeral(event, messageEphemeralWrongGuild)
 		return
 	}
+	if !m.authorizeSlashCommand(event) {
+		return
+	}
 
 	switch event.CommandName {
 	case commandMojiokoshi:
 		m.handleStartCommand(event)
 	case commandMojiokoshiStop:
 		m.handleStopCommand(event)
+	case commandMojiokoshiRetranscribe:
+		m.handleRetranscribeCommand(event)
+	case commandFixTranscript:
+		m.handleFixTranscriptCommand(event)
+	case commandMojiokoshiPhrases:
+		m.handlePhrasesCommand(event)
+	case commandMojiokoshiReplace:
+		m.handleReplaceCommand(event)
+	case commandMojiokoshiSettings:
+		m.handleSettingsCommand(event)
+	case commandMojiokoshiSubscribe:
+		m.handleSubscribeCommand(event)
+	case commandMojiokoshiOptOut:
+		m.handleConsentCommand(event, repository.RecordingConsentDenied)
+	case commandMojiokoshiOptIn:
+		m.handleConsentCommand(event, repository.RecordingConsentGranted)
+	case commandMojiokoshiDelete:
+		m.handleDeleteCommand(event)
+	case commandMojiokoshiAudit:
+		m.handleAuditCommand(event)
 	default:
 		slog.Warn("unknown slash command received", "command", event.CommandName, "guild_id", event.GuildID, "channel_id", event.ChannelID, "user_id", event.UserID)
 		m.respondEphemeral(event, messageEphemeralUnknownCommand)
@@ -156,7 +428,13 @@ func (m *Manager) handleStartCommand(event discord.SlashCommandEvent) {
 		m.respondEphemeral(event, messageEphemeralAlreadyRunning)
 		return
 	}
-	if err := m.startSession(event.GuildID, channelID, event.UserID, false); err != nil {
+	opts, err := m.sessionOptionsFromCommand(event)
+	if err != nil {
+		m.respondEphemeral(event, messageEphemeralLanguagesInvalid)
+		return
+	}
+	opts.trigger = repository.StartTriggerSlash
+	if err := m.startSession(event.GuildID, channelID, event.UserID, false, opts); err != nil {
 		slog.Error("failed to start session by slash command", "error", err, "guild_id", event.GuildID, "channel_id", channelID, "user_id", event.UserID)
 		m.respondEphemeral(event, messageEphemeralStartFailed)
 		return
@@ -175,7 +453,15 @@ func (m *Manager) handleStopCommand(event discord.SlashCommandEvent) {
 		m.respondEphemeral(event, messageEphemeralJoinVCFirst)
 		return
 	}
-	stopped, err := m.stopSession(event.GuildID, channelID, stopReasonManualSlash)
+	// 同じボイスチャンネルにいるメンバーは参加者として扱う
+	if startedBy, ok := m.runningSessionStarter(event.GuildID, channelID); ok {
+		if err := m.authorizeStop(context.Background(), event.GuildID, slashCommandMember(event), startedBy, true); err != nil {
+			slog.Info("stop command rejected by permission", "guild_id", event.GuildID, "channel_id", channelID, "user_id", event.UserID)
+			m.respondEphemeral(event, messageEphemeralStopNotAllowed)
+			return
+		}
+	}
+	stopped, err := m.stopSessionBy(event.GuildID, channelID, event.UserID, stopReasonManualSlash)
 	if err != nil {
 		slog.Error("failed to stop session by slash command", "error", err, "guild_id", event.GuildID, "channel_id", channelID, "user_id", event.UserID)
 		m.respondEphemeral(event, messageEphemeralStopFailed)
@@ -205,6 +491,24 @@ func (m *Manager) isSessionRunning(guildID, channelID string) bool {
 	return exists
 }
 
+// RunningSessionCount は実行中のセッション数を返す
+func (m *Manager) RunningSessionCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.sessions)
+}
+
+func (m *Manager) runningSessionStarter(guildID, channelID string) (string, bool) {
+	key := m.sessionKey(guildID, channelID)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rs, exists := m.sessions[key]
+	if !exists || rs == nil {
+		return "", false
+	}
+	return rs.stopOwnerUserID(), true
+}
+
 func (m *Manager) HandleVoiceStateUpdate(event discord.VoiceStateEvent) {
 	slog.Info(
 		"voice state update received",
@@ -247,6 +551,7 @@ func (m *Manager) trackVoiceParticipants(event discord.VoiceStateEvent) {
 	}
 	if event.AfterChannelID != "" {
 		m.addParticipantIfSessionRunning(event.GuildID, event.AfterChannelID, event.UserID, event.UserIsBot)
+		m.applyJoinedParticipantConsent(event.GuildID, event.AfterChannelID, event.UserID, event.UserIsBot)
 	}
 }
 
@@ -295,7 +600,9 @@ func (m *Manager) startAutoTranscribeIfConfigured(event discord.VoiceStateEvent)
 
 	joinedTarget := event.AfterChannelID == targetChannelID && event.BeforeChannelID != targetChannelID
 	if joinedTarget {
-		if err := m.startSession(event.GuildID, targetChannelID, event.UserID, event.UserIsBot); err != nil {
+		opts := m.defaultSessionOptions()
+		opts.trigger = repository.StartTriggerAuto
+		if err := m.startSession(event.GuildID, targetChannelID, event.UserID, event.UserIsBot, opts); err != nil {
 			slog.Error("failed to start session", "error", err)
 		}
 	}
@@ -305,12 +612,42 @@ func (m *Manager) sessionKey(guildID, channelID string) string {
 	return guildID + ":" + channelID
 }
 
-func (m *Manager) startSession(guildID, channelID, userID string, userIsBot bool) error {
+func (m *Manager) defaultSessionOptions() sessionOptions {
+	threshold := m.cfg.VADThresholdDBFS
+	if threshold >= 0 {
+		threshold = audio.DefaultVADThresholdDBFS
+	}
+	return sessionOptions{
+		vad: audio.VADConfig{
+			Enabled:       m.cfg.VADEnabled,
+			ThresholdDBFS: threshold,
+			Hangover:      time.Duration(m.cfg.VADHangoverMS) * time.Millisecond,
+		},
+	}
+}
+
+func (m *Manager) sessionOptionsFromCommand(event discord.SlashCommandEvent) (sessionOptions, error) {
+	opts := m.defaultSessionOptions()
+	switch event.Option(optionVAD) {
+	case vadChoiceOn:
+		opts.vad.Enabled = true
+	case vadChoiceOff:
+		opts.vad.Enabled = false
+	}
+	languages, err := parseLanguageCandidates(event.Option(optionLanguages))
+	if err != nil {
+		return opts, err
+	}
+	opts.languages = languages
+	return opts, nil
+}
+
+func (m *Manager) startSession(guildID, channelID, userID string, userIsBot bool, opts sessionOptions) (err error) {
 	if strings.TrimSpace(userID) == "" {
 		return nil
 	}
 	key := m.sessionKey(guildID, channelID)
-	slog.Info("start session requested", "session_key", key, "guild_id", guildID, "channel_id", channelID, "user_id", userID, "user_is_bot", userIsBot)
+	slog.Info("start session requested", "session_key", key, "guild_id", guildID, "channel_id", channelID, "user_id", userID, "user_is_bot", userIsBot, "trigger", opts.trigger)
 
 	countable := m.shouldCountLifecycleParticipant(userID, userIsBot)
 
@@ -328,14 +665,26 @@ func (m *Manager) startSession(guildID, channelID, userID string, userIsBot bool
 		return nil
 	}
 
-	ctx := context.Background()
+	// セッションIDは作成後に分かるため、あとから属性に付ける
+	ctx, span := tracer.Start(context.Background(), "session.start", trace.WithAttributes(
+		attribute.String("guild.id", guildID),
+		attribute.String("channel.id", channelID),
+		attribute.String("session.trigger", string(opts.trigger)),
+	))
+	defer func() { tracing.End(span, err) }()
+
 	if err := m.cleanupOrphanRunningSession(ctx, guildID, channelID); err != nil {
 		return err
 	}
-	created, startedAt, streamCtx, mixer, voice, writer, cancel, err := m.initializeSessionRuntime(ctx, guildID, channelID)
+	participants, listErr := m.discord.ListVoiceChannelParticipants(guildID, channelID)
+	streamOpts := m.liveStreamOptions(ctx, guildID, channelID, participants, opts.languages)
+	clock := &audioClock{}
+	translations := newSegmentTranslations()
+	created, startedAt, streamCtx, mixer, voice, writer, cancel, err := m.initializeSessionRuntime(ctx, repository.CreateSessionInput{GuildID: guildID, ChannelID: channelID, StartedByUserID: userID, StartTrigger: opts.trigger}, streamOpts, clock, translations)
 	if err != nil {
 		return err
 	}
+	span.SetAttributes(tracing.SessionID(created.ID))
 
 	rs = &runningSession{
 		repoSession:        created,
@@ -343,38 +692,57 @@ func (m *Manager) startSession(guildID, channelID, userID string, userIsBot bool
 		mixer:              mixer,
 		writer:             writer,
 		cancel:             cancel,
+		translations:       translations,
 		activeParticipants: make(map[string]participantState),
 		allParticipants:    make(map[string]participantState),
+		startedByUserID:    userID,
 	}
 	m.registerSessionJoin(rs, userID, userIsBot, countable, startedAt)
 
-	if participants, err := m.discord.ListVoiceChannelParticipants(guildID, channelID); err != nil {
-		slog.Warn("failed to list voice channel participants", "error", err, "guild_id", guildID, "channel_id", channelID)
+	if listErr != nil {
+		slog.Warn("failed to list voice channel participants", "error", listErr, "guild_id", guildID, "channel_id", channelID)
 	} else {
 		for _, p := range participants {
 			m.registerSessionJoin(rs, p.UserID, p.IsBot, m.shouldCountLifecycleParticipant(p.UserID, p.IsBot), startedAt)
 		}
 	}
+	rs.consent = m.loadConsentGate(ctx, guildID, rs.allParticipants)
 
 	m.mu.Lock()
 	m.sessions[key] = rs
 	m.mu.Unlock()
+	m.metrics.SessionStarted(guildID)
 	slog.Info("session activated", "session_key", key, "session_id", created.ID, "active_participants", len(rs.activeParticipants), "all_participants", len(rs.allParticipants))
+	m.recordAudit(ctx, repository.AuditEvent{GuildID: guildID, ChannelID: channelID, SessionID: created.ID, ActorUserID: userID, Action: repository.AuditActionSessionStart, Reason: string(opts.trigger)})
 
-	_ = m.discord.SendChannelMessage(channelID, m.startChannelMessage())
+	m.sendDiscordStartMessage(rs, channelID)
 
+	pipeline := audioPipeline{
+		archive: m.openSessionAudioArchive(created.ID),
+		vad:     audio.NewVAD(opts.vad, audioMixInterval),
+		clock:   clock,
+	}
+	slog.Info("voice activity detection configured", "session_id", created.ID, "enabled", opts.vad.Enabled, "threshold_dbfs", opts.vad.ThresholdDBFS, "hangover_ms", opts.vad.Hangover.Milliseconds())
 	var receivedOpusPackets int64
+	consent := rs.consent
 	m.runSessionWorker(guildID, channelID, created.ID, "voice_receive", func() {
 		voice.ReceiveAudio(func(audioUserID string, opusPacket []byte) {
 			n := atomic.AddInt64(&receivedOpusPackets, 1)
 			if n == 1 || n%500 == 0 {
 				slog.Info("received opus packet", "session_id", created.ID, "user_id", audioUserID, "packet_bytes", len(opusPacket), "total_packets", n)
 			}
+			m.metrics.AddOpusPackets(guildID, 1)
+			if !consent.allows(audioUserID) {
+				return
+			}
 			mixer.WriteOpusPacket(audioUserID, opusPacket)
 		})
 	})
 	m.runSessionWorker(guildID, channelID, created.ID, "audio_stream", func() {
-		m.streamMixedAudio(streamCtx, created.ID, mixer, writer, &receivedOpusPackets)
+		m.streamMixedAudio(streamCtx, guildID, created.ID, mixer, writer, pipeline, &receivedOpusPackets)
+	})
+	m.runSessionWorker(guildID, channelID, created.ID, "consent_retry", func() {
+		m.retryUnresolvedConsents(streamCtx, rs, channelID)
 	})
 	m.runSessionWorker(guildID, channelID, created.ID, "session_timeout_watch", func() {
 		m.watchSessionTimeoutForSession(streamCtx, guildID, channelID, created.ID)
@@ -425,8 +793,12 @@ func (m *Manager) cleanupOrphanRunningSession(ctx context.Context, guildID, chan
 	return nil
 }
 
-func (m *Manager) initializeSessionRuntime(ctx context.Context, guildID, channelID string) (*repository.Session, time.Time, context.Context, audio.Mixer, discord.VoiceConnection, transcriber.StreamWriter, context.CancelFunc, error) {
+// input の StartedAt は音声チャンネルに参加した時刻で上書きする
+func (m *Manager) initializeSessionRuntime(ctx context.Context, input repository.CreateSessionInput, streamOpts transcriber.StreamOptions, clock *audioClock, translations *segmentTranslations) (*repository.Session, time.Time, context.Context, audio.Mixer, discord.VoiceConnection, transcriber.StreamWriter, context.CancelFunc, error) {
+	guildID, channelID := input.GuildID, input.ChannelID
+	_, joinSpan := tracer.Start(ctx, "session.join_voice")
 	voice, err := m.discord.JoinVoiceChannel(guildID, channelID)
+	tracing.End(joinSpan, err)
 	if err != nil {
 		slog.Error("failed to join voice channel", "error", err, "guild_id", guildID, "channel_id", channelID)
 		return nil, time.Time{}, nil, nil, nil, nil, nil, err
@@ -434,7 +806,8 @@ func (m *Manager) initializeSessionRuntime(ctx context.Context, guildID, channel
 	slog.Info("joined voice channel", "guild_id", guildID, "channel_id", channelID)
 
 	startedAt := time.Now()
-	created, err := m.repo.CreateSession(ctx, repository.CreateSessionInput{GuildID: guildID, ChannelID: channelID, StartedAt: startedAt})
+	input.StartedAt = startedAt
+	created, err := m.repo.CreateSession(ctx, input)
 	if err != nil {
 		_ = voice.Disconnect()
 		slog.Error("failed to create session in repository", "error", err, "guild_id", guildID, "channel_id", channelID)
@@ -443,9 +816,10 @@ func (m *Manager) initializeSessionRuntime(ctx context.Context, guildID, channel
 	slog.Info("created session", "session_id", created.ID, "guild_id", guildID, "channel_id", channelID)
 
 	mixer := m.newMixer()
-	streamCtx, cancel := context.WithCancel(context.Background())
-	receiver := &resultReceiver{manager: m, sessionID: created.ID, channelID: channelID}
-	writer, err := m.transcriber.StartStreaming(streamCtx, created.ID, m.cfg.DefaultTranscribeLanguage, receiver)
+	// 再接続などのスパンを開始時のスパンにつなげるため、ctx を引き継ぐ
+	streamCtx, cancel := context.WithCancel(ctx)
+	receiver := &resultReceiver{manager: m, guildID: guildID, sessionID: created.ID, channelID: channelID, clock: clock, translations: translations}
+	writer, err := m.transcriber.StartStreaming(streamCtx, created.ID, streamOpts, receiver)
 	if err != nil {
 		cancel()
 		mixer.Close()
@@ -601,16 +975,25 @@ func (m *Manager) removeParticipantAndMaybeStop(guildID, channelID, userID strin
 	return err
 }
 
-func (m *Manager) streamMixedAudio(ctx context.Context, sessionID string, mixer audio.Mixer, writer transcriber.StreamWriter, receivedOpusPackets *int64) {
+// audioPipeline はミックス済みフレームを音声認識に送るまでに通す処理をまとめる
+type audioPipeline struct {
+	archive *sessionAudioArchive
+	vad     *audio.VAD
+	clock   *audioClock
+}
+
+func (m *Manager) streamMixedAudio(ctx context.Context, guildID, sessionID string, mixer audio.Mixer, writer transcriber.StreamWriter, pipeline audioPipeline, receivedOpusPackets *int64) {
 	ticker := time.NewTicker(audioMixInterval)
 	statsTicker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
 	defer statsTicker.Stop()
+	defer pipeline.archive.close()
 	buf := make([]byte, audioFrameBytes)
 	var (
-		mixedFrames int64
-		zeroFrames  int64
-		writeFrames int64
+		mixedFrames      int64
+		zeroFrames       int64
+		vadDroppedFrames int64
+		writeFrames      int64
 	)
 	slog.Info("audio mixer loop started")
 	for {
@@ -621,6 +1004,7 @@ func (m *Manager) streamMixedAudio(ctx context.Context, sessionID string, mixer
 				"received_opus_packets", atomic.LoadInt64(receivedOpusPackets),
 				"mixed_frames", mixedFrames,
 				"zero_frames", zeroFrames,
+				"vad_dropped_frames", vadDroppedFrames,
 				"written_frames", writeFrames)
 			return
 		case <-statsTicker.C:
@@ -629,6 +1013,7 @@ func (m *Manager) streamMixedAudio(ctx context.Context, sessionID string, mixer
 				"received_opus_packets", atomic.LoadInt64(receivedOpusPackets),
 				"mixed_frames", mixedFrames,
 				"zero_frames", zeroFrames,
+				"vad_dropped_frames", vadDroppedFrames,
 				"written_frames", writeFrames)
 		case <-ticker.C:
 			n, err := mixer.ReadMixedPCM(buf)
@@ -637,20 +1022,36 @@ func (m *Manager) streamMixedAudio(ctx context.Context, sessionID string, mixer
 				continue
 			}
 			mixedFrames++
+			m.metrics.AddMixedFrames(guildID, 1)
 			if n == 0 {
 				zeroFrames++
+				pipeline.archive.writeSilence()
+				pipeline.clock.skipped()
+				continue
+			}
+			pipeline.archive.writeFrame(buf[:n])
+			if !pipeline.vad.Accept(buf[:n]) {
+				vadDroppedFrames++
+				pipeline.clock.skipped()
 				continue
 			}
 			if err := writer.Write(buf[:n]); err != nil {
 				slog.Error("failed to write pcm to transcriber stream", "error", err, "session_id", sessionID, "pcm_bytes", n)
 				return
 			}
+			pipeline.clock.wrote(n, time.Now())
 			writeFrames++
+			m.metrics.AddWrittenFrames(guildID, 1)
 		}
 	}
 }
 
 func (m *Manager) stopSession(guildID, channelID, reason string) (bool, error) {
+	return m.stopSessionBy(guildID, channelID, "", reason)
+}
+
+// actorUserID はメンバーの操作で止めた場合のみ指定する
+func (m *Manager) stopSessionBy(guildID, channelID, actorUserID, reason string) (bool, error) {
 	if strings.TrimSpace(reason) == "" {
 		reason = stopReasonUnknownError
 	}
@@ -664,6 +1065,8 @@ func (m *Manager) stopSession(guildID, channelID, reason string) (bool, error) {
 
 	endedAt := time.Now()
 	slog.Info("stopping session", "session_id", rs.repoSession.ID, "channel_id", channelID, "reason", reason)
+	rs.stoppedByUserID = actorUserID
+	m.auditSessionStop(rs, channelID, actorUserID, reason)
 	m.terminateSessionRuntime(rs)
 	go m.runFinalizeSession(rs, channelID, reason, endedAt)
 	return true, nil
@@ -683,9 +1086,11 @@ func (m *Manager) StopAllSessions(reason string) int {
 		if stopped.rs == nil || stopped.rs.repoSession == nil || strings.TrimSpace(stopped.rs.repoSession.ID) == "" {
 			continue
 		}
+		stopped.rs.shuttingDown = true
 		wg.Add(1)
 		go func(stopped stoppedSession) {
 			defer wg.Done()
+			m.auditSessionStop(stopped.rs, stopped.channelID, "", reason)
 			m.terminateSessionRuntime(stopped.rs)
 			m.runFinalizeSession(stopped.rs, stopped.channelID, reason, time.Now())
 		}(stopped)
@@ -704,6 +1109,17 @@ func (m *Manager) StopAllSessions(reason string) int {
 	return len(sessions)
 }
 
+func (m *Manager) auditSessionStop(rs *runningSession, channelID, actorUserID, reason string) {
+	m.recordAudit(context.Background(), repository.AuditEvent{
+		GuildID:     rs.repoSession.GuildID,
+		ChannelID:   channelID,
+		SessionID:   rs.repoSession.ID,
+		ActorUserID: actorUserID,
+		Action:      repository.AuditActionSessionStop,
+		Reason:      reason,
+	})
+}
+
 type stoppedSession struct {
 	channelID string
 	rs        *runningSession
@@ -751,6 +1167,9 @@ func (m *Manager) terminateSessionRuntime(rs *runningSession) {
 	if rs == nil {
 		return
 	}
+	if rs.repoSession != nil {
+		m.metrics.SessionStopped(rs.repoSession.GuildID)
+	}
 	if rs.cancel != nil {
 		rs.cancel()
 	}
@@ -783,13 +1202,16 @@ func (m *Manager) runFinalizeSession(rs *runningSession, channelID, reason strin
 }
 
 func (m *Manager) finalizeSession(rs *runningSession, channelID, reason string, endedAt time.Time) {
-	ctx := context.Background()
 	if rs == nil || rs.repoSession == nil {
 		slog.Warn("skipping finalize for empty session state", "channel_id", channelID, "reason", reason)
 		return
 	}
 	s := rs.repoSession
-	m.sendDiscordStopMessage(s.ID, channelID, reason)
+	ctx, span := startSessionSpan(context.Background(), "session.finalize", s.ID, attribute.String("session.stop_reason", reason))
+	defer span.End()
+	m.disableStartMessageStopButton(rs, channelID)
+	m.sendDiscordStopMessage(rs, channelID, reason, endedAt)
+	waitForTranslations(rs.translations, s.ID)
 
 	segmentsCtx, cancelSegments := context.WithTimeout(ctx, finalizeSegmentLookupTimeout)
 	segments, segmentsAvailable := m.listSegmentsBestEffort(segmentsCtx, s.ID)
@@ -800,22 +1222,44 @@ func (m *Manager) finalizeSession(rs *runningSession, channelID, reason string,
 	meta := m.resolveTranscriptMetadataBestEffort(metadataCtx, s, participantUserIDs, rs.allParticipants)
 	cancelMetadata()
 
-	filename := fmt.Sprintf("transcript-%s.txt", s.ID)
-	body := buildTranscriptText(meta, s.StartedAt, endedAt, m.cfg.TranscriptTimezone, m.transcriptLocation, segments)
+	src := transcriptSource{
+		SessionID:              s.ID,
+		Meta:                   meta,
+		StartedAt:              s.StartedAt,
+		EndedAt:                endedAt,
+		Timezone:               m.cfg.TranscriptTimezone,
+		Location:               m.transcriptLocation,
+		Segments:               segments,
+		LowConfidenceThreshold: m.cfg.TranscriptLowConfidence,
+		StartedByUserID:        s.StartedByUserID,
+		StartTrigger:           s.StartTrigger,
+		StoppedByUserID:        rs.stoppedByUserID,
+	}
+	filename := transcriptFilename(s.ID, 0)
+	body := buildTranscriptText(src)
 	if !segmentsAvailable {
 		body = append(body, []byte("\n\n(文字起こし本文の取得に失敗したため、取得できた範囲のみを添付しています)\n")...)
 	}
-	m.sendDiscordTranscriptAttachment(s.ID, channelID, filename, body)
+	m.sendDiscordTranscriptAttachment(ctx, s.ID, channelID, filename, body, m.transcriptAttachmentEmbed(src))
 	m.completeSessionBestEffort(ctx, s.ID, endedAt)
 
-	payload := buildTranscriptWebhookPayload(s.ID, meta, s.StartedAt, endedAt, m.cfg.TranscriptTimezone, m.transcriptLocation, segments)
+	payload := buildTranscriptWebhookPayload(src)
 	slog.Info("sending transcript webhook payload", "session_id", s.ID, "discord_server_id", payload.DiscordServerID, "discord_server_name", payload.DiscordServerName, "discord_voice_channel_id", payload.DiscordVoiceChannelID, "discord_voice_channel_name", payload.DiscordVoiceChannelName, "segment_count", payload.SegmentCount)
 	m.saveSessionOutputBestEffort(ctx, s, reason, endedAt, meta, filename, body, payload, rs.allParticipants)
 	m.sendWebhookBestEffort(ctx, s.ID, payload)
+	m.deliverTranscriptDMsBestEffort(ctx, s, filename, body)
+
+	// 要約は時間がかかるため、文字起こしを届けて保存した後に追って投稿する
+	if rs.shuttingDown {
+		return
+	}
+	m.attachSummaryBestEffort(ctx, s.ID, channelID, src, filename, body)
 }
 
 func (m *Manager) listSegmentsBestEffort(ctx context.Context, sessionID string) ([]repository.TranscriptSegment, bool) {
+	ctx, span := startSessionSpan(ctx, "session.list_segments", sessionID)
 	segments, err := m.repo.ListSegmentsBySessionID(ctx, sessionID)
+	tracing.End(span, err)
 	if err != nil {
 		slog.Error("failed to list transcript segments", "error", err, "session_id", sessionID)
 		return []repository.TranscriptSegment{}, false
@@ -833,7 +1277,9 @@ func participantUserIDsFromStates(states map[string]participantState) []string {
 }
 
 func (m *Manager) resolveTranscriptMetadataBestEffort(ctx context.Context, s *repository.Session, participantUserIDs []string, allParticipants map[string]participantState) discord.TranscriptMetadata {
+	ctx, span := startSessionSpan(ctx, "session.resolve_metadata", s.ID)
 	meta, err := m.discord.ResolveTranscriptMetadata(ctx, s.GuildID, s.ChannelID, participantUserIDs)
+	tracing.End(span, err)
 	if err != nil {
 		slog.Warn("failed to resolve transcript metadata; using fallback values", "error", err, "session_id", s.ID)
 		meta = transcriptMetadataFallbackFromSession(s)
@@ -843,19 +1289,27 @@ func (m *Manager) resolveTranscriptMetadataBestEffort(ctx context.Context, s *re
 	return meta
 }
 
-func (m *Manager) sendDiscordStopMessage(sessionID, channelID, reason string) {
-	if err := m.discord.SendChannelMessage(channelID, m.stopChannelMessage(reason)); err != nil {
-		slog.Error("failed to send stop message", "error", err, "session_id", sessionID, "channel_id", channelID, "reason", reason)
+func (m *Manager) sendDiscordStopMessage(rs *runningSession, channelID, reason string, endedAt time.Time) {
+	if _, err := m.discord.SendChannelEmbedMessage(m.stopChannelEmbed(rs, channelID, reason, endedAt)); err != nil {
+		slog.Error("failed to send stop message", "error", err, "session_id", rs.repoSession.ID, "channel_id", channelID, "reason", reason)
 	}
 }
 
-func (m *Manager) sendDiscordTranscriptAttachment(sessionID, channelID, filename string, body []byte) {
-	if err := m.discord.SendChannelMessageWithFile(discord.FileMessage{
+func (m *Manager) sendDiscordTranscriptAttachment(ctx context.Context, sessionID, channelID, filename string, body []byte, embed discord.Embed) {
+	_, span := startSessionSpan(ctx, "session.send_attachment", sessionID)
+	err := m.discord.SendChannelMessageWithFile(discord.FileMessage{
 		ChannelID: channelID,
-		Content:   m.transcriptAttachmentMessage(),
 		Filename:  filename,
 		FileBody:  body,
-	}); err != nil {
+		Embeds:    []discord.Embed{embed},
+		Buttons: []discord.Button{{
+			CustomID: sessionButtonCustomID(buttonActionResendTranscriptDM, sessionID),
+			Label:    messageButtonResendTranscriptDM,
+			Style:    discord.ButtonStyleSecondary,
+		}},
+	})
+	tracing.End(span, err)
+	if err != nil {
 		slog.Error("failed to send transcript attachment", "error", err, "session_id", sessionID, "channel_id", channelID)
 	}
 }
@@ -873,6 +1327,7 @@ func (m *Manager) saveSessionOutputBestEffort(ctx context.Context, s *repository
 		SessionID:          s.ID,
 		EndedAt:            endedAt,
 		StopReason:         reason,
+		StoppedByUserID:    payload.StoppedByUserID,
 		GuildName:          meta.DiscordServerName,
 		ChannelName:        meta.DiscordVoiceChannelName,
 		Timezone:           m.cfg.TranscriptTimezone,
@@ -882,8 +1337,12 @@ func (m *Manager) saveSessionOutputBestEffort(ctx context.Context, s *repository
 		TranscriptFilename: filename,
 		TranscriptText:     string(body),
 		WebhookPayloadJSON: payloadJSON,
+		SummaryJSON:        marshalSummaryBestEffort(payload.Summary, s.ID),
 	}
-	if err := m.repo.SaveSessionOutput(ctx, saveInput); err != nil {
+	ctx, span := startSessionSpan(ctx, "session.save_output", s.ID)
+	err := m.repo.SaveSessionOutput(ctx, saveInput)
+	tracing.End(span, err)
+	if err != nil {
 		slog.Error("failed to save session output", "error", err, "session_id", s.ID)
 	}
 }
@@ -897,8 +1356,23 @@ func marshalPayloadBestEffort(payload webhook.TranscriptWebhookPayload, sessionI
 	return payloadJSON
 }
 
+func marshalSummaryBestEffort(summary *webhook.TranscriptWebhookSummary, sessionID string) []byte {
+	if summary == nil {
+		return nil
+	}
+	summaryJSON, err := json.Marshal(summary)
+	if err != nil {
+		slog.Error("failed to marshal session summary for database persistence", "error", err, "session_id", sessionID)
+		return nil
+	}
+	return summaryJSON
+}
+
 func (m *Manager) sendWebhookBestEffort(ctx context.Context, sessionID string, payload webhook.TranscriptWebhookPayload) {
-	if err := m.webhook.SendTranscript(ctx, payload); err != nil {
+	ctx, span := startSessionSpan(ctx, "session.send_webhook", sessionID)
+	err := m.webhook.SendTranscript(ctx, payload)
+	tracing.End(span, err)
+	if err != nil {
 		slog.Error("failed to send webhook transcript", "error", err, "session_id", sessionID)
 	}
 }
@@ -973,34 +1447,21 @@ func fallbackParticipants(participantUserIDs []string, allParticipants map[strin
 	return participants
 }
 
-func (m *Manager) startChannelMessage() string {
+func (m *Manager) retranscribeAttachmentMessage(revision int) string {
 	lines := []string{
-		messageStartChannelTitle,
-		messageStartChannelHint,
+		retranscribeAttachmentTitle(revision),
 	}
 	return strings.Join(m.withPoweredByForBrand(lines), "\n")
 }
 
-func (m *Manager) stopChannelMessage(reason string) string {
-	restart := messageStopRestart
-	if stopReasonNeedsRestartAgain(reason) {
-		restart = messageStopRestartAgain
-	}
+func (m *Manager) retranscribeEphemeralMessage() string {
 	lines := []string{
-		messageStopChannelTitle,
-		"-# " + stopReasonDetail(reason),
-		"-# " + restart,
+		messageRetranscribeEphemeralTitle,
+		messageRetranscribeEphemeralHint,
 	}
 	return strings.Join(lines, "\n")
 }
 
-func (m *Manager) transcriptAttachmentMessage() string {
-	lines := []string{
-		messageAttachmentTitle,
-	}
-	return strings.Join(m.withPoweredByForBrand(lines), "\n")
-}
-
 func (m *Manager) startEphemeralMessage(channelID string) string {
 	lines := []string{
 		startEphemeralTitle(channelID),
@@ -1053,38 +1514,83 @@ func participantIdentityForSnapshot(userID string, state participantState, displ
 	return name, isBot || metaParticipant.IsBot
 }
 
-func (m *Manager) handleTranscriptionResult(sessionID, channelID string, segmentIndex int, text string, isFinal bool) {
-	if !isFinal || strings.TrimSpace(text) == "" {
-		return
-	}
-	ctx := context.Background()
-	if err := m.repo.InsertSegment(ctx, repository.InsertSegmentInput{SessionID: sessionID, Content: text, SegmentIndex: segmentIndex, SpokenAt: time.Now()}); err != nil {
+// 翻訳を待たずに原文を保存・投稿する。翻訳は戻り値の postedSegment をもとに後から付け足す
+func (m *Manager) handleTranscriptionResult(guildID, sessionID, channelID string, segmentIndex int, result transcriber.Result, timing segmentTiming) (postedSegment, bool) {
+	if !result.IsFinal || strings.TrimSpace(result.Text) == "" {
+		return postedSegment{}, false
+	}
+	ctx, span := startSessionSpan(context.Background(), "session.segment", sessionID, attribute.Int("segment.index", segmentIndex))
+	defer span.End()
+	processed := m.processTranscriptText(ctx, guildID, result.Text)
+	text := processed.content
+	if strings.TrimSpace(text) == "" {
+		return postedSegment{}, false
+	}
+	if !processed.keepWords {
+		timing.words = nil
+	}
+	persistCtx, persistSpan := startSessionSpan(ctx, "session.segment.persist", sessionID)
+	err := m.repo.InsertSegment(persistCtx, repository.InsertSegmentInput{
+		SessionID:    sessionID,
+		Content:      text,
+		RawContent:   processed.raw,
+		SegmentIndex: segmentIndex,
+		SpokenAt:     timing.spokenAt,
+		EndedAt:      timing.endedAt,
+		Confidence:   result.Confidence,
+		Words:        timing.words,
+		Language:     result.Language,
+	})
+	tracing.End(persistSpan, err)
+	if err != nil {
 		slog.Error("failed to insert segment", "error", err, "session_id", sessionID)
-		return
+		return postedSegment{}, false
 	}
-	if err := m.discord.SendChannelMessage(channelID, text); err != nil {
+	posted := postedSegment{sessionID: sessionID, channelID: channelID, segmentIndex: segmentIndex, content: text, language: result.Language}
+	_, postSpan := startSessionSpan(ctx, "session.segment.post", sessionID)
+	messageID, err := m.discord.SendChannelMessage(channelID, text)
+	tracing.End(postSpan, err)
+	if err != nil {
 		slog.Error("failed to post transcript message", "error", err, "session_id", sessionID)
+		return posted, true
+	}
+	m.metrics.ObserveSegmentLatency(guildID, time.Since(timing.endedAt))
+	if err := m.repo.SetSegmentDiscordMessageID(ctx, sessionID, segmentIndex, messageID); err != nil {
+		slog.Warn("failed to link transcript message to segment", "error", err, "session_id", sessionID, "segment_index", segmentIndex, "message_id", messageID)
 	}
+	posted.messageID = messageID
+	return posted, true
 }
 
 type resultReceiver struct {
 	manager   *Manager
+	guildID   string
 	sessionID string
 	channelID string
-	mu        sync.Mutex
-	nextIndex int
+	clock     *audioClock
+	// 終了処理で翻訳の完了を待つために、実行中のセッションと共有する
+	translations *segmentTranslations
+	mu           sync.Mutex
+	nextIndex    int
 }
 
-func (r *resultReceiver) OnResult(segmentIndex int, text string, isFinal bool) {
-	_ = segmentIndex
-	if !isFinal {
+func (r *resultReceiver) OnResult(result transcriber.Result) {
+	if !result.IsFinal {
 		return
 	}
+	now := time.Now()
+	timing := segmentTiming{spokenAt: now, endedAt: now}
+	if r.clock != nil {
+		timing = r.clock.timing(result, now)
+	}
 	r.mu.Lock()
 	idx := r.nextIndex
 	r.nextIndex++
 	r.mu.Unlock()
-	r.manager.handleTranscriptionResult(r.sessionID, r.channelID, idx, text, true)
+	posted, ok := r.manager.handleTranscriptionResult(r.guildID, r.sessionID, r.channelID, idx, result, timing)
+	if ok && r.translations != nil {
+		r.manager.translatePostedSegmentInBackground(r.translations, posted)
+	}
 }
 
 func (r *resultReceiver) OnError(err error) {
@@ -1,37 +1,72 @@
 package session
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/foxseedlab/mojiokoshin/internal/audio"
 	"github.com/foxseedlab/mojiokoshin/internal/config"
 	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/metrics"
 	"github.com/foxseedlab/mojiokoshin/internal/repository"
+	"github.com/foxseedlab/mojiokoshin/internal/summarizer"
 	"github.com/foxseedlab/mojiokoshin/internal/transcriber"
 	"github.com/foxseedlab/mojiokoshin/internal/webhook"
 )
 
 type mockRepository struct {
-	insertCalls       []repository.InsertSegmentInput
-	savedOutputCalls  []repository.SaveSessionOutputInput
-	createCount       int
-	listSegmentsErr   error
-	listSegmentsDelay time.Duration
+	mu                    sync.Mutex
+	insertCalls           []repository.InsertSegmentInput
+	savedOutputCalls      []repository.SaveSessionOutputInput
+	savedRevisionCalls    []repository.SaveTranscriptRevisionInput
+	createCount           int
+	listSegmentsErr       error
+	listSegmentsDelay     time.Duration
+	sessionsByID          map[string]*repository.Session
+	participantsBySession map[string][]repository.SessionParticipant
+	segments              []repository.TranscriptSegment
+	linkedMessageIDs      map[int]string
+	correctCalls          []repository.CorrectSegmentInput
+	savedArtifactCalls    []repository.SaveTranscriptArtifactInput
+	phraseHints           []repository.PhraseHint
+	replacementRules      []repository.ReplacementRule
+	guildSettings         map[string]repository.GuildSettings
+	guildSettingsErr      error
+	translationUpdates    []int
+	subscriptions         map[string]bool
+	consents              map[string]repository.RecordingConsent
+	consentErr            error
+	expiredTranscripts    []string
+	expiredArtifacts      []string
+	purgeInputs           []repository.PurgeExpiredInput
+	deletedSessionIDs     []string
+	userParticipations    []repository.UserParticipation
+	userSegmentEdits      []repository.UserSegmentEdit
+	userPreferences       []repository.UserGuildPreference
+	userArtifacts         []repository.UserArtifact
+	eraseCalls            []repository.EraseUserInput
+	auditEvents           []repository.AuditEvent
 }
 
 func (m *mockRepository) CreateSession(_ context.Context, input repository.CreateSessionInput) (*repository.Session, error) {
 	m.createCount++
 	return &repository.Session{
-		ID:        fmt.Sprintf("session-%d", m.createCount),
-		GuildID:   input.GuildID,
-		ChannelID: input.ChannelID,
-		StartedAt: input.StartedAt,
-		Status:    repository.SessionStatusRunning,
+		ID:              fmt.Sprintf("session-%d", m.createCount),
+		GuildID:         input.GuildID,
+		ChannelID:       input.ChannelID,
+		StartedAt:       input.StartedAt,
+		Status:          repository.SessionStatusRunning,
+		StartedByUserID: input.StartedByUserID,
+		StartTrigger:    input.StartTrigger,
 	}, nil
 }
 
@@ -39,16 +74,56 @@ func (m *mockRepository) UpdateSessionCompleted(_ context.Context, _ repository.
 	return nil
 }
 
+func (m *mockRepository) GetTranscriptArtifact(_ context.Context, sessionID string) (*repository.TranscriptArtifact, error) {
+	for i := len(m.savedArtifactCalls) - 1; i >= 0; i-- {
+		if c := m.savedArtifactCalls[i]; c.SessionID == sessionID {
+			return &repository.TranscriptArtifact{SessionID: c.SessionID, TranscriptFilename: c.TranscriptFilename, TranscriptText: c.TranscriptText}, nil
+		}
+	}
+	for i := len(m.savedOutputCalls) - 1; i >= 0; i-- {
+		if c := m.savedOutputCalls[i]; c.SessionID == sessionID {
+			return &repository.TranscriptArtifact{SessionID: c.SessionID, TranscriptFilename: c.TranscriptFilename, TranscriptText: c.TranscriptText}, nil
+		}
+	}
+	return nil, nil
+}
+
 func (m *mockRepository) SaveSessionOutput(_ context.Context, input repository.SaveSessionOutputInput) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.savedOutputCalls = append(m.savedOutputCalls, input)
 	return nil
 }
 
+func (m *mockRepository) SaveTranscriptRevision(_ context.Context, input repository.SaveTranscriptRevisionInput) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.savedRevisionCalls = append(m.savedRevisionCalls, input)
+	return nil
+}
+
+func (m *mockRepository) SaveTranscriptArtifact(_ context.Context, input repository.SaveTranscriptArtifactInput) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.savedArtifactCalls = append(m.savedArtifactCalls, input)
+	return nil
+}
+
 func (m *mockRepository) GetRunningSessionByChannel(_ context.Context, _, _ string) (*repository.Session, error) {
 	return nil, nil
 }
 
+func (m *mockRepository) GetSessionByID(_ context.Context, sessionID string) (*repository.Session, error) {
+	return m.sessionsByID[sessionID], nil
+}
+
+func (m *mockRepository) ListSessionParticipants(_ context.Context, sessionID string) ([]repository.SessionParticipant, error) {
+	return m.participantsBySession[sessionID], nil
+}
+
 func (m *mockRepository) InsertSegment(_ context.Context, input repository.InsertSegmentInput) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.insertCalls = append(m.insertCalls, input)
 	return nil
 }
@@ -66,34 +141,388 @@ func (m *mockRepository) ListSegmentsBySessionID(ctx context.Context, _ string)
 	if m.listSegmentsErr != nil {
 		return nil, m.listSegmentsErr
 	}
-	return nil, nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]repository.TranscriptSegment(nil), m.segments...), nil
+}
+
+func (m *mockRepository) AddPhraseHint(_ context.Context, input repository.AddPhraseHintInput) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, h := range m.phraseHints {
+		if h.GuildID == input.GuildID && h.Phrase == input.Phrase {
+			return false, nil
+		}
+	}
+	m.phraseHints = append(m.phraseHints, repository.PhraseHint{GuildID: input.GuildID, Phrase: input.Phrase, CreatedByUserID: input.CreatedByUserID})
+	return true, nil
+}
+
+func (m *mockRepository) RemovePhraseHint(_ context.Context, guildID, phrase string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, h := range m.phraseHints {
+		if h.GuildID == guildID && h.Phrase == phrase {
+			m.phraseHints = append(m.phraseHints[:i], m.phraseHints[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockRepository) ListPhraseHints(_ context.Context, guildID string) ([]repository.PhraseHint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var list []repository.PhraseHint
+	for _, h := range m.phraseHints {
+		if h.GuildID == guildID {
+			list = append(list, h)
+		}
+	}
+	return list, nil
+}
+
+func (m *mockRepository) SaveReplacementRule(_ context.Context, input repository.SaveReplacementRuleInput) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rule := repository.ReplacementRule{GuildID: input.GuildID, Pattern: input.Pattern, Replacement: input.Replacement, IsRegex: input.IsRegex, CreatedByUserID: input.CreatedByUserID}
+	for i, existing := range m.replacementRules {
+		if existing.GuildID == input.GuildID && existing.Pattern == input.Pattern {
+			m.replacementRules[i] = rule
+			return nil
+		}
+	}
+	m.replacementRules = append(m.replacementRules, rule)
+	return nil
+}
+
+func (m *mockRepository) RemoveReplacementRule(_ context.Context, guildID, pattern string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, rule := range m.replacementRules {
+		if rule.GuildID == guildID && rule.Pattern == pattern {
+			m.replacementRules = append(m.replacementRules[:i], m.replacementRules[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockRepository) ListReplacementRules(_ context.Context, guildID string) ([]repository.ReplacementRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var list []repository.ReplacementRule
+	for _, rule := range m.replacementRules {
+		if rule.GuildID == guildID {
+			list = append(list, rule)
+		}
+	}
+	return list, nil
+}
+
+func (m *mockRepository) GetGuildSettings(_ context.Context, guildID string) (*repository.GuildSettings, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.guildSettingsErr != nil {
+		return nil, m.guildSettingsErr
+	}
+	settings, ok := m.guildSettings[guildID]
+	if !ok {
+		return nil, nil
+	}
+	return &settings, nil
+}
+
+func (m *mockRepository) SaveGuildSettings(_ context.Context, settings repository.GuildSettings) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.guildSettings == nil {
+		m.guildSettings = make(map[string]repository.GuildSettings)
+	}
+	m.guildSettings[settings.GuildID] = settings
+	return nil
+}
+
+func (m *mockRepository) SetTranscriptSubscription(_ context.Context, guildID, userID string, subscribed bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.subscriptions == nil {
+		m.subscriptions = make(map[string]bool)
+	}
+	m.subscriptions[guildID+":"+userID] = subscribed
+	return nil
+}
+
+func (m *mockRepository) IsTranscriptSubscribed(_ context.Context, guildID, userID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.subscriptions[guildID+":"+userID], nil
+}
+
+func (m *mockRepository) ListTranscriptSubscribers(_ context.Context, guildID string, userIDs []string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []string
+	for _, userID := range userIDs {
+		if m.subscriptions[guildID+":"+userID] {
+			out = append(out, userID)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockRepository) SetRecordingConsent(_ context.Context, guildID, userID string, consent repository.RecordingConsent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.consentErr != nil {
+		return m.consentErr
+	}
+	if m.consents == nil {
+		m.consents = make(map[string]repository.RecordingConsent)
+	}
+	m.consents[guildID+":"+userID] = consent
+	return nil
+}
+
+func (m *mockRepository) ListRecordingConsents(_ context.Context, guildID string, userIDs []string) (map[string]repository.RecordingConsent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.consentErr != nil {
+		return nil, m.consentErr
+	}
+	out := make(map[string]repository.RecordingConsent)
+	for _, userID := range userIDs {
+		if consent, ok := m.consents[guildID+":"+userID]; ok {
+			out[userID] = consent
+		}
+	}
+	return out, nil
+}
+
+func (m *mockRepository) PurgeExpiredTranscripts(_ context.Context, input repository.PurgeExpiredInput) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.purgeInputs = append(m.purgeInputs, input)
+	return takeExpired(&m.expiredTranscripts, input.Limit), nil
+}
+
+func (m *mockRepository) PurgeExpiredArtifacts(_ context.Context, input repository.PurgeExpiredInput) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.purgeInputs = append(m.purgeInputs, input)
+	return takeExpired(&m.expiredArtifacts, input.Limit), nil
+}
+
+func takeExpired(queue *[]string, limit int) []string {
+	n := min(limit, len(*queue))
+	out := (*queue)[:n]
+	*queue = (*queue)[n:]
+	return out
+}
+
+func (m *mockRepository) DeleteSession(_ context.Context, sessionID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sessionsByID[sessionID]; !ok {
+		return false, nil
+	}
+	delete(m.sessionsByID, sessionID)
+	m.deletedSessionIDs = append(m.deletedSessionIDs, sessionID)
+	return true, nil
+}
+
+func (m *mockRepository) ListUserParticipations(_ context.Context, _ string) ([]repository.UserParticipation, error) {
+	return m.userParticipations, nil
+}
+
+func (m *mockRepository) ListUserSegmentEdits(_ context.Context, _ string) ([]repository.UserSegmentEdit, error) {
+	return m.userSegmentEdits, nil
+}
+
+func (m *mockRepository) ListUserGuildPreferences(_ context.Context, _ string) ([]repository.UserGuildPreference, error) {
+	return m.userPreferences, nil
+}
+
+func (m *mockRepository) ListUserArtifacts(_ context.Context, _ string) ([]repository.UserArtifact, error) {
+	return m.userArtifacts, nil
+}
+
+func (m *mockRepository) EraseUser(_ context.Context, input repository.EraseUserInput) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.eraseCalls = append(m.eraseCalls, input)
+	return nil
+}
+
+func (m *mockRepository) InsertAuditEvent(_ context.Context, event repository.AuditEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	event.ID = fmt.Sprintf("audit-%d", len(m.auditEvents)+1)
+	event.CreatedAt = time.Now()
+	m.auditEvents = append(m.auditEvents, event)
+	return nil
+}
+
+func (m *mockRepository) ListAuditEvents(_ context.Context, input repository.ListAuditEventsInput) ([]repository.AuditEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []repository.AuditEvent
+	for i := len(m.auditEvents) - 1; i >= 0; i-- {
+		e := m.auditEvents[i]
+		if (input.GuildID != "" && e.GuildID != input.GuildID) ||
+			(input.SessionID != "" && e.SessionID != input.SessionID) ||
+			(input.ActorUserID != "" && e.ActorUserID != input.ActorUserID) {
+			continue
+		}
+		out = append(out, e)
+		if input.Limit > 0 && len(out) == input.Limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (m *mockRepository) auditActions() []repository.AuditAction {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	actions := make([]repository.AuditAction, 0, len(m.auditEvents))
+	for _, e := range m.auditEvents {
+		actions = append(actions, e.Action)
+	}
+	return actions
+}
+
+func (m *mockRepository) ReencryptBatch(_ context.Context, _ int) (int, error) {
+	return 0, nil
+}
+
+func (m *mockRepository) Ping(_ context.Context) error {
+	return nil
+}
+
+func (m *mockRepository) SetSegmentDiscordMessageID(_ context.Context, _ string, segmentIndex int, messageID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.linkedMessageIDs == nil {
+		m.linkedMessageIDs = make(map[int]string)
+	}
+	m.linkedMessageIDs[segmentIndex] = messageID
+	return nil
+}
+
+func (m *mockRepository) SetSegmentTranslations(_ context.Context, _ string, segmentIndex int, translations []repository.SegmentTranslation) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.insertCalls {
+		if m.insertCalls[i].SegmentIndex == segmentIndex {
+			m.insertCalls[i].Translations = translations
+		}
+	}
+	m.translationUpdates = append(m.translationUpdates, segmentIndex)
+	return true, nil
+}
+
+func (m *mockRepository) GetSegment(_ context.Context, sessionID string, segmentIndex int) (*repository.TranscriptSegment, error) {
+	return m.findSegment(func(seg repository.TranscriptSegment) bool {
+		return seg.SessionID == sessionID && seg.SegmentIndex == segmentIndex
+	}), nil
+}
+
+func (m *mockRepository) GetSegmentByDiscordMessageID(_ context.Context, messageID string) (*repository.TranscriptSegment, error) {
+	return m.findSegment(func(seg repository.TranscriptSegment) bool {
+		return seg.DiscordMessageID == messageID
+	}), nil
+}
+
+func (m *mockRepository) findSegment(match func(repository.TranscriptSegment) bool) *repository.TranscriptSegment {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, seg := range m.segments {
+		if match(seg) {
+			found := seg
+			return &found
+		}
+	}
+	return nil
+}
+
+func (m *mockRepository) CorrectSegment(_ context.Context, input repository.CorrectSegmentInput) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.correctCalls = append(m.correctCalls, input)
+	for i := range m.segments {
+		if m.segments[i].ID == input.SegmentID {
+			editedAt := input.EditedAt
+			m.segments[i].Content = input.Content
+			m.segments[i].CorrectedAt = &editedAt
+			m.segments[i].Confidence = nil
+			m.segments[i].Words = nil
+		}
+	}
+	return nil
 }
 
 type mockDiscordClient struct {
 	sendCalls            []string
+	editCalls            []string
 	fileCalls            []discord.FileMessage
+	embedCalls           []discord.EmbedMessage
+	embedEditCalls       []discord.EmbedMessage
+	dmCalls              []discord.FileMessage
+	dmErr                error
 	userVoiceChannelByID map[string]string
 	botUserID            string
 	resolveMetadataErr   error
 	resolveMetadataDelay time.Duration
+	// voicePackets は参加した音声チャンネルで受信する音声で、キーは話者のユーザーID
+	voicePackets []voicePacket
+	// voiceDone は voicePackets をすべて渡し終えたときに閉じる
+	voiceDone chan struct{}
+}
+
+type voicePacket struct {
+	userID string
+	opus   []byte
 }
 
 func (m *mockDiscordClient) Connect(_ context.Context) error { return nil }
 func (m *mockDiscordClient) Close() error                    { return nil }
 func (m *mockDiscordClient) JoinVoiceChannel(_, _ string) (discord.VoiceConnection, error) {
-	return &mockVoiceConnection{}, nil
+	return &mockVoiceConnection{packets: m.voicePackets, done: m.voiceDone}, nil
 }
-func (m *mockDiscordClient) SendChannelMessage(_ string, content string) error {
+func (m *mockDiscordClient) SendChannelMessage(_ string, content string) (string, error) {
 	m.sendCalls = append(m.sendCalls, content)
+	return fmt.Sprintf("message-%d", len(m.sendCalls)), nil
+}
+func (m *mockDiscordClient) EditChannelMessage(_, messageID, content string) error {
+	m.editCalls = append(m.editCalls, messageID+":"+content)
 	return nil
 }
 func (m *mockDiscordClient) SendChannelMessageWithFile(msg discord.FileMessage) error {
 	m.fileCalls = append(m.fileCalls, msg)
 	return nil
 }
+func (m *mockDiscordClient) SendChannelEmbedMessage(msg discord.EmbedMessage) (string, error) {
+	m.embedCalls = append(m.embedCalls, msg)
+	return fmt.Sprintf("embed-%d", len(m.embedCalls)), nil
+}
+func (m *mockDiscordClient) SendDirectMessageWithFile(userID string, msg discord.FileMessage) error {
+	if m.dmErr != nil {
+		return m.dmErr
+	}
+	msg.ChannelID = "dm-" + userID
+	m.dmCalls = append(m.dmCalls, msg)
+	return nil
+}
+func (m *mockDiscordClient) EditChannelEmbedMessage(_ string, msg discord.EmbedMessage) error {
+	m.embedEditCalls = append(m.embedEditCalls, msg)
+	return nil
+}
 func (m *mockDiscordClient) RegisterVoiceStateUpdateHandler(_ func(discord.VoiceStateEvent)) {
 }
 func (m *mockDiscordClient) RegisterSlashCommandHandler(_ func(discord.SlashCommandEvent)) {}
+func (m *mockDiscordClient) RegisterModalSubmitHandler(_ func(discord.ModalSubmitEvent))   {}
+func (m *mockDiscordClient) RegisterComponentHandler(_ func(discord.ComponentEvent))       {}
 func (m *mockDiscordClient) UpsertGuildSlashCommands(_ string, _ []discord.SlashCommandDefinition) error {
 	return nil
 }
@@ -106,6 +535,8 @@ func (m *mockDiscordClient) GetUserVoiceChannelID(_, userID string) (string, err
 func (m *mockDiscordClient) ListVoiceChannelParticipants(_, _ string) ([]discord.VoiceParticipant, error) {
 	return nil, nil
 }
+func (m *mockDiscordClient) GatewayConnected() bool { return true }
+
 func (m *mockDiscordClient) GetBotUserID() (string, error) {
 	if m.botUserID != "" {
 		return m.botUserID, nil
@@ -141,24 +572,41 @@ func (m *mockDiscordClient) Run() error { return nil }
 
 type mockTranscriber struct{}
 
-func (m *mockTranscriber) StartStreaming(_ context.Context, _, _ string, _ transcriber.ResultReceiver) (transcriber.StreamWriter, error) {
+func (m *mockTranscriber) StartStreaming(_ context.Context, _ string, _ transcriber.StreamOptions, _ transcriber.ResultReceiver) (transcriber.StreamWriter, error) {
 	return &mockStreamWriter{}, nil
 }
 
+func (m *mockTranscriber) Ping(_ context.Context) error { return nil }
+
 type mockStreamWriter struct{}
 
 func (m *mockStreamWriter) Write(_ []byte) error { return nil }
 func (m *mockStreamWriter) Close() error         { return nil }
 
-type mockVoiceConnection struct{}
+type mockVoiceConnection struct {
+	packets []voicePacket
+	done    chan struct{}
+}
 
 func (m *mockVoiceConnection) Disconnect() error { return nil }
-func (m *mockVoiceConnection) ReceiveAudio(_ func(userID string, opusPCM []byte)) {
+func (m *mockVoiceConnection) ReceiveAudio(callback func(userID string, opusPCM []byte)) {
+	for _, p := range m.packets {
+		callback(p.userID, p.opus)
+	}
+	if m.done != nil {
+		close(m.done)
+	}
 }
 
-type mockWebhookSender struct{}
+type mockWebhookSender struct {
+	mu       sync.Mutex
+	payloads []webhook.TranscriptWebhookPayload
+}
 
-func (m *mockWebhookSender) SendTranscript(_ context.Context, _ webhook.TranscriptWebhookPayload) error {
+func (m *mockWebhookSender) SendTranscript(_ context.Context, payload webhook.TranscriptWebhookPayload) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.payloads = append(m.payloads, payload)
 	return nil
 }
 
@@ -168,6 +616,85 @@ func (m *mockMixer) WriteOpusPacket(_ string, _ []byte) {}
 func (m *mockMixer) ReadMixedPCM(_ []byte) (int, error) { return 0, nil }
 func (m *mockMixer) Close()                             {}
 
+type mockTranslator struct {
+	err   error
+	calls int
+}
+
+func (m *mockTranslator) Translate(_ context.Context, text, _, targetLanguage string) (string, error) {
+	m.calls++
+	if m.err != nil {
+		return "", m.err
+	}
+	return "[" + targetLanguage + "] " + text, nil
+}
+
+type mockSummarizer struct {
+	summary *summarizer.Summary
+	err     error
+	inputs  []string
+	// release が設定されている場合は閉じられるまで要約を返さない
+	release chan struct{}
+}
+
+func (m *mockSummarizer) Summarize(ctx context.Context, transcript string) (*summarizer.Summary, error) {
+	m.inputs = append(m.inputs, transcript)
+	if m.release != nil {
+		select {
+		case <-m.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.summary, nil
+}
+
+type mockArchive struct {
+	mu     sync.Mutex
+	bodies map[string][]byte
+}
+
+func (m *mockArchive) Create(sessionID string) (io.WriteCloser, error) {
+	return &mockArchiveWriter{archive: m, sessionID: sessionID}, nil
+}
+
+func (m *mockArchive) Open(sessionID string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	body, ok := m.bodies[sessionID]
+	if !ok {
+		return nil, audio.ErrArchiveNotFound
+	}
+	return io.NopCloser(bytes.NewReader(body)), nil
+}
+
+func (m *mockArchive) Delete(sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.bodies, sessionID)
+	return nil
+}
+
+type mockArchiveWriter struct {
+	archive   *mockArchive
+	sessionID string
+	buf       bytes.Buffer
+}
+
+func (w *mockArchiveWriter) Write(p []byte) (int, error) { return w.buf.Write(p) }
+func (w *mockArchiveWriter) Close() error {
+	w.archive.mu.Lock()
+	defer w.archive.mu.Unlock()
+	if w.archive.bodies == nil {
+		w.archive.bodies = make(map[string][]byte)
+	}
+	w.archive.bodies[w.sessionID] = w.buf.Bytes()
+	return nil
+}
+
 func newTestManager(repo repository.Repository, dc discord.Client) *Manager {
 	cfg := &config.Config{
 		DiscordGuildID:             "guild-1",
@@ -180,7 +707,7 @@ func newTestManager(repo repository.Repository, dc discord.Client) *Manager {
 		DiscordShowPoweredBy:       true,
 		Env:                        "test",
 	}
-	return NewManager(cfg, repo, dc, &mockTranscriber{}, &mockWebhookSender{}, func() audio.Mixer { return &mockMixer{} })
+	return NewManager(cfg, repo, dc, &mockTranscriber{}, &mockWebhookSender{}, func() audio.Mixer { return &mockMixer{} }, &mockArchive{}, &mockTranslator{}, &mockSummarizer{}, metrics.Nop)
 }
 
 func TestHandleVoiceStateUpdate_IgnoresOtherGuild(t *testing.T) {
@@ -208,9 +735,11 @@ func TestHandleTranscriptionResult_InsertsAndSendsOnlyFinalNonEmpty(t *testing.T
 	dc := &mockDiscordClient{}
 	manager := newTestManager(repo, dc)
 
-	manager.handleTranscriptionResult("session-1", "vc-1", 0, " ", true)
-	manager.handleTranscriptionResult("session-1", "vc-1", 0, "hello", false)
-	manager.handleTranscriptionResult("session-1", "vc-1", 1, "hello", true)
+	spokenAt := time.Date(2026, 2, 28, 12, 0, 5, 0, time.UTC)
+	endedAt := spokenAt.Add(2 * time.Second)
+	manager.handleTranscriptionResult("guild-1", "session-1", "vc-1", 0, transcriber.Result{Text: " ", IsFinal: true}, segmentTiming{spokenAt: spokenAt, endedAt: endedAt})
+	manager.handleTranscriptionResult("guild-1", "session-1", "vc-1", 0, transcriber.Result{Text: "hello"}, segmentTiming{spokenAt: spokenAt, endedAt: endedAt})
+	manager.handleTranscriptionResult("guild-1", "session-1", "vc-1", 1, transcriber.Result{Text: "hello", IsFinal: true}, segmentTiming{spokenAt: spokenAt, endedAt: endedAt})
 
 	if len(repo.insertCalls) != 1 {
 		t.Fatalf("expected one insert, got %d", len(repo.insertCalls))
@@ -219,8 +748,8 @@ func TestHandleTranscriptionResult_InsertsAndSendsOnlyFinalNonEmpty(t *testing.T
 	if got.SessionID != "session-1" || got.Content != "hello" || got.SegmentIndex != 1 {
 		t.Fatalf("unexpected insert payload: %+v", got)
 	}
-	if got.SpokenAt.IsZero() || got.SpokenAt.After(time.Now().Add(1*time.Second)) {
-		t.Fatalf("unexpected spoken_at: %v", got.SpokenAt)
+	if !got.SpokenAt.Equal(spokenAt) || !got.EndedAt.Equal(endedAt) {
+		t.Fatalf("unexpected segment times: %v - %v", got.SpokenAt, got.EndedAt)
 	}
 	if len(dc.sendCalls) != 1 || dc.sendCalls[0] != "hello" {
 		t.Fatalf("unexpected discord sends: %+v", dc.sendCalls)
@@ -254,8 +783,8 @@ func TestResultReceiver_OnResultUsesMonotonicIndex(t *testing.T) {
 		channelID: "vc-1",
 	}
 
-	receiver.OnResult(10, "first", true)
-	receiver.OnResult(99, "second", true)
+	receiver.OnResult(transcriber.Result{SegmentIndex: 10, Text: "first", IsFinal: true})
+	receiver.OnResult(transcriber.Result{SegmentIndex: 99, Text: "second", IsFinal: true})
 
 	if len(repo.insertCalls) != 2 {
 		t.Fatalf("expected two insert calls, got %d", len(repo.insertCalls))
@@ -352,6 +881,43 @@ func TestHandleSlashCommand_StartAndStopSuccess(t *testing.T) {
 	}
 }
 
+func TestHandleSlashCommand_RecordsStarterAndStopper(t *testing.T) {
+	repo := &mockRepository{}
+	dc := &mockDiscordClient{
+		userVoiceChannelByID: map[string]string{"user-1": "vc-1", "user-2": "vc-1"},
+	}
+	manager := newTestManager(repo, dc)
+	manager.SetBotUserID("bot-self")
+
+	for _, c := range []struct{ name, userID string }{{commandMojiokoshi, "user-1"}, {commandMojiokoshiStop, "user-2"}} {
+		manager.HandleSlashCommand(discord.SlashCommandEvent{
+			GuildID:          "guild-1",
+			CommandName:      c.name,
+			UserID:           c.userID,
+			RespondEphemeral: func(string) error { return nil },
+		})
+	}
+	waitUntil(t, time.Second, func() bool {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		return len(repo.savedOutputCalls) == 1
+	}, "expected session output to be saved")
+
+	if got := repo.savedOutputCalls[0].StoppedByUserID; got != "user-2" {
+		t.Fatalf("unexpected stopped_by_user_id: %q", got)
+	}
+	var payload webhook.TranscriptWebhookPayload
+	if err := json.Unmarshal(repo.savedOutputCalls[0].WebhookPayloadJSON, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.StartedByUserID != "user-1" || payload.StartTrigger != string(repository.StartTriggerSlash) || payload.StoppedByUserID != "user-2" {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+	if !strings.Contains(repo.savedOutputCalls[0].TranscriptText, transcriptStartTriggerLabel+"スラッシュコマンド") {
+		t.Fatalf("expected start trigger in header: %q", repo.savedOutputCalls[0].TranscriptText)
+	}
+}
+
 func TestShouldCountLifecycleParticipant_ExcludesSelfBotAlways(t *testing.T) {
 	repo := &mockRepository{}
 	dc := &mockDiscordClient{botUserID: "bot-self"}
@@ -516,13 +1082,14 @@ func TestPoweredByShownOnlyOnStartAndAttachment(t *testing.T) {
 	dc := &mockDiscordClient{}
 	manager := newTestManager(repo, dc)
 
-	if !strings.Contains(manager.startChannelMessage(), messagePoweredByLine) {
+	rs := &runningSession{repoSession: &repository.Session{ID: "session-1", StartedAt: time.Now()}, startedByUserID: "user-1"}
+	if !strings.Contains(manager.startChannelEmbed(rs, "vc-1", false).Embeds[0].Description, messagePoweredByLine) {
 		t.Fatal("expected powered by line on start channel message")
 	}
-	if !strings.Contains(manager.transcriptAttachmentMessage(), messagePoweredByLine) {
+	if !strings.Contains(manager.transcriptAttachmentEmbed(transcriptSource{}).Description, messagePoweredByLine) {
 		t.Fatal("expected powered by line on attachment message")
 	}
-	if strings.Contains(manager.stopChannelMessage(stopReasonManualSlash), messagePoweredByLine) {
+	if strings.Contains(manager.stopChannelEmbed(rs, "vc-1", stopReasonManualSlash, time.Now()).Embeds[0].Description, messagePoweredByLine) {
 		t.Fatal("did not expect powered by line on stop channel message")
 	}
 	if strings.Contains(manager.startEphemeralMessage("vc-1"), messagePoweredByLine) {
@@ -568,11 +1135,11 @@ func TestHandleVoiceStateUpdate_BotRemovedStopsSession(t *testing.T) {
 
 	waitUntil(t, time.Second, func() bool { return !manager.isSessionRunning("guild-1", "vc-1") }, "session should stop when bot is removed")
 	waitUntil(t, time.Second, func() bool { return len(dc.fileCalls) == 1 }, "finalize should attach transcript after bot removal")
-	if len(dc.sendCalls) == 0 {
+	if len(dc.embedCalls) == 0 {
 		t.Fatal("expected stop message to be sent")
 	}
-	if dc.sendCalls[0] != manager.stopChannelMessage(stopReasonBotRemoved) {
-		t.Fatalf("unexpected stop message: %q", dc.sendCalls[0])
+	if !isStopEmbed(dc.embedCalls[0], stopReasonBotRemoved) {
+		t.Fatalf("unexpected stop message: %+v", dc.embedCalls[0])
 	}
 }
 
@@ -639,11 +1206,11 @@ func TestRunSessionWorker_PanicStopsWithUnknownReasonAndSendsAttachment(t *testi
 
 	waitUntil(t, time.Second, func() bool { return !manager.isSessionRunning("guild-1", "vc-1") }, "session should stop after worker panic")
 	waitUntil(t, time.Second, func() bool { return len(dc.fileCalls) == 1 }, "finalize should attach transcript after worker panic")
-	if len(dc.sendCalls) == 0 {
+	if len(dc.embedCalls) == 0 {
 		t.Fatal("expected stop message to be sent")
 	}
-	if dc.sendCalls[0] != manager.stopChannelMessage(stopReasonUnknownError) {
-		t.Fatalf("unexpected stop message: %q", dc.sendCalls[0])
+	if !isStopEmbed(dc.embedCalls[0], stopReasonUnknownError) {
+		t.Fatalf("unexpected stop message: %+v", dc.embedCalls[0])
 	}
 }
 
@@ -702,9 +1269,9 @@ func TestFinalizeSession_SendsStopMessageBeforeSegmentLookup(t *testing.T) {
 		t.Fatal("expected session to be stopped")
 	}
 
-	waitUntil(t, 300*time.Millisecond, func() bool { return len(dc.sendCalls) >= 1 }, "expected stop message to be sent immediately")
-	if dc.sendCalls[0] != manager.stopChannelMessage(stopReasonServerClosed) {
-		t.Fatalf("unexpected stop message: %q", dc.sendCalls[0])
+	waitUntil(t, 300*time.Millisecond, func() bool { return len(dc.embedCalls) >= 1 }, "expected stop message to be sent immediately")
+	if !isStopEmbed(dc.embedCalls[0], stopReasonServerClosed) {
+		t.Fatalf("unexpected stop message: %+v", dc.embedCalls[0])
 	}
 	waitUntil(t, time.Second, func() bool { return len(dc.fileCalls) == 1 }, "expected attachment after segment lookup timeout")
 }
@@ -773,6 +1340,18 @@ func TestFinalizeSession_AttachmentContainsFallbackNoticeWhenSegmentsUnavailable
 	}
 }
 
+func isStopEmbed(msg discord.EmbedMessage, reason string) bool {
+	if len(msg.Embeds) != 1 || msg.Embeds[0].Title != messageStopChannelTitle {
+		return false
+	}
+	for _, f := range msg.Embeds[0].Fields {
+		if f.Name == messageEmbedFieldStopReason {
+			return f.Value == stopReasonDetail(reason)
+		}
+	}
+	return false
+}
+
 func waitUntil(t *testing.T, timeout time.Duration, cond func() bool, message string) {
 	t.Helper()
 	deadline := time.Now().Add(timeout)
@@ -784,3 +1363,46 @@ func waitUntil(t *testing.T, timeout time.Duration, cond func() bool, message st
 	}
 	t.Fatal(message)
 }
+
+type mockRecorder struct {
+	metrics.Recorder
+	mu      sync.Mutex
+	started []string
+	stopped []string
+}
+
+func (r *mockRecorder) SessionStarted(guildID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.started = append(r.started, guildID)
+}
+
+func (r *mockRecorder) SessionStopped(guildID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopped = append(r.stopped, guildID)
+}
+
+func TestMetrics_RecordsSessionStartAndStop(t *testing.T) {
+	repo := &mockRepository{}
+	dc := &mockDiscordClient{userVoiceChannelByID: map[string]string{"user-1": "vc-1"}}
+	manager := newTestManager(repo, dc)
+	recorder := &mockRecorder{Recorder: metrics.Nop}
+	manager.metrics = recorder
+	manager.SetBotUserID("bot-self")
+
+	for _, name := range []string{commandMojiokoshi, commandMojiokoshiStop} {
+		manager.HandleSlashCommand(discord.SlashCommandEvent{
+			GuildID:          "guild-1",
+			CommandName:      name,
+			UserID:           "user-1",
+			RespondEphemeral: func(string) error { return nil },
+		})
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if !slices.Equal(recorder.started, []string{"guild-1"}) || !slices.Equal(recorder.stopped, []string{"guild-1"}) {
+		t.Fatalf("unexpected session metrics: started=%v stopped=%v", recorder.started, recorder.stopped)
+	}
+}
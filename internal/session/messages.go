@@ -1,11 +1,76 @@
 package session
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+)
+
+// Discord のメッセージ上限 2000 文字に収まるように一覧を切り詰める
+const maxListMessageLength = 1800
 
 const (
 	slashCommandStartDescription = "あなたがいるボイスチャンネルで文字起こしを開始します。"
 	slashCommandStopDescription  = "あなたがいるボイスチャンネルの文字起こしを中止します。"
 
+	slashOptionVADDescription = "無音区間を音声認識に送らないようにするか（省略時はサーバーの既定値）"
+	slashChoiceVADOn          = "有効"
+	slashChoiceVADOff         = "無効"
+
+	slashOptionLanguagesDescription = "話される言語の候補（カンマ区切りで3つまで、例: ja-JP,en-US。省略時はサーバーの設定）"
+
+	slashCommandRetranscribeDescription = "保存された音声から、終了したセッションの文字起こしをやり直します。"
+	slashOptionSessionIDDescription     = "対象のセッションID"
+	slashOptionLanguageDescription      = "文字起こしの言語コード（カンマ区切りで3つまで、例: ja-JP,en-US）"
+	slashOptionModelDescription         = "音声認識モデル名（省略時は既定のモデル）"
+
+	slashCommandPhrasesDescription = "音声認識で優先して認識させる語句を管理します。"
+	slashOptionActionDescription   = "操作"
+	slashOptionPhraseDescription   = "対象の語句（追加・削除時に指定）"
+	slashChoiceActionAdd           = "追加"
+	slashChoiceActionRemove        = "削除"
+	slashChoiceActionList          = "一覧"
+
+	slashCommandReplaceDescription    = "文字起こし結果に適用する置換ルールを管理します。"
+	slashOptionPatternDescription     = "置換する語句または正規表現（追加・削除時に指定）"
+	slashOptionReplacementDescription = "置換後の文字列（追加時に指定、省略すると語句を削除）"
+	slashOptionRegexDescription       = "語句を正規表現として扱うか（省略時は文字列）"
+	slashChoiceRegexOn                = "正規表現"
+	slashChoiceRegexOff               = "文字列"
+
+	slashCommandSettingsDescription = "このサーバーの音声認識と文字起こしの整形の設定を表示・変更します。"
+	slashOptionFeatureDescription   = "変更する設定（省略すると現在の設定を表示）"
+	slashOptionValueDescription     = "有効にするか無効にするか"
+	slashChoiceSettingOn            = "有効"
+	slashChoiceSettingOff           = "無効"
+
+	slashOptionGuildLanguagesDescription = "話される言語の候補（カンマ区切りで3つまで、default で既定に戻す）"
+
+	slashCommandSubscribeDescription     = "参加したセッションの文字起こしを終了時に DM で受け取るかを設定します。"
+	slashOptionSubscribeValueDescription = "受け取るかどうか（省略すると現在の設定を表示）"
+
+	slashCommandOptOutDescription     = "このサーバーの文字起こしにあなたの音声を使わないようにします。"
+	slashCommandOptInDescription      = "このサーバーの文字起こしにあなたの音声を使うことに同意します。"
+	slashOptionConsentModeDescription = "録音に同意していない参加者の扱い"
+	slashChoiceConsentAnnounce        = "案内して文字起こしする"
+	slashChoiceConsentExclude         = "同意した人のみ文字起こしする"
+
+	slashCommandDeleteDescription             = "参加したセッションの文字起こしと保存した音声を削除します。"
+	slashOptionTranscriptRetentionDescription = "文字起こしを保存する日数（0 で無期限）"
+	slashOptionArtifactRetentionDescription   = "添付ファイル・要約・音声を保存する日数（0 で無期限）"
+
+	slashCommandAuditDescription         = "文字起こしの開始・中止や設定変更などの操作の記録を表示します。"
+	slashOptionAuditSessionIDDescription = "絞り込むセッションID"
+	slashOptionAuditUserDescription      = "絞り込む操作した人（メンションかユーザーID）"
+
+	slashOptionStartRolesDescription     = "文字起こしを開始できるロール（メンションかIDを空白区切り、none で全員）"
+	slashOptionModeratorRolesDescription = "中止と設定の変更をいつでもできるロール（メンションかIDを空白区切り、none で解除）"
+	slashOptionStopPolicyDescription     = "実行中の文字起こしを中止できるメンバー"
+	slashChoiceStopParticipants          = "参加者全員"
+	slashChoiceStopStarter               = "開始した人のみ"
+	slashChoiceStopModerators            = "モデレーターのみ"
+
 	messageEphemeralWrongGuild        = ":warning: **このサーバーでは実行できません。**"
 	messageEphemeralUnknownCommand    = ":warning: **不明なコマンドです。**"
 	messageEphemeralVoiceLookupFailed = ":warning: **ボイスチャンネルの参加状態の確認に失敗しました。**"
@@ -14,16 +79,146 @@ const (
 	messageEphemeralStartFailed       = ":warning: **文字起こしの開始に失敗しました。**"
 	messageEphemeralStopFailed        = ":warning: **文字起こしの停止に失敗しました。**"
 	messageEphemeralNotRunning        = ":warning: **現在このボイスチャンネルでは文字起こしは実行されていません。**"
-	messagePoweredByLine              = "-# *Powered by [Mojiokoshin](https://github.com/foxseedlab/mojiokoshin)*"
 
-	messageStartChannelTitle = ":microphone2: **文字起こしを開始しました。**"
-	messageStartChannelHint  = "-# /mojiokoshi-stop コマンドで中止できます。"
+	messageEphemeralSessionNotFound         = ":warning: **指定されたセッションが見つかりません。**"
+	messageEphemeralSessionStillRunning     = ":warning: **実行中のセッションは再文字起こしできません。**"
+	messageEphemeralRetranscribeInProgress  = ":warning: **このセッションは既に再文字起こし中です。**"
+	messageEphemeralAudioNotArchived        = ":warning: **このセッションの音声は保存されていません。**"
+	messageEphemeralRetranscribeFailed      = ":warning: **再文字起こしの開始に失敗しました。**"
+	messageEphemeralRetranscribeNotAllowed  = ":no_entry: **このセッションの参加者とモデレーターのみ再文字起こしできます。**"
+	messageEphemeralNotTranscriptMessage    = ":warning: **このメッセージは修正できる文字起こしではありません。**"
+	messageEphemeralSessionPurged           = ":warning: **保存期間を過ぎて文字起こしが削除されたセッションは変更できません。**"
+	messageEphemeralSegmentSuperseded       = ":warning: **この文字起こしは再文字起こしで置き換えられているため修正できません。**"
+	messageEphemeralNotSessionParticipant   = ":warning: **このセッションの参加者のみ修正できます。**"
+	messageEphemeralCorrectionEmpty         = ":warning: **修正後のテキストを入力してください。**"
+	messageEphemeralCorrectionTooLong       = ":warning: **修正後のテキストが長すぎます。**"
+	messageEphemeralCorrectionFailed        = ":warning: **文字起こしの修正に失敗しました。**"
+	messageEphemeralCorrectionSaved         = ":pencil2: **文字起こしを修正しました。**"
+	messageEphemeralPhraseInvalid           = ":warning: **語句は1文字以上100文字以内で指定してください。**"
+	messageEphemeralPhraseLimitReached      = ":warning: **登録できる語句の上限に達しています。**"
+	messageEphemeralPhraseFailed            = ":warning: **語句の更新に失敗しました。**"
+	messageEphemeralReplacementInvalid      = ":warning: **置換ルールが正しくありません。200文字以内の語句か、正しい書式の正規表現を指定してください。**"
+	messageEphemeralReplacementLimitReached = ":warning: **登録できる置換ルールの上限に達しています。**"
+	messageEphemeralReplacementFailed       = ":warning: **置換ルールの更新に失敗しました。**"
+	messageEphemeralSettingsFailed          = ":warning: **設定の更新に失敗しました。**"
+	messageEphemeralLanguagesInvalid        = ":warning: **言語コードが正しくありません。ja-JP のような言語コードを3つまで指定してください。**"
+	messageEphemeralNotSessionOperator      = ":warning: **このセッションの参加者のみ操作できます。**"
+	messageEphemeralSessionAlreadyStopped   = ":information_source: **このセッションの文字起こしは既に終了しています。**"
+	messageEphemeralTranscriptNotReady      = ":hourglass: **文字起こしの内容を準備しています。しばらくしてからもう一度お試しください。**"
+	messageEphemeralTranscriptLoadFailed    = ":warning: **文字起こしの内容の取得に失敗しました。**"
+	messageEphemeralTranscriptSentDM        = ":incoming_envelope: **文字起こしの内容を DM に送信しました。**"
+	messageEphemeralTranscriptDMFailed      = ":warning: **DM を送信できませんでした。DM の受信設定を確認してください。**"
+	messageEphemeralSubscribed              = ":incoming_envelope: **参加したセッションの文字起こしを終了時に DM で受け取ります。**"
+	messageEphemeralUnsubscribed            = ":no_bell: **文字起こしを DM で受け取らないようにしました。**"
+	messageEphemeralSubscriptionStatusOn    = ":incoming_envelope: **参加したセッションの文字起こしを DM で受け取る設定です。**"
+	messageEphemeralSubscriptionStatusOff   = ":no_bell: **文字起こしを DM で受け取らない設定です。**"
+	messageEphemeralSubscriptionFailed      = ":warning: **DM での受け取りの設定に失敗しました。**"
+	messageEphemeralOptedOut                = ":no_entry_sign: **このサーバーの文字起こしにあなたの音声を使わないようにしました。**"
+	messageEphemeralOptedIn                 = ":white_check_mark: **このサーバーの文字起こしにあなたの音声を使うことに同意しました。**"
+	messageEphemeralConsentFailed           = ":warning: **録音の同意の設定に失敗しました。**"
+	messageEphemeralConsentModeInvalid      = ":warning: **録音の同意の扱いが正しくありません。**"
+	messageEphemeralRetentionInvalid        = ":warning: **保存期間は0から3650までの日数で指定してください。**"
+	messageEphemeralDeleteStillRunning      = ":warning: **実行中のセッションは削除できません。**"
+	messageEphemeralDeleteFailed            = ":warning: **セッションの削除に失敗しました。**"
+	messageEphemeralSettingsUnavailable     = ":warning: **サーバーの設定を読み込めなかったため開始できません。時間をおいて再度お試しください。**"
+	messageEphemeralStartNotAllowed         = ":no_entry: **文字起こしを開始する権限がありません。**"
+	messageEphemeralStopNotAllowed          = ":no_entry: **この文字起こしを中止する権限がありません。**"
+	messageEphemeralAdminOnly               = ":no_entry: **このコマンドはサーバーの管理者とモデレーターのみ実行できます。**"
+	messageEphemeralRolesInvalid            = ":warning: **ロールはメンションかロールIDで指定してください（none で指定を解除）。**"
+	messageEphemeralStopPolicyInvalid       = ":warning: **中止できるメンバーの指定が正しくありません。**"
+	messageEphemeralUserInvalid             = ":warning: **ユーザーはメンションかユーザーIDで指定してください。**"
+	messageEphemeralAuditFailed             = ":warning: **操作の記録の取得に失敗しました。**"
+	messagePoweredByLine                    = "-# *Powered by [Mojiokoshin](https://github.com/foxseedlab/mojiokoshin)*"
 
-	messageStopChannelTitle = ":pause_button:  **文字起こしを中止しました。**"
+	messageStartChannelTitle = ":microphone2: 文字起こしを開始しました。"
+	messageStartChannelHint  = "-# 下のボタンか /mojiokoshi-stop コマンドで中止できます。"
+
+	messageStopChannelTitle = ":pause_button: 文字起こしを中止しました。"
 	messageStopRestart      = "/mojiokoshi コマンドで開始できます。"
 	messageStopRestartAgain = "/mojiokoshi コマンドで再度開始できます。"
 
-	messageAttachmentTitle = ":page_facing_up:  **文字起こしの内容**"
+	messageAttachmentTitle = ":page_facing_up: 文字起こしの内容"
+
+	messageEmbedFieldChannel      = "チャンネル"
+	messageEmbedFieldStartedBy    = "開始した人"
+	messageEmbedFieldDuration     = "通話時間"
+	messageEmbedFieldParticipants = "参加者"
+	messageEmbedFieldSegmentCount = "発言数"
+	messageEmbedFieldStopReason   = "終了理由"
+	messageEmbedFieldNone         = "なし"
+	messageEmbedFieldConsent      = "録音の同意"
+	messageEmbedFieldExcluded     = "文字起こしの対象外"
+	messageEmbedFieldUnconfirmed  = "同意を確認していない参加者"
+
+	messageConsentPolicyAnnounce     = "参加者の音声を文字起こしします。文字起こしされたくない場合は /mojiokoshi-optout を実行してください。"
+	messageConsentPolicyExclude      = "/mojiokoshi-optin で同意した参加者の音声のみ文字起こしします。"
+	messageConsentJoinAnnounceFormat = ":information_source: <@%s> このボイスチャンネルは文字起こし中です。文字起こしされたくない場合は /mojiokoshi-optout を実行してください。"
+	messageConsentJoinExcludeFormat  = ":information_source: <@%s> このボイスチャンネルは文字起こし中です。/mojiokoshi-optin で同意するまで、あなたの音声は文字起こしされません。"
+
+	messageButtonStop               = "中止"
+	messageButtonDownloadTranscript = "文字起こしをダウンロード"
+	messageButtonResendTranscriptDM = "DM に再送"
+
+	messageTranscriptDMFormat = ":page_facing_up: <#%s> の文字起こしの内容"
+
+	messageRetranscribeAttachmentTitleFormat = ":page_facing_up:  **再文字起こしの内容（リビジョン %d）**"
+	messageRetranscribeFailedChannel         = ":warning: **再文字起こしに失敗しました。**"
+	messageRetranscribeEphemeralTitle        = ":arrows_counterclockwise: **再文字起こしを開始しました。**"
+	messageRetranscribeEphemeralHint         = "-# 完了するとボイスチャンネルのチャットに結果が投稿されます。"
+
+	messagePhraseAddedFormat         = ":white_check_mark: **「%s」を追加しました。**"
+	messagePhraseAlreadyExistsFormat = ":information_source: **「%s」は既に登録されています。**"
+	messagePhraseRemovedFormat       = ":wastebasket: **「%s」を削除しました。**"
+	messagePhraseNotFoundFormat      = ":warning: **「%s」は登録されていません。**"
+	messagePhraseListTitleFormat     = ":books: **登録されている語句（%d件）**"
+	messageListRestFormat            = " ほか%d件"
+	messagePhraseListEmpty           = ":books: **登録されている語句はありません。**"
+	messagePhraseListHint            = "-# ボイスチャンネル参加者の表示名は登録しなくても認識対象に含まれます。"
+
+	messageReplacementSavedFormat     = ":white_check_mark: **「%s」→「%s」の置換ルールを保存しました。**"
+	messageReplacementRemovedFormat   = ":wastebasket: **「%s」の置換ルールを削除しました。**"
+	messageReplacementNotFoundFormat  = ":warning: **「%s」の置換ルールは登録されていません。**"
+	messageReplacementListTitleFormat = ":books: **登録されている置換ルール（%d件）**"
+	messageReplacementListLineFormat  = "- `%s` → %s"
+	messageReplacementListEmpty       = ":books: **登録されている置換ルールはありません。**"
+	messageReplacementRegexSuffix     = "（正規表現）"
+	messageReplacementDeleted         = "（削除）"
+
+	messageSettingsTitle            = ":gear: **文字起こしの設定**"
+	messageSettingsUpdated          = ":white_check_mark: **設定を変更しました。**"
+	messageSettingsLineFormat       = "- %s: %s"
+	messageSettingsEnabled          = "有効"
+	messageSettingsDisabled         = "無効"
+	messageSettingsHint             = "-# 音声認識の設定は次に開始する文字起こしから反映されます。"
+	messageSettingsLanguagesLabel   = "認識する言語"
+	messageSettingsLanguagesDefault = "既定（%s）"
+	messageSettingsConsentModeLabel = "録音に同意していない参加者"
+
+	messageSettingsTranscriptRetentionLabel = "文字起こしの保存期間"
+	messageSettingsArtifactRetentionLabel   = "添付ファイルと音声の保存期間"
+	messageSettingsRetentionDaysFormat      = "%d日"
+	messageSettingsRetentionForever         = "無期限"
+
+	messageSettingsStartRolesLabel     = "開始できるロール"
+	messageSettingsModeratorRolesLabel = "モデレーターのロール"
+	messageSettingsStopPolicyLabel     = "中止できるメンバー"
+	messageSettingsRolesEveryone       = "全員"
+	messageSettingsRolesAdminsOnly     = "なし（サーバーの管理者のみ）"
+
+	messageAuditListTitle   = ":scroll: **操作の記録（新しい順）**"
+	messageAuditListEmpty   = ":scroll: **操作の記録はありません。**"
+	messageAuditActorSystem = "自動"
+
+	messageSessionDeletedFormat = ":wastebasket: **セッション `%s` のデータを削除しました。**"
+
+	messageTranslationLineFormat = "-# :globe_with_meridians: %s: %s"
+
+	messageSummaryTitle       = ":memo: 要約"
+	messageSummaryDecisions   = "決定事項"
+	messageSummaryActionItems = "アクションアイテム"
+
+	messageFixTranscriptModalTitle = "文字起こしを修正"
+	messageFixTranscriptInputLabel = "修正後のテキスト"
 
 	messageStartEphemeralTitleFormat = ":microphone2: <#%s> **の文字起こしを開始しました。**"
 	messageStopEphemeralTitleFormat  = ":pause_button:  <#%s> **の文字起こしを中止しました。**"
@@ -41,12 +236,197 @@ func stopEphemeralTitle(channelID string) string {
 	return fmt.Sprintf(messageStopEphemeralTitleFormat, channelID)
 }
 
+func retranscribeAttachmentTitle(revision int) string {
+	return fmt.Sprintf(messageRetranscribeAttachmentTitleFormat, revision)
+}
+
+func phraseAddedMessage(phrase string) string {
+	return fmt.Sprintf(messagePhraseAddedFormat, phrase)
+}
+
+func phraseAlreadyExistsMessage(phrase string) string {
+	return fmt.Sprintf(messagePhraseAlreadyExistsFormat, phrase)
+}
+
+func phraseRemovedMessage(phrase string) string {
+	return fmt.Sprintf(messagePhraseRemovedFormat, phrase)
+}
+
+func phraseNotFoundMessage(phrase string) string {
+	return fmt.Sprintf(messagePhraseNotFoundFormat, phrase)
+}
+
+func phraseListTitle(count int) string {
+	return fmt.Sprintf(messagePhraseListTitleFormat, count)
+}
+
+func replacementSavedMessage(pattern, replacement string) string {
+	return fmt.Sprintf(messageReplacementSavedFormat, pattern, replacement)
+}
+
+func replacementRemovedMessage(pattern string) string {
+	return fmt.Sprintf(messageReplacementRemovedFormat, pattern)
+}
+
+func replacementNotFoundMessage(pattern string) string {
+	return fmt.Sprintf(messageReplacementNotFoundFormat, pattern)
+}
+
+func replacementListTitle(count int) string {
+	return fmt.Sprintf(messageReplacementListTitleFormat, count)
+}
+
+func replacementListLine(rule repository.ReplacementRule) string {
+	replacement := rule.Replacement
+	if replacement == "" {
+		replacement = messageReplacementDeleted
+	}
+	line := fmt.Sprintf(messageReplacementListLineFormat, rule.Pattern, replacement)
+	if rule.IsRegex {
+		line += messageReplacementRegexSuffix
+	}
+	return line
+}
+
+func guildSettingLine(feature string, enabled bool) string {
+	state := messageSettingsDisabled
+	if enabled {
+		state = messageSettingsEnabled
+	}
+	return fmt.Sprintf(messageSettingsLineFormat, guildFeatureLabel(feature), state)
+}
+
+func guildLanguagesLine(languages []string, defaultLanguage string) string {
+	value := fmt.Sprintf(messageSettingsLanguagesDefault, defaultLanguage)
+	if len(languages) > 0 {
+		value = strings.Join(languages, ", ")
+	}
+	return fmt.Sprintf(messageSettingsLineFormat, messageSettingsLanguagesLabel, value)
+}
+
+func guildConsentModeLine(mode repository.ConsentMode) string {
+	value := slashChoiceConsentAnnounce
+	if mode == repository.ConsentModeExclude {
+		value = slashChoiceConsentExclude
+	}
+	return fmt.Sprintf(messageSettingsLineFormat, messageSettingsConsentModeLabel, value)
+}
+
+func guildRolesLine(label string, roleIDs []string, empty string) string {
+	value := empty
+	if len(roleIDs) > 0 {
+		mentions := make([]string, 0, len(roleIDs))
+		for _, roleID := range roleIDs {
+			mentions = append(mentions, "<@&"+roleID+">")
+		}
+		value = strings.Join(mentions, " ")
+	}
+	return fmt.Sprintf(messageSettingsLineFormat, label, value)
+}
+
+func guildStopPolicyLine(policy repository.StopPolicy) string {
+	value := slashChoiceStopParticipants
+	switch policy {
+	case repository.StopPolicyStarter:
+		value = slashChoiceStopStarter
+	case repository.StopPolicyModerators:
+		value = slashChoiceStopModerators
+	}
+	return fmt.Sprintf(messageSettingsLineFormat, messageSettingsStopPolicyLabel, value)
+}
+
+func guildRetentionLine(label string, days int) string {
+	value := messageSettingsRetentionForever
+	if days > 0 {
+		value = fmt.Sprintf(messageSettingsRetentionDaysFormat, days)
+	}
+	return fmt.Sprintf(messageSettingsLineFormat, label, value)
+}
+
+func sessionDeletedMessage(sessionID string) string {
+	return fmt.Sprintf(messageSessionDeletedFormat, strings.TrimSpace(sessionID))
+}
+
+func startTriggerLabel(trigger repository.StartTrigger) string {
+	switch trigger {
+	case repository.StartTriggerSlash:
+		return "スラッシュコマンド"
+	case repository.StartTriggerAuto:
+		return "自動"
+	default:
+		return string(trigger)
+	}
+}
+
+func auditActionLabel(action repository.AuditAction) string {
+	switch action {
+	case repository.AuditActionSessionStart:
+		return "開始"
+	case repository.AuditActionSessionStop:
+		return "中止"
+	case repository.AuditActionConfigUpdate:
+		return "設定変更"
+	case repository.AuditActionSessionDelete:
+		return "削除"
+	case repository.AuditActionRetentionPurge:
+		return "保存期間による削除"
+	case repository.AuditActionUserErase:
+		return "ユーザーデータの消去"
+	default:
+		return string(action)
+	}
+}
+
+func guildFeatureLabel(feature string) string {
+	switch feature {
+	case featureAutomaticPunctuation:
+		return "句読点の自動挿入"
+	case featureSpokenPunctuation:
+		return "読み上げた句読点の変換"
+	case featureProfanityFilter:
+		return "不適切な語句のフィルタ"
+	case featureNumberNormalization:
+		return "数字の半角統一"
+	case featureTextNormalization:
+		return "全角・半角と空白の整形"
+	default:
+		return feature
+	}
+}
+
+func transcriptDMMessage(channelID string) string {
+	return fmt.Sprintf(messageTranscriptDMFormat, channelID)
+}
+
+func joinListWithinLimit(items []string, sep string) string {
+	return joinListWithin(items, sep, maxListMessageLength)
+}
+
+func joinListWithin(items []string, sep string, limit int) string {
+	listed := make([]string, 0, len(items))
+	length := 0
+	for _, item := range items {
+		length += len(item) + len(sep)
+		if length > limit {
+			break
+		}
+		listed = append(listed, item)
+	}
+	body := strings.Join(listed, sep)
+	if rest := len(items) - len(listed); rest > 0 {
+		body += fmt.Sprintf(messageListRestFormat, rest)
+	}
+	return body
+}
+
 func stopReasonDetail(reason string) string {
 	switch reason {
 	case stopReasonMaxDuration:
 		return "文字起こしの最大制限時間に到達しました。"
 	case stopReasonManualSlash:
 		return "参加者に終了コマンドを実行されました。"
+	case stopReasonManualButton:
+		return "参加者に中止ボタンを押されました。"
 	case stopReasonParticipantsLeft:
 		return "ボイスチャットに誰もいなくなりました。"
 	case stopReasonBotRemoved:
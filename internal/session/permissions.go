@@ -0,0 +1,206 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+)
+
+// ロールの指定を空にする
+const rolesResetKeyword = "none"
+
+var (
+	ErrInvalidRoles      = errors.New("invalid role ids")
+	ErrInvalidStopPolicy = errors.New("invalid stop policy")
+	ErrNotAllowedToStop  = errors.New("member is not allowed to stop the session")
+)
+
+// <@&ロールID> の形式のメンションと、ロールIDそのものを受け付ける
+var roleIDPattern = regexp.MustCompile(`^(?:<@&)?([0-9]{1,20})>?$`)
+
+// 設定を変更するコマンド。サーバーの管理権限を持つメンバーとモデレーターのみ実行できる
+var guildAdminCommands = map[string]struct{}{
+	commandMojiokoshiSettings: {},
+	commandMojiokoshiPhrases:  {},
+	commandMojiokoshiReplace:  {},
+	commandMojiokoshiAudit:    {},
+}
+
+type member struct {
+	userID         string
+	roleIDs        []string
+	canManageGuild bool
+}
+
+func slashCommandMember(event discord.SlashCommandEvent) member {
+	return member{userID: event.UserID, roleIDs: event.RoleIDs, canManageGuild: event.CanManageGuild}
+}
+
+func componentMember(event discord.ComponentEvent) member {
+	return member{userID: event.UserID, roleIDs: event.RoleIDs, canManageGuild: event.CanManageGuild}
+}
+
+func (mb member) hasAnyRole(roleIDs []string) bool {
+	for _, roleID := range mb.roleIDs {
+		if slices.Contains(roleIDs, roleID) {
+			return true
+		}
+	}
+	return false
+}
+
+func isModerator(settings repository.GuildSettings, mb member) bool {
+	return mb.canManageGuild || mb.hasAnyRole(settings.ModeratorRoleIDs)
+}
+
+func canStartSession(settings repository.GuildSettings, mb member) bool {
+	return len(settings.StartRoleIDs) == 0 || isModerator(settings, mb) || mb.hasAnyRole(settings.StartRoleIDs)
+}
+
+// startedByUserID は runningSession.stopOwnerUserID の値で、自動で開始したセッションでは空になる
+func canStopSession(settings repository.GuildSettings, mb member, startedByUserID string, participated bool) bool {
+	if isModerator(settings, mb) {
+		return true
+	}
+	switch stopPolicyOrDefault(settings.StopPolicy) {
+	case repository.StopPolicyModerators:
+		return false
+	case repository.StopPolicyStarter:
+		if startedByUserID != "" {
+			return mb.userID == startedByUserID
+		}
+	}
+	return participated
+}
+
+func stopPolicyOrDefault(policy repository.StopPolicy) repository.StopPolicy {
+	if policy == "" {
+		return repository.StopPolicyParticipants
+	}
+	return policy
+}
+
+func parseStopPolicy(value string) (repository.StopPolicy, error) {
+	switch policy := repository.StopPolicy(value); policy {
+	case repository.StopPolicyParticipants, repository.StopPolicyStarter, repository.StopPolicyModerators:
+		return policy, nil
+	default:
+		return "", ErrInvalidStopPolicy
+	}
+}
+
+// カンマまたは空白区切りのロールを解釈する。rolesResetKeyword の場合は空にする
+func parseRoleIDs(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+	if value == rolesResetKeyword {
+		return nil, nil
+	}
+	fields := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' || r == '　' })
+	if len(fields) == 0 {
+		return nil, ErrInvalidRoles
+	}
+	roleIDs := make([]string, 0, len(fields))
+	for _, field := range fields {
+		match := roleIDPattern.FindStringSubmatch(field)
+		if match == nil {
+			return nil, ErrInvalidRoles
+		}
+		if !slices.Contains(roleIDs, match[1]) {
+			roleIDs = append(roleIDs, match[1])
+		}
+	}
+	return roleIDs, nil
+}
+
+// 設定の読み込みに失敗した場合、開始はロールの制限を確認できないため拒否する。
+// 管理コマンドと再文字起こしは既定の設定で判定するため、モデレーターはサーバーの管理権限を持つメンバーのみになる
+func (m *Manager) authorizeSlashCommand(event discord.SlashCommandEvent) bool {
+	ctx := context.Background()
+	settings, loaded := m.guildSettingsBestEffort(ctx, event.GuildID)
+	mb := slashCommandMember(event)
+	switch {
+	case event.CommandName == commandMojiokoshi && !loaded:
+		slog.Warn("start command rejected because guild settings could not be loaded", "guild_id", event.GuildID, "user_id", event.UserID)
+		m.respondEphemeral(event, messageEphemeralSettingsUnavailable)
+		return false
+	case event.CommandName == commandMojiokoshi && !canStartSession(settings, mb):
+		slog.Info("start command rejected by role", "guild_id", event.GuildID, "user_id", event.UserID)
+		m.respondEphemeral(event, messageEphemeralStartNotAllowed)
+		return false
+	case isGuildAdminCommand(event.CommandName) && !isModerator(settings, mb):
+		slog.Info("admin command rejected", "guild_id", event.GuildID, "user_id", event.UserID, "command", event.CommandName)
+		m.respondEphemeral(event, messageEphemeralAdminOnly)
+		return false
+	case event.CommandName == commandMojiokoshiRetranscribe:
+		if err := m.authorizeRetranscription(ctx, settings, event); err != nil {
+			slog.Info("retranscription rejected", "error", err, "guild_id", event.GuildID, "user_id", event.UserID, "session_id", event.Option(optionSessionID))
+			m.respondEphemeral(event, retranscribeErrorMessage(err))
+			return false
+		}
+		return true
+	default:
+		return true
+	}
+}
+
+func isGuildAdminCommand(name string) bool {
+	_, ok := guildAdminCommands[name]
+	return ok
+}
+
+func (m *Manager) authorizeStop(ctx context.Context, guildID string, mb member, startedByUserID string, participated bool) error {
+	settings, _ := m.guildSettingsBestEffort(ctx, guildID)
+	if !canStopSession(settings, mb, startedByUserID, participated) {
+		return ErrNotAllowedToStop
+	}
+	return nil
+}
+
+func (m *Manager) SetGuildStopPolicy(ctx context.Context, guildID, value, userID string) (repository.GuildSettings, error) {
+	policy, err := parseStopPolicy(value)
+	if err != nil {
+		return repository.GuildSettings{}, err
+	}
+	return m.updateGuildSettings(ctx, guildID, userID, func(settings *repository.GuildSettings) {
+		settings.StopPolicy = policy
+	})
+}
+
+func (m *Manager) SetGuildStartRoles(ctx context.Context, guildID, value, userID string) (repository.GuildSettings, error) {
+	roleIDs, err := parseRoleIDs(value)
+	if err != nil {
+		return repository.GuildSettings{}, err
+	}
+	return m.updateGuildSettings(ctx, guildID, userID, func(settings *repository.GuildSettings) {
+		settings.StartRoleIDs = roleIDs
+	})
+}
+
+func (m *Manager) SetGuildModeratorRoles(ctx context.Context, guildID, value, userID string) (repository.GuildSettings, error) {
+	roleIDs, err := parseRoleIDs(value)
+	if err != nil {
+		return repository.GuildSettings{}, err
+	}
+	return m.updateGuildSettings(ctx, guildID, userID, func(settings *repository.GuildSettings) {
+		settings.ModeratorRoleIDs = roleIDs
+	})
+}
+
+func (m *Manager) updateGuildSettings(ctx context.Context, guildID, userID string, apply func(settings *repository.GuildSettings)) (repository.GuildSettings, error) {
+	settings, err := m.GuildSettings(ctx, guildID)
+	if err != nil {
+		return settings, err
+	}
+	apply(&settings)
+	settings.UpdatedByUserID = userID
+	if err := m.repo.SaveGuildSettings(ctx, settings); err != nil {
+		return settings, err
+	}
+	return settings, nil
+}
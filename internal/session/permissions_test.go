@@ -0,0 +1,220 @@
+package session
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+)
+
+func TestCanStopSession(t *testing.T) {
+	settings := repository.GuildSettings{ModeratorRoleIDs: []string{"mod"}}
+	starter := member{userID: "user-1"}
+	other := member{userID: "user-2"}
+	moderator := member{userID: "user-3", roleIDs: []string{"mod"}}
+	admin := member{userID: "user-4", canManageGuild: true}
+
+	tests := []struct {
+		name         string
+		policy       repository.StopPolicy
+		mb           member
+		startedBy    string
+		participated bool
+		want         bool
+	}{
+		{name: "participant", policy: repository.StopPolicyParticipants, mb: other, startedBy: "user-1", participated: true, want: true},
+		{name: "non-participant", policy: repository.StopPolicyParticipants, mb: other, startedBy: "user-1", want: false},
+		{name: "starter", policy: repository.StopPolicyStarter, mb: starter, startedBy: "user-1", participated: true, want: true},
+		{name: "not starter", policy: repository.StopPolicyStarter, mb: other, startedBy: "user-1", participated: true, want: false},
+		{name: "auto started", policy: repository.StopPolicyStarter, mb: other, participated: true, want: true},
+		{name: "moderator role", policy: repository.StopPolicyStarter, mb: moderator, startedBy: "user-1", want: true},
+		{name: "moderators only", policy: repository.StopPolicyModerators, mb: starter, startedBy: "user-1", participated: true, want: false},
+		{name: "guild manager", policy: repository.StopPolicyModerators, mb: admin, startedBy: "user-1", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings.StopPolicy = tt.policy
+			if got := canStopSession(settings, tt.mb, tt.startedBy, tt.participated); got != tt.want {
+				t.Fatalf("canStopSession() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRoleIDs(t *testing.T) {
+	got, err := parseRoleIDs("<@&111> 222,<@&111>")
+	if err != nil || !slices.Equal(got, []string{"111", "222"}) {
+		t.Fatalf("unexpected roles: %v %v", got, err)
+	}
+	if got, err := parseRoleIDs(rolesResetKeyword); err != nil || got != nil {
+		t.Fatalf("expected reset: %v %v", got, err)
+	}
+	for _, value := range []string{"moderators", "<@111>", "@everyone"} {
+		if _, err := parseRoleIDs(value); err == nil {
+			t.Fatalf("expected error for %q", value)
+		}
+	}
+}
+
+func TestHandleSlashCommand_StartRequiresRole(t *testing.T) {
+	repo := &mockRepository{guildSettings: map[string]repository.GuildSettings{
+		"guild-1": {GuildID: "guild-1", StartRoleIDs: []string{"recorder"}},
+	}}
+	dc := &mockDiscordClient{userVoiceChannelByID: map[string]string{"user-1": "vc-1"}}
+	manager := newTestManager(repo, dc)
+
+	var got string
+	event := discord.SlashCommandEvent{
+		GuildID:     "guild-1",
+		CommandName: commandMojiokoshi,
+		UserID:      "user-1",
+		RespondEphemeral: func(content string) error {
+			got = content
+			return nil
+		},
+	}
+	manager.HandleSlashCommand(event)
+	if got != messageEphemeralStartNotAllowed || manager.isSessionRunning("guild-1", "vc-1") {
+		t.Fatalf("expected start to be rejected: %q", got)
+	}
+}
+
+func TestHandleSlashCommand_StartRejectedWhenSettingsFailToLoad(t *testing.T) {
+	repo := &mockRepository{guildSettingsErr: errors.New("db down")}
+	dc := &mockDiscordClient{userVoiceChannelByID: map[string]string{"user-1": "vc-1"}}
+	manager := newTestManager(repo, dc)
+
+	var got string
+	manager.HandleSlashCommand(discord.SlashCommandEvent{
+		GuildID:        "guild-1",
+		CommandName:    commandMojiokoshi,
+		UserID:         "user-1",
+		CanManageGuild: true,
+		RespondEphemeral: func(content string) error {
+			got = content
+			return nil
+		},
+	})
+	if got != messageEphemeralSettingsUnavailable || manager.isSessionRunning("guild-1", "vc-1") {
+		t.Fatalf("expected start to be rejected: %q", got)
+	}
+}
+
+func TestHandleSlashCommand_RetranscribeAllowsModeratorRole(t *testing.T) {
+	manager, repo, _, _ := newRetranscribeTestManager(t, &scriptedTranscriber{}, nil)
+	manager.archive = &mockArchive{}
+	repo.guildSettings = map[string]repository.GuildSettings{
+		"guild-1": {GuildID: "guild-1", ModeratorRoleIDs: []string{"mod"}},
+	}
+
+	var got string
+	event := discord.SlashCommandEvent{
+		GuildID:     "guild-1",
+		CommandName: commandMojiokoshiRetranscribe,
+		UserID:      "user-9",
+		Options:     map[string]string{optionSessionID: "session-1"},
+		RespondEphemeral: func(content string) error {
+			got = content
+			return nil
+		},
+	}
+	manager.HandleSlashCommand(event)
+	if got != messageEphemeralRetranscribeNotAllowed {
+		t.Fatalf("expected non-participant to be rejected: %q", got)
+	}
+	event.RoleIDs = []string{"mod"}
+	manager.HandleSlashCommand(event)
+	if got != messageEphemeralAudioNotArchived {
+		t.Fatalf("expected moderator to pass authorization: %q", got)
+	}
+}
+
+func TestHandleSlashCommand_AdminCommandsRequireModerator(t *testing.T) {
+	repo := &mockRepository{guildSettings: map[string]repository.GuildSettings{
+		"guild-1": {GuildID: "guild-1", ModeratorRoleIDs: []string{"mod"}},
+	}}
+	manager := newTestManager(repo, &mockDiscordClient{})
+
+	var got string
+	event := settingsCommand(map[string]string{optionStopPolicy: string(repository.StopPolicyStarter)}, &got)
+	event.CanManageGuild = false
+	manager.HandleSlashCommand(event)
+	if got != messageEphemeralAdminOnly {
+		t.Fatalf("expected admin only response: %q", got)
+	}
+	event.RoleIDs = []string{"mod"}
+	manager.HandleSlashCommand(event)
+	if !strings.Contains(got, messageSettingsUpdated) || repo.guildSettings["guild-1"].StopPolicy != repository.StopPolicyStarter {
+		t.Fatalf("unexpected response for moderator: %q settings=%+v", got, repo.guildSettings["guild-1"])
+	}
+}
+
+func TestHandleSettingsCommand_Roles(t *testing.T) {
+	repo := &mockRepository{}
+	manager := newTestManager(repo, &mockDiscordClient{})
+
+	var got string
+	manager.HandleSlashCommand(settingsCommand(map[string]string{optionStartRoles: "<@&111> 222"}, &got))
+	if !slices.Equal(repo.guildSettings["guild-1"].StartRoleIDs, []string{"111", "222"}) || !strings.Contains(got, "<@&111> <@&222>") {
+		t.Fatalf("unexpected response: %q settings=%+v", got, repo.guildSettings["guild-1"])
+	}
+	manager.HandleSlashCommand(settingsCommand(map[string]string{optionStartRoles: rolesResetKeyword}, &got))
+	if len(repo.guildSettings["guild-1"].StartRoleIDs) != 0 {
+		t.Fatalf("expected roles to be cleared: %+v", repo.guildSettings["guild-1"])
+	}
+	manager.HandleSlashCommand(settingsCommand(map[string]string{optionModeratorRoles: "moderators"}, &got))
+	if got != messageEphemeralRolesInvalid {
+		t.Fatalf("unexpected response: %q", got)
+	}
+}
+
+func TestHandleComponent_StopButtonFollowsStopPolicy(t *testing.T) {
+	repo := &mockRepository{guildSettings: map[string]repository.GuildSettings{
+		"guild-1": {GuildID: "guild-1", StopPolicy: repository.StopPolicyStarter},
+	}}
+	manager := newTestManager(repo, &mockDiscordClient{})
+	manager.sessions[manager.sessionKey("guild-1", "vc-1")] = &runningSession{
+		repoSession:        &repository.Session{ID: "session-1", GuildID: "guild-1", ChannelID: "vc-1", StartedAt: time.Now(), Status: repository.SessionStatusRunning, StartTrigger: repository.StartTriggerSlash},
+		activeParticipants: map[string]participantState{"user-1": {}, "user-2": {}},
+		allParticipants:    map[string]participantState{"user-1": {}, "user-2": {}},
+		startedByUserID:    "user-1",
+	}
+	customID := sessionButtonCustomID(buttonActionStop, "session-1")
+
+	if got, _ := pressButton(manager, "user-2", customID); got != messageEphemeralStopNotAllowed {
+		t.Fatalf("unexpected response for non-starter: %q", got)
+	}
+	if !manager.isSessionRunning("guild-1", "vc-1") {
+		t.Fatal("expected session to keep running")
+	}
+	if got, _ := pressButton(manager, "user-1", customID); !strings.Contains(got, "vc-1") {
+		t.Fatalf("unexpected response for starter: %q", got)
+	}
+	if manager.isSessionRunning("guild-1", "vc-1") {
+		t.Fatal("expected session to be stopped")
+	}
+}
+
+func TestHandleComponent_StopButtonAllowsParticipantsOfAutoStartedSession(t *testing.T) {
+	repo := &mockRepository{guildSettings: map[string]repository.GuildSettings{
+		"guild-1": {GuildID: "guild-1", StopPolicy: repository.StopPolicyStarter},
+	}}
+	manager := newTestManager(repo, &mockDiscordClient{})
+	manager.sessions[manager.sessionKey("guild-1", "vc-1")] = &runningSession{
+		repoSession:        &repository.Session{ID: "session-1", GuildID: "guild-1", ChannelID: "vc-1", StartedAt: time.Now(), Status: repository.SessionStatusRunning, StartTrigger: repository.StartTriggerAuto},
+		activeParticipants: map[string]participantState{"user-1": {}, "user-2": {}},
+		allParticipants:    map[string]participantState{"user-1": {}, "user-2": {}},
+		startedByUserID:    "user-1",
+	}
+
+	if got, _ := pressButton(manager, "user-2", sessionButtonCustomID(buttonActionStop, "session-1")); !strings.Contains(got, "vc-1") {
+		t.Fatalf("unexpected response for participant: %q", got)
+	}
+	if manager.isSessionRunning("guild-1", "vc-1") {
+		t.Fatal("expected session to be stopped")
+	}
+}
@@ -0,0 +1,217 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+	"github.com/foxseedlab/mojiokoshin/internal/transcriber"
+)
+
+const (
+	maxPhraseHintLength = 100
+	maxGuildPhraseHints = 500
+	// 参加者の表示名を足しても Cloud Speech のインラインフレーズセットの上限に収まるようにする
+	maxStreamPhraseHints = 1000
+)
+
+var (
+	ErrInvalidPhraseHint      = errors.New("phrase hint is empty or too long")
+	ErrPhraseHintLimitReached = errors.New("phrase hint limit reached for this guild")
+)
+
+type PhraseHintInput struct {
+	GuildID string
+	Phrase  string
+	UserID  string
+}
+
+func (m *Manager) AddPhraseHint(ctx context.Context, input PhraseHintInput) (bool, error) {
+	phrase, err := normalizePhraseHint(input.Phrase)
+	if err != nil {
+		return false, err
+	}
+	hints, err := m.repo.ListPhraseHints(ctx, input.GuildID)
+	if err != nil {
+		return false, err
+	}
+	if len(hints) >= maxGuildPhraseHints {
+		return false, ErrPhraseHintLimitReached
+	}
+	return m.repo.AddPhraseHint(ctx, repository.AddPhraseHintInput{
+		GuildID:         input.GuildID,
+		Phrase:          phrase,
+		CreatedByUserID: input.UserID,
+	})
+}
+
+func (m *Manager) RemovePhraseHint(ctx context.Context, guildID, phrase string) (bool, error) {
+	normalized, err := normalizePhraseHint(phrase)
+	if err != nil {
+		return false, err
+	}
+	return m.repo.RemovePhraseHint(ctx, guildID, normalized)
+}
+
+func (m *Manager) ListPhraseHints(ctx context.Context, guildID string) ([]string, error) {
+	hints, err := m.repo.ListPhraseHints(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	phrases := make([]string, 0, len(hints))
+	for _, h := range hints {
+		phrases = append(phrases, h.Phrase)
+	}
+	return phrases, nil
+}
+
+func normalizePhraseHint(phrase string) (string, error) {
+	normalized := strings.Join(strings.Fields(phrase), " ")
+	if normalized == "" || utf8.RuneCountInString(normalized) > maxPhraseHintLength {
+		return "", ErrInvalidPhraseHint
+	}
+	return normalized, nil
+}
+
+// 登録済みの語句を優先し、重複や長すぎる語句を除いて上限まで詰める
+func mergePhraseHints(groups ...[]string) []string {
+	seen := make(map[string]struct{})
+	var merged []string
+	for _, group := range groups {
+		for _, phrase := range group {
+			normalized, err := normalizePhraseHint(phrase)
+			if err != nil {
+				continue
+			}
+			if _, ok := seen[normalized]; ok {
+				continue
+			}
+			if len(merged) >= maxStreamPhraseHints {
+				return merged
+			}
+			seen[normalized] = struct{}{}
+			merged = append(merged, normalized)
+		}
+	}
+	return merged
+}
+
+func (m *Manager) streamPhraseHints(ctx context.Context, guildID string, participantNames []string) []string {
+	phrases, err := m.ListPhraseHints(ctx, guildID)
+	if err != nil {
+		slog.Warn("failed to list phrase hints; continuing with participant names only", "error", err, "guild_id", guildID)
+	}
+	return mergePhraseHints(phrases, participantNames)
+}
+
+func (m *Manager) liveStreamOptions(ctx context.Context, guildID, channelID string, participants []discord.VoiceParticipant, sessionLanguages []string) transcriber.StreamOptions {
+	names := m.voiceParticipantDisplayNames(ctx, guildID, channelID, participants)
+	settings, _ := m.guildSettingsBestEffort(ctx, guildID)
+	language, alternatives := m.streamLanguages(sessionLanguages, settings.Languages)
+	return transcriber.StreamOptions{
+		Language:             language,
+		AlternativeLanguages: alternatives,
+		PhraseHints:          m.streamPhraseHints(ctx, guildID, names),
+		Features:             recognitionFeatures(settings),
+		GuildID:              guildID,
+	}
+}
+
+func (m *Manager) voiceParticipantDisplayNames(ctx context.Context, guildID, channelID string, participants []discord.VoiceParticipant) []string {
+	userIDs := make([]string, 0, len(participants))
+	for _, p := range participants {
+		if !p.IsBot {
+			userIDs = append(userIDs, p.UserID)
+		}
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+	metaCtx, cancel := context.WithTimeout(ctx, finalizeMetadataTimeout)
+	defer cancel()
+	meta, err := m.discord.ResolveTranscriptMetadata(metaCtx, guildID, channelID, userIDs)
+	if err != nil {
+		slog.Warn("failed to resolve participant display names for phrase hints", "error", err, "guild_id", guildID, "channel_id", channelID)
+		return nil
+	}
+	names := make([]string, 0, len(meta.Participants))
+	for _, p := range meta.Participants {
+		if !p.IsBot {
+			names = append(names, p.DisplayName)
+		}
+	}
+	return names
+}
+
+func (m *Manager) sessionParticipantDisplayNames(ctx context.Context, sessionID string) []string {
+	participants, err := m.repo.ListSessionParticipants(ctx, sessionID)
+	if err != nil {
+		slog.Warn("failed to list session participants for phrase hints", "error", err, "session_id", sessionID)
+		return nil
+	}
+	names := make([]string, 0, len(participants))
+	for _, p := range participants {
+		if !p.IsBot {
+			names = append(names, p.DisplayName)
+		}
+	}
+	return names
+}
+
+func (m *Manager) handlePhrasesCommand(event discord.SlashCommandEvent) {
+	ctx := context.Background()
+	phrase := event.Option(optionPhrase)
+	switch event.Option(optionAction) {
+	case actionAdd:
+		added, err := m.AddPhraseHint(ctx, PhraseHintInput{GuildID: event.GuildID, Phrase: phrase, UserID: event.UserID})
+		m.respondPhraseChange(event, phrase, err, added, phraseAddedMessage, phraseAlreadyExistsMessage)
+	case actionRemove:
+		removed, err := m.RemovePhraseHint(ctx, event.GuildID, phrase)
+		m.respondPhraseChange(event, phrase, err, removed, phraseRemovedMessage, phraseNotFoundMessage)
+	default:
+		phrases, err := m.ListPhraseHints(ctx, event.GuildID)
+		if err != nil {
+			slog.Error("failed to list phrase hints", "error", err, "guild_id", event.GuildID)
+			m.respondEphemeral(event, messageEphemeralPhraseFailed)
+			return
+		}
+		m.respondEphemeral(event, phraseListMessage(phrases))
+	}
+}
+
+func (m *Manager) respondPhraseChange(event discord.SlashCommandEvent, phrase string, err error, changed bool, changedMessage, unchangedMessage func(string) string) {
+	if err != nil {
+		slog.Warn("failed to update phrase hints", "error", err, "guild_id", event.GuildID, "user_id", event.UserID, "action", event.Option(optionAction))
+		m.respondEphemeral(event, phraseErrorMessage(err))
+		return
+	}
+	normalized, _ := normalizePhraseHint(phrase)
+	if changed {
+		m.auditCommand(event, repository.AuditActionConfigUpdate, "")
+		m.respondEphemeral(event, changedMessage(normalized))
+		return
+	}
+	m.respondEphemeral(event, unchangedMessage(normalized))
+}
+
+func phraseErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidPhraseHint):
+		return messageEphemeralPhraseInvalid
+	case errors.Is(err, ErrPhraseHintLimitReached):
+		return messageEphemeralPhraseLimitReached
+	default:
+		return messageEphemeralPhraseFailed
+	}
+}
+
+func phraseListMessage(phrases []string) string {
+	if len(phrases) == 0 {
+		return strings.Join([]string{messagePhraseListEmpty, messagePhraseListHint}, "\n")
+	}
+	return strings.Join([]string{phraseListTitle(len(phrases)), joinListWithinLimit(phrases, "、"), messagePhraseListHint}, "\n")
+}
@@ -0,0 +1,76 @@
+package session
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+)
+
+func phrasesCommand(action, phrase string, got *string) discord.SlashCommandEvent {
+	return discord.SlashCommandEvent{
+		GuildID:        "guild-1",
+		CommandName:    commandMojiokoshiPhrases,
+		UserID:         "user-1",
+		CanManageGuild: true,
+		Options:        map[string]string{optionAction: action, optionPhrase: phrase},
+		RespondEphemeral: func(content string) error {
+			*got = content
+			return nil
+		},
+	}
+}
+
+func TestHandleSlashCommand_PhrasesAddListRemove(t *testing.T) {
+	repo := &mockRepository{}
+	manager := newTestManager(repo, &mockDiscordClient{})
+	var got string
+
+	manager.HandleSlashCommand(phrasesCommand(actionAdd, "  もじおこ  しん ", &got))
+	if got != phraseAddedMessage("もじおこ しん") {
+		t.Fatalf("unexpected add response: %q", got)
+	}
+	manager.HandleSlashCommand(phrasesCommand(actionAdd, "もじおこ しん", &got))
+	if got != phraseAlreadyExistsMessage("もじおこ しん") {
+		t.Fatalf("unexpected duplicate response: %q", got)
+	}
+	manager.HandleSlashCommand(phrasesCommand(actionList, "", &got))
+	if !strings.Contains(got, phraseListTitle(1)) || !strings.Contains(got, "もじおこ しん") {
+		t.Fatalf("unexpected list response: %q", got)
+	}
+	manager.HandleSlashCommand(phrasesCommand(actionRemove, "もじおこ しん", &got))
+	if got != phraseRemovedMessage("もじおこ しん") || len(repo.phraseHints) != 0 {
+		t.Fatalf("unexpected remove response: %q, hints: %+v", got, repo.phraseHints)
+	}
+	manager.HandleSlashCommand(phrasesCommand(actionAdd, strings.Repeat("あ", maxPhraseHintLength+1), &got))
+	if got != messageEphemeralPhraseInvalid {
+		t.Fatalf("unexpected response for too long phrase: %q", got)
+	}
+}
+
+func TestMergePhraseHints_DedupesAndSkipsInvalid(t *testing.T) {
+	got := mergePhraseHints(
+		[]string{"Alice", " Mojiokoshin ", ""},
+		[]string{"Alice", strings.Repeat("x", maxPhraseHintLength+1), "Bob"},
+	)
+	want := []string{"Alice", "Mojiokoshin", "Bob"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected merged hints: %v", got)
+	}
+}
+
+func TestRetranscribeSession_PassesGuildPhrasesAndParticipantNames(t *testing.T) {
+	stt := &scriptedTranscriber{}
+	manager, repo, _, _ := newRetranscribeTestManager(t, stt, nil)
+	repo.phraseHints = []repository.PhraseHint{{GuildID: "guild-1", Phrase: "Mojiokoshin"}}
+
+	if _, err := manager.RetranscribeSession(context.Background(), RetranscribeInput{SessionID: "session-1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"Mojiokoshin", "Alice"}; !reflect.DeepEqual(stt.gotOpts.PhraseHints, want) {
+		t.Fatalf("unexpected phrase hints: %v", stt.gotOpts.PhraseHints)
+	}
+}
@@ -0,0 +1,166 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+	"github.com/foxseedlab/mojiokoshin/internal/textfilter"
+)
+
+const (
+	maxReplacementPatternLength = 200
+	maxGuildReplacementRules    = 200
+)
+
+var (
+	ErrInvalidReplacementRule      = errors.New("replacement rule is invalid")
+	ErrReplacementRuleLimitReached = errors.New("replacement rule limit reached for this guild")
+)
+
+type ReplacementRuleInput struct {
+	GuildID     string
+	Pattern     string
+	Replacement string
+	Regex       bool
+	UserID      string
+}
+
+func (m *Manager) SaveReplacementRule(ctx context.Context, input ReplacementRuleInput) error {
+	pattern := strings.TrimSpace(input.Pattern)
+	if utf8.RuneCountInString(pattern) > maxReplacementPatternLength {
+		return ErrInvalidReplacementRule
+	}
+	if err := textfilter.ValidateReplacementRule(textfilter.ReplacementRule{Pattern: pattern, Regex: input.Regex}); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidReplacementRule, err)
+	}
+	rules, err := m.repo.ListReplacementRules(ctx, input.GuildID)
+	if err != nil {
+		return err
+	}
+	if len(rules) >= maxGuildReplacementRules && !hasReplacementPattern(rules, pattern) {
+		return ErrReplacementRuleLimitReached
+	}
+	if err := m.repo.SaveReplacementRule(ctx, repository.SaveReplacementRuleInput{
+		GuildID:         input.GuildID,
+		Pattern:         pattern,
+		Replacement:     input.Replacement,
+		IsRegex:         input.Regex,
+		CreatedByUserID: input.UserID,
+	}); err != nil {
+		return err
+	}
+	m.invalidateTextProcessor(input.GuildID)
+	return nil
+}
+
+func (m *Manager) RemoveReplacementRule(ctx context.Context, guildID, pattern string) (bool, error) {
+	removed, err := m.repo.RemoveReplacementRule(ctx, guildID, strings.TrimSpace(pattern))
+	if err != nil {
+		return false, err
+	}
+	m.invalidateTextProcessor(guildID)
+	return removed, nil
+}
+
+func (m *Manager) ListReplacementRules(ctx context.Context, guildID string) ([]repository.ReplacementRule, error) {
+	return m.repo.ListReplacementRules(ctx, guildID)
+}
+
+func hasReplacementPattern(rules []repository.ReplacementRule, pattern string) bool {
+	for _, rule := range rules {
+		if rule.Pattern == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Manager) loadTextReplacer(ctx context.Context, guildID string) (*textfilter.Replacer, error) {
+	rules, err := m.repo.ListReplacementRules(ctx, guildID)
+	if err != nil {
+		slog.Warn("failed to load replacement rules; posting transcript without replacements", "error", err, "guild_id", guildID)
+		return nil, err
+	}
+	filterRules := make([]textfilter.ReplacementRule, 0, len(rules))
+	for _, rule := range rules {
+		filterRules = append(filterRules, textfilter.ReplacementRule{Pattern: rule.Pattern, Replacement: rule.Replacement, Regex: rule.IsRegex})
+	}
+	replacer, err := textfilter.NewReplacer(filterRules)
+	if err != nil {
+		slog.Warn("failed to compile replacement rules; posting transcript without replacements", "error", err, "guild_id", guildID)
+		return nil, err
+	}
+	return replacer, nil
+}
+
+func (m *Manager) handleReplaceCommand(event discord.SlashCommandEvent) {
+	ctx := context.Background()
+	pattern := event.Option(optionPattern)
+	switch event.Option(optionAction) {
+	case actionAdd:
+		replacement := event.Option(optionReplacement)
+		err := m.SaveReplacementRule(ctx, ReplacementRuleInput{
+			GuildID:     event.GuildID,
+			Pattern:     pattern,
+			Replacement: replacement,
+			Regex:       event.Option(optionRegex) == regexChoiceOn,
+			UserID:      event.UserID,
+		})
+		if err != nil {
+			slog.Warn("failed to save replacement rule", "error", err, "guild_id", event.GuildID, "user_id", event.UserID)
+			m.respondEphemeral(event, replacementErrorMessage(err))
+			return
+		}
+		m.auditCommand(event, repository.AuditActionConfigUpdate, "")
+		m.respondEphemeral(event, replacementSavedMessage(pattern, replacement))
+	case actionRemove:
+		removed, err := m.RemoveReplacementRule(ctx, event.GuildID, pattern)
+		switch {
+		case err != nil:
+			slog.Error("failed to remove replacement rule", "error", err, "guild_id", event.GuildID, "user_id", event.UserID)
+			m.respondEphemeral(event, messageEphemeralReplacementFailed)
+		case removed:
+			m.auditCommand(event, repository.AuditActionConfigUpdate, "")
+			m.respondEphemeral(event, replacementRemovedMessage(pattern))
+		default:
+			m.respondEphemeral(event, replacementNotFoundMessage(pattern))
+		}
+	default:
+		m.respondReplacementList(ctx, event)
+	}
+}
+
+func (m *Manager) respondReplacementList(ctx context.Context, event discord.SlashCommandEvent) {
+	rules, err := m.ListReplacementRules(ctx, event.GuildID)
+	if err != nil {
+		slog.Error("failed to list replacement rules", "error", err, "guild_id", event.GuildID)
+		m.respondEphemeral(event, messageEphemeralReplacementFailed)
+		return
+	}
+	if len(rules) == 0 {
+		m.respondEphemeral(event, messageReplacementListEmpty)
+		return
+	}
+	lines := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		lines = append(lines, replacementListLine(rule))
+	}
+	m.respondEphemeral(event, strings.Join([]string{replacementListTitle(len(rules)), joinListWithinLimit(lines, "\n")}, "\n"))
+}
+
+func replacementErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidReplacementRule):
+		return messageEphemeralReplacementInvalid
+	case errors.Is(err, ErrReplacementRuleLimitReached):
+		return messageEphemeralReplacementLimitReached
+	default:
+		return messageEphemeralReplacementFailed
+	}
+}
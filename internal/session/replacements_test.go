@@ -0,0 +1,62 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/transcriber"
+)
+
+func replaceCommand(options map[string]string, got *string) discord.SlashCommandEvent {
+	return discord.SlashCommandEvent{
+		GuildID:        "guild-1",
+		CommandName:    commandMojiokoshiReplace,
+		UserID:         "user-1",
+		CanManageGuild: true,
+		Options:        options,
+		RespondEphemeral: func(content string) error {
+			*got = content
+			return nil
+		},
+	}
+}
+
+func TestHandleTranscriptionResult_AppliesReplacementRulesAndKeepsRawText(t *testing.T) {
+	repo := &mockRepository{}
+	dc := &mockDiscordClient{}
+	manager := newTestManager(repo, dc)
+	var got string
+
+	manager.HandleSlashCommand(replaceCommand(map[string]string{optionAction: actionAdd, optionPattern: "もじおこしん", optionReplacement: "Mojiokoshin"}, &got))
+	if got != replacementSavedMessage("もじおこしん", "Mojiokoshin") {
+		t.Fatalf("unexpected add response: %q", got)
+	}
+	now := time.Now()
+	manager.handleTranscriptionResult("guild-1", "session-1", "vc-1", 0, transcriber.Result{Text: "もじおこしんです", IsFinal: true}, segmentTiming{spokenAt: now, endedAt: now})
+
+	if len(repo.insertCalls) != 1 || repo.insertCalls[0].Content != "Mojiokoshinです" || repo.insertCalls[0].RawContent != "もじおこしんです" {
+		t.Fatalf("unexpected inserted segment: %+v", repo.insertCalls)
+	}
+	if len(dc.sendCalls) != 1 || dc.sendCalls[0] != "Mojiokoshinです" {
+		t.Fatalf("unexpected posted text: %+v", dc.sendCalls)
+	}
+
+	manager.HandleSlashCommand(replaceCommand(map[string]string{optionAction: actionRemove, optionPattern: "もじおこしん"}, &got))
+	manager.handleTranscriptionResult("guild-1", "session-1", "vc-1", 1, transcriber.Result{Text: "もじおこしんです", IsFinal: true}, segmentTiming{spokenAt: now, endedAt: now})
+	if repo.insertCalls[1].Content != "もじおこしんです" {
+		t.Fatalf("expected removed rule to stop applying, got %q", repo.insertCalls[1].Content)
+	}
+}
+
+func TestHandleSlashCommand_ReplaceRejectsInvalidRegex(t *testing.T) {
+	repo := &mockRepository{}
+	manager := newTestManager(repo, &mockDiscordClient{})
+	var got string
+
+	manager.HandleSlashCommand(replaceCommand(map[string]string{optionAction: actionAdd, optionPattern: "(", optionRegex: regexChoiceOn}, &got))
+
+	if got != messageEphemeralReplacementInvalid || len(repo.replacementRules) != 0 {
+		t.Fatalf("unexpected response %q, rules: %+v", got, repo.replacementRules)
+	}
+}
@@ -0,0 +1,171 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+)
+
+const (
+	retentionKindTranscripts = "transcripts"
+	retentionKindArtifacts   = "artifacts"
+
+	// 10年を超える保存期間は入力ミスとみなす
+	maxRetentionDays = 3650
+)
+
+var ErrInvalidRetentionDays = errors.New("retention days are invalid")
+
+type purgeFunc func(ctx context.Context, input repository.PurgeExpiredInput) ([]string, error)
+
+// RunRetentionJanitor は ctx がキャンセルされるまで、保存期間を過ぎたデータを定期的に削除する
+func (m *Manager) RunRetentionJanitor(ctx context.Context) {
+	interval := time.Duration(m.cfg.RetentionIntervalMin) * time.Minute
+	if interval <= 0 {
+		slog.Info("retention janitor disabled")
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		m.PurgeExpiredData(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// 文字起こしを先に消すことで、同じセッションの添付ファイルを二重に処理しないようにする
+func (m *Manager) PurgeExpiredData(ctx context.Context, now time.Time) {
+	guildID := m.cfg.DiscordGuildID
+	settings, ok := m.guildSettingsBestEffort(ctx, guildID)
+	if !ok {
+		return
+	}
+	m.purgeExpiredBatches(ctx, guildID, retentionKindTranscripts, settings.TranscriptRetentionDays, now, m.repo.PurgeExpiredTranscripts)
+	m.purgeExpiredBatches(ctx, guildID, retentionKindArtifacts, settings.ArtifactRetentionDays, now, m.repo.PurgeExpiredArtifacts)
+}
+
+func (m *Manager) purgeExpiredBatches(ctx context.Context, guildID, kind string, days int, now time.Time, purge purgeFunc) {
+	if days <= 0 {
+		return
+	}
+	input := repository.PurgeExpiredInput{
+		GuildID:     guildID,
+		EndedBefore: now.AddDate(0, 0, -days),
+		Limit:       m.cfg.RetentionBatchSize,
+	}
+	total := 0
+	for ctx.Err() == nil {
+		sessionIDs, err := purge(ctx, input)
+		if err != nil {
+			slog.Error("failed to purge expired session data", "error", err, "guild_id", guildID, "kind", kind)
+			break
+		}
+		m.deleteAudioArchives(sessionIDs)
+		for _, sessionID := range sessionIDs {
+			m.recordAudit(ctx, repository.AuditEvent{GuildID: guildID, SessionID: sessionID, Action: repository.AuditActionRetentionPurge, Reason: kind})
+		}
+		total += len(sessionIDs)
+		if len(sessionIDs) < input.Limit {
+			break
+		}
+	}
+	if total > 0 {
+		slog.Info("expired session data purged", "guild_id", guildID, "kind", kind, "sessions", total, "ended_before", input.EndedBefore)
+	}
+}
+
+// 保存した音声は文字起こしよりも機微な情報のため、どちらの保存期間を過ぎても削除する
+func (m *Manager) deleteAudioArchives(sessionIDs []string) {
+	for _, sessionID := range sessionIDs {
+		if err := m.archive.Delete(sessionID); err != nil {
+			slog.Warn("failed to delete audio archive", "error", err, "session_id", sessionID)
+		}
+	}
+}
+
+// DeleteSessionData はセッションの参加者の依頼で、セッションの記録と保存した音声をすべて削除する
+func (m *Manager) DeleteSessionData(ctx context.Context, guildID, userID, sessionID string) error {
+	s, err := m.authorizeSessionParticipant(ctx, guildID, userID, strings.TrimSpace(sessionID))
+	if errors.Is(err, ErrSegmentNotFound) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if s.Status == repository.SessionStatusRunning {
+		return ErrSessionStillRunning
+	}
+	// 削除中に再文字起こしが始まらないように、再文字起こし中の印を付けておく
+	if !m.markRetranscribing(s.ID) {
+		return ErrRetranscriptionInProgress
+	}
+	defer m.unmarkRetranscribing(s.ID)
+	deleted, err := m.repo.DeleteSession(ctx, s.ID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrSessionNotFound
+	}
+	m.deleteAudioArchives([]string{s.ID})
+	return nil
+}
+
+func (m *Manager) handleDeleteCommand(event discord.SlashCommandEvent) {
+	sessionID := event.Option(optionSessionID)
+	if err := m.DeleteSessionData(context.Background(), event.GuildID, event.UserID, sessionID); err != nil {
+		slog.Warn("failed to delete session data", "error", err, "guild_id", event.GuildID, "user_id", event.UserID, "session_id", sessionID)
+		m.respondEphemeral(event, deleteErrorMessage(err))
+		return
+	}
+	slog.Info("session data deleted by participant", "guild_id", event.GuildID, "user_id", event.UserID, "session_id", sessionID)
+	m.auditCommand(event, repository.AuditActionSessionDelete, sessionID)
+	m.respondEphemeral(event, sessionDeletedMessage(sessionID))
+}
+
+func deleteErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrSessionNotFound):
+		return messageEphemeralSessionNotFound
+	case errors.Is(err, ErrNotSessionParticipant):
+		return messageEphemeralNotSessionOperator
+	case errors.Is(err, ErrSessionStillRunning):
+		return messageEphemeralDeleteStillRunning
+	case errors.Is(err, ErrRetranscriptionInProgress):
+		return messageEphemeralRetranscribeInProgress
+	default:
+		return messageEphemeralDeleteFailed
+	}
+}
+
+// value は日数で、0 を指定すると無期限に戻す
+func (m *Manager) SetGuildRetention(ctx context.Context, guildID, kind, value, userID string) (repository.GuildSettings, error) {
+	days, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || days < 0 || days > maxRetentionDays {
+		return repository.GuildSettings{}, ErrInvalidRetentionDays
+	}
+	settings, err := m.GuildSettings(ctx, guildID)
+	if err != nil {
+		return settings, err
+	}
+	if kind == retentionKindTranscripts {
+		settings.TranscriptRetentionDays = days
+	} else {
+		settings.ArtifactRetentionDays = days
+	}
+	settings.UpdatedByUserID = userID
+	if err := m.repo.SaveGuildSettings(ctx, settings); err != nil {
+		return settings, err
+	}
+	return settings, nil
+}
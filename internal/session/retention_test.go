@@ -0,0 +1,127 @@
+package session
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+)
+
+func TestPurgeExpiredData_PurgesInBatchesAndDeletesAudio(t *testing.T) {
+	repo := &mockRepository{
+		guildSettings: map[string]repository.GuildSettings{
+			"guild-1": {GuildID: "guild-1", TranscriptRetentionDays: 90, ArtifactRetentionDays: 30},
+		},
+		expiredTranscripts: []string{"session-1", "session-2", "session-3"},
+		expiredArtifacts:   []string{"session-4"},
+	}
+	manager := newTestManager(repo, &mockDiscordClient{})
+	manager.cfg.RetentionBatchSize = 2
+	archive := &mockArchive{bodies: map[string][]byte{"session-1": {1}, "session-4": {1}, "session-5": {1}}}
+	manager.archive = archive
+	now := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	manager.PurgeExpiredData(context.Background(), now)
+
+	if len(repo.purgeInputs) != 3 {
+		t.Fatalf("expected 2 transcript batches and 1 artifact batch, got %+v", repo.purgeInputs)
+	}
+	if got := repo.purgeInputs[0].EndedBefore; !got.Equal(now.AddDate(0, 0, -90)) {
+		t.Fatalf("unexpected transcript cutoff: %v", got)
+	}
+	if got := repo.purgeInputs[2].EndedBefore; !got.Equal(now.AddDate(0, 0, -30)) {
+		t.Fatalf("unexpected artifact cutoff: %v", got)
+	}
+	if len(repo.expiredTranscripts) != 0 || len(repo.expiredArtifacts) != 0 {
+		t.Fatal("expected all expired sessions to be purged")
+	}
+	if len(archive.bodies) != 1 || archive.bodies["session-5"] == nil {
+		t.Fatalf("expected only audio of purged sessions to be deleted: %v", archive.bodies)
+	}
+}
+
+func TestPurgeExpiredData_KeepsDataWithoutRetention(t *testing.T) {
+	repo := &mockRepository{expiredTranscripts: []string{"session-1"}}
+	manager := newTestManager(repo, &mockDiscordClient{})
+
+	manager.PurgeExpiredData(context.Background(), time.Now())
+
+	if len(repo.purgeInputs) != 0 {
+		t.Fatalf("expected no purge without retention settings, got %+v", repo.purgeInputs)
+	}
+}
+
+func runDeleteCommand(manager *Manager, userID, sessionID string) string {
+	var got string
+	manager.HandleSlashCommand(discord.SlashCommandEvent{
+		GuildID:     "guild-1",
+		CommandName: commandMojiokoshiDelete,
+		UserID:      userID,
+		Options:     map[string]string{optionSessionID: sessionID},
+		RespondEphemeral: func(content string) error {
+			got = content
+			return nil
+		},
+	})
+	return got
+}
+
+func TestHandleSlashCommand_DeleteSession(t *testing.T) {
+	repo := newCorrectionFixture(repository.SessionStatusCompleted)
+	manager := newTestManager(repo, &mockDiscordClient{})
+	archive := &mockArchive{bodies: map[string][]byte{"session-1": {1}}}
+	manager.archive = archive
+
+	if got := runDeleteCommand(manager, "user-2", "session-1"); got != messageEphemeralNotSessionOperator {
+		t.Fatalf("unexpected response for non-participant: %q", got)
+	}
+	if got := runDeleteCommand(manager, "user-1", "missing"); got != messageEphemeralSessionNotFound {
+		t.Fatalf("unexpected response for missing session: %q", got)
+	}
+	got := runDeleteCommand(manager, "user-1", "session-1")
+	if !strings.Contains(got, "session-1") {
+		t.Fatalf("unexpected response: %q", got)
+	}
+	if len(repo.deletedSessionIDs) != 1 || repo.deletedSessionIDs[0] != "session-1" {
+		t.Fatalf("expected session to be deleted: %+v", repo.deletedSessionIDs)
+	}
+	if _, ok := archive.bodies["session-1"]; ok {
+		t.Fatal("expected audio archive to be deleted")
+	}
+}
+
+func TestHandleSlashCommand_DeleteRunningSessionIsRejected(t *testing.T) {
+	repo := newCorrectionFixture(repository.SessionStatusRunning)
+	manager := newTestManager(repo, &mockDiscordClient{})
+
+	if got := runDeleteCommand(manager, "user-1", "session-1"); got != messageEphemeralDeleteStillRunning {
+		t.Fatalf("unexpected response: %q", got)
+	}
+	if len(repo.deletedSessionIDs) != 0 {
+		t.Fatal("expected running session to be kept")
+	}
+}
+
+func TestHandleSettingsCommand_Retention(t *testing.T) {
+	repo := &mockRepository{}
+	manager := newTestManager(repo, &mockDiscordClient{})
+
+	var got string
+	manager.HandleSlashCommand(settingsCommand(map[string]string{optionTranscriptRetention: "90"}, &got))
+	if !strings.Contains(got, messageSettingsUpdated) || repo.guildSettings["guild-1"].TranscriptRetentionDays != 90 {
+		t.Fatalf("unexpected response: %q settings=%+v", got, repo.guildSettings["guild-1"])
+	}
+	manager.HandleSlashCommand(settingsCommand(map[string]string{optionArtifactRetention: "30"}, &got))
+	if repo.guildSettings["guild-1"].ArtifactRetentionDays != 30 || repo.guildSettings["guild-1"].TranscriptRetentionDays != 90 {
+		t.Fatalf("unexpected settings: %+v", repo.guildSettings["guild-1"])
+	}
+	for _, value := range []string{"-1", "abc", "3651"} {
+		manager.HandleSlashCommand(settingsCommand(map[string]string{optionArtifactRetention: value}, &got))
+		if got != messageEphemeralRetentionInvalid {
+			t.Fatalf("unexpected response for %q: %q", value, got)
+		}
+	}
+}
@@ -0,0 +1,439 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/foxseedlab/mojiokoshin/internal/audio"
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+	"github.com/foxseedlab/mojiokoshin/internal/transcriber"
+)
+
+var (
+	ErrSessionNotFound           = errors.New("session not found")
+	ErrSessionStillRunning       = errors.New("session is still running")
+	ErrRetranscriptionInProgress = errors.New("retranscription is already in progress for this session")
+)
+
+const (
+	retranscribeTimeoutMargin = 10 * time.Minute
+	retranscribeMaxDuration   = 6 * time.Hour
+)
+
+type RetranscribeInput struct {
+	SessionID string
+	GuildID   string
+	Language  string
+	Model     string
+}
+
+type RetranscribeResult struct {
+	SessionID    string
+	Revision     int
+	SegmentCount int
+}
+
+type retranscription struct {
+	manager  *Manager
+	session  *repository.Session
+	audio    io.ReadCloser
+	opts     transcriber.StreamOptions
+	revision int
+}
+
+func (m *Manager) RetranscribeSession(ctx context.Context, input RetranscribeInput) (RetranscribeResult, error) {
+	job, err := m.prepareRetranscription(ctx, input)
+	if err != nil {
+		return RetranscribeResult{}, err
+	}
+	return job.run(ctx)
+}
+
+func (m *Manager) prepareRetranscription(ctx context.Context, input RetranscribeInput) (*retranscription, error) {
+	languages, err := parseLanguageCandidates(input.Language)
+	if err != nil {
+		return nil, err
+	}
+	s, err := m.repo.GetSessionByID(ctx, strings.TrimSpace(input.SessionID))
+	if err != nil {
+		return nil, err
+	}
+	if s == nil || (input.GuildID != "" && s.GuildID != input.GuildID) {
+		return nil, ErrSessionNotFound
+	}
+	if s.Status == repository.SessionStatusRunning {
+		return nil, ErrSessionStillRunning
+	}
+	if sessionPurged(s) {
+		return nil, ErrSessionPurged
+	}
+	if !m.markRetranscribing(s.ID) {
+		return nil, ErrRetranscriptionInProgress
+	}
+	r, err := m.archive.Open(s.ID)
+	if err != nil {
+		m.unmarkRetranscribing(s.ID)
+		return nil, err
+	}
+	settings, _ := m.guildSettingsBestEffort(ctx, s.GuildID)
+	language, alternatives := m.streamLanguages(languages, settings.Languages)
+	return &retranscription{
+		manager: m,
+		session: s,
+		audio:   r,
+		opts: transcriber.StreamOptions{
+			Language:             language,
+			AlternativeLanguages: alternatives,
+			Model:                strings.TrimSpace(input.Model),
+			PhraseHints:          m.streamPhraseHints(ctx, s.GuildID, m.sessionParticipantDisplayNames(ctx, s.ID)),
+			Features:             recognitionFeatures(settings),
+		},
+		revision: s.TranscriptRevision + 1,
+	}, nil
+}
+
+func (m *Manager) markRetranscribing(sessionID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.retranscribing[sessionID]; exists {
+		return false
+	}
+	m.retranscribing[sessionID] = struct{}{}
+	return true
+}
+
+func (m *Manager) unmarkRetranscribing(sessionID string) {
+	m.mu.Lock()
+	delete(m.retranscribing, sessionID)
+	m.mu.Unlock()
+}
+
+func (j *retranscription) run(ctx context.Context) (RetranscribeResult, error) {
+	s := j.session
+	defer j.manager.unmarkRetranscribing(s.ID)
+	defer func() {
+		_ = j.audio.Close()
+	}()
+	slog.Info("retranscription started", "session_id", s.ID, "revision", j.revision, "language", j.opts.Language, "model", j.opts.Model)
+
+	receiver := &revisionReceiver{manager: j.manager, session: s, revision: j.revision, clock: &audioClock{}}
+	writer, err := j.manager.transcriber.StartStreaming(ctx, s.ID, j.opts, receiver)
+	if err != nil {
+		return RetranscribeResult{}, fmt.Errorf("start transcriber streaming: %w", err)
+	}
+	replayErr := replayArchivedAudio(ctx, j.audio, writer, receiver.clock, s.StartedAt, newRealtimePacer())
+	closeErr := writer.Close()
+	if replayErr != nil {
+		return RetranscribeResult{}, fmt.Errorf("replay archived audio: %w", replayErr)
+	}
+	if closeErr != nil {
+		return RetranscribeResult{}, fmt.Errorf("close transcriber stream: %w", closeErr)
+	}
+	segments, err := receiver.result()
+	if err != nil {
+		return RetranscribeResult{}, err
+	}
+	if err := j.publish(ctx, segments); err != nil {
+		return RetranscribeResult{}, err
+	}
+	slog.Info("retranscription completed", "session_id", s.ID, "revision", j.revision, "segment_count", len(segments))
+	return RetranscribeResult{SessionID: s.ID, Revision: j.revision, SegmentCount: len(segments)}, nil
+}
+
+// 送信した音声の長さに合わせて書き込みを待つ。ストリーミング認識は実時間より速い送信を受け付けない
+type audioPacer func(ctx context.Context, sent time.Duration) error
+
+func newRealtimePacer() audioPacer {
+	begin := time.Now()
+	return func(ctx context.Context, sent time.Duration) error {
+		wait := time.Until(begin.Add(sent))
+		if wait <= 0 {
+			return ctx.Err()
+		}
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return nil
+		}
+	}
+}
+
+// 再文字起こしの上限時間。送信は実時間で進むため、セッションの長さに余裕を足す
+func (j *retranscription) timeout() time.Duration {
+	if j.session.EndedAt == nil || j.session.EndedAt.Before(j.session.StartedAt) {
+		return retranscribeMaxDuration
+	}
+	return min(j.session.EndedAt.Sub(j.session.StartedAt)+retranscribeTimeoutMargin, retranscribeMaxDuration)
+}
+
+// アーカイブは実時間に揃えるため無音区間をゼロ埋めで保存している。
+// 再生時はゼロのみのフレームを送らず、再生位置だけを進めて課金対象の音声を減らす。
+// 送信は pace で送信済みの音声の長さに合わせ、無音を飛ばした分だけ実時間より早く終わる
+func replayArchivedAudio(ctx context.Context, r io.Reader, w transcriber.StreamWriter, clock *audioClock, startedAt time.Time, pace audioPacer) error {
+	buf := make([]byte, audioFrameBytes)
+	silence := make([]byte, audioFrameBytes)
+	var position, sent time.Duration
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			frame := buf[:n]
+			position += pcmDuration(n)
+			if bytes.Equal(frame, silence[:n]) {
+				clock.skipped()
+			} else {
+				if paceErr := pace(ctx, sent); paceErr != nil {
+					return paceErr
+				}
+				if writeErr := w.Write(frame); writeErr != nil {
+					return writeErr
+				}
+				sent += pcmDuration(n)
+				clock.wrote(n, startedAt.Add(position))
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (j *retranscription) publish(ctx context.Context, segments []repository.TranscriptSegment) error {
+	m := j.manager
+	s := j.session
+	src := m.transcriptSourceFromRepository(ctx, s, segments, transcriptRevisionInfo{Number: j.revision, Language: streamLanguageLabel(j.opts.Language, j.opts.AlternativeLanguages), Model: j.opts.Model})
+	filename := transcriptFilename(s.ID, j.revision)
+	body := buildTranscriptText(src)
+	payload := buildTranscriptWebhookPayload(src)
+	if err := m.repo.SaveTranscriptRevision(ctx, repository.SaveTranscriptRevisionInput{
+		SessionID:          s.ID,
+		Revision:           j.revision,
+		SegmentCount:       len(segments),
+		TranscriptFilename: filename,
+		TranscriptText:     string(body),
+		WebhookPayloadJSON: marshalPayloadBestEffort(payload, s.ID),
+	}); err != nil {
+		return fmt.Errorf("save transcript revision: %w", err)
+	}
+	if err := m.discord.SendChannelMessageWithFile(discord.FileMessage{
+		ChannelID: s.ChannelID,
+		Content:   m.retranscribeAttachmentMessage(j.revision),
+		Filename:  filename,
+		FileBody:  body,
+	}); err != nil {
+		slog.Error("failed to send retranscribed attachment", "error", err, "session_id", s.ID, "revision", j.revision)
+	}
+	m.sendWebhookBestEffort(ctx, s.ID, payload)
+	return nil
+}
+
+func (m *Manager) transcriptSourceFromRepository(ctx context.Context, s *repository.Session, segments []repository.TranscriptSegment, revision transcriptRevisionInfo) transcriptSource {
+	endedAt := s.StartedAt.Add(time.Duration(s.DurationSeconds) * time.Second)
+	if s.EndedAt != nil {
+		endedAt = *s.EndedAt
+	}
+	timezone := s.Timezone
+	if timezone == "" {
+		timezone = m.cfg.TranscriptTimezone
+	}
+	return transcriptSource{
+		SessionID:              s.ID,
+		Meta:                   m.transcriptMetadataFromRepository(ctx, s),
+		StartedAt:              s.StartedAt,
+		EndedAt:                endedAt,
+		Timezone:               timezone,
+		Location:               m.transcriptLocation,
+		Segments:               segments,
+		Revision:               revision,
+		LowConfidenceThreshold: m.cfg.TranscriptLowConfidence,
+		StartedByUserID:        s.StartedByUserID,
+		StartTrigger:           s.StartTrigger,
+		StoppedByUserID:        s.StoppedByUserID,
+	}
+}
+
+func (m *Manager) transcriptMetadataFromRepository(ctx context.Context, s *repository.Session) discord.TranscriptMetadata {
+	meta := discord.TranscriptMetadata{
+		DiscordServerID:         s.GuildID,
+		DiscordServerName:       s.GuildName,
+		DiscordVoiceChannelID:   s.ChannelID,
+		DiscordVoiceChannelName: s.ChannelName,
+	}
+	participants, err := m.repo.ListSessionParticipants(ctx, s.ID)
+	if err != nil {
+		slog.Warn("failed to list session participants; attachment will omit them", "error", err, "session_id", s.ID)
+	}
+	for _, p := range participants {
+		meta.Participants = append(meta.Participants, discord.TranscriptParticipant{
+			UserID:      p.UserID,
+			DisplayName: p.DisplayName,
+			IsBot:       p.IsBot,
+		})
+	}
+	return fillTranscriptMetadataFallbacks(meta, s, nil, nil)
+}
+
+type revisionReceiver struct {
+	manager   *Manager
+	session   *repository.Session
+	revision  int
+	clock     *audioClock
+	mu        sync.Mutex
+	segments  []repository.TranscriptSegment
+	insertErr error
+	streamErr error
+}
+
+func (r *revisionReceiver) OnResult(result transcriber.Result) {
+	if !result.IsFinal || strings.TrimSpace(result.Text) == "" {
+		return
+	}
+	processed := r.manager.processTranscriptText(context.Background(), r.session.GuildID, result.Text)
+	if strings.TrimSpace(processed.content) == "" {
+		return
+	}
+	timing := r.clock.timing(result, r.session.StartedAt)
+	if !processed.keepWords {
+		timing.words = nil
+	}
+	translations := r.manager.translateSegment(context.Background(), processed.content, result.Language)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.insertErr != nil {
+		return
+	}
+	seg := repository.TranscriptSegment{
+		SessionID:    r.session.ID,
+		Content:      processed.content,
+		RawContent:   processed.raw,
+		SegmentIndex: len(r.segments),
+		Revision:     r.revision,
+		SpokenAt:     timing.spokenAt,
+		EndedAt:      &timing.endedAt,
+		Words:        timing.words,
+		Language:     result.Language,
+		Translations: translations,
+	}
+	if result.Confidence > 0 {
+		seg.Confidence = &result.Confidence
+	}
+	if err := r.manager.repo.InsertSegment(context.Background(), repository.InsertSegmentInput{
+		SessionID:    seg.SessionID,
+		Content:      seg.Content,
+		RawContent:   seg.RawContent,
+		SegmentIndex: seg.SegmentIndex,
+		Revision:     seg.Revision,
+		SpokenAt:     seg.SpokenAt,
+		EndedAt:      timing.endedAt,
+		Confidence:   result.Confidence,
+		Words:        seg.Words,
+		Language:     seg.Language,
+		Translations: seg.Translations,
+	}); err != nil {
+		r.insertErr = fmt.Errorf("insert segment: %w", err)
+		return
+	}
+	r.segments = append(r.segments, seg)
+}
+
+func (r *revisionReceiver) OnError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.streamErr == nil {
+		r.streamErr = err
+	}
+}
+
+func (r *revisionReceiver) result() ([]repository.TranscriptSegment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.insertErr != nil {
+		return nil, r.insertErr
+	}
+	if r.streamErr != nil {
+		return nil, fmt.Errorf("transcriber stream: %w", r.streamErr)
+	}
+	return r.segments, nil
+}
+
+// 再文字起こしは STT の利用料がかかるため、セッションの参加者とモデレーターのみ実行できる
+func (m *Manager) authorizeRetranscription(ctx context.Context, settings repository.GuildSettings, event discord.SlashCommandEvent) error {
+	if isModerator(settings, slashCommandMember(event)) {
+		return nil
+	}
+	if _, err := m.authorizeSessionParticipant(ctx, event.GuildID, event.UserID, strings.TrimSpace(event.Option(optionSessionID))); err != nil {
+		if errors.Is(err, ErrSegmentNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (m *Manager) handleRetranscribeCommand(event discord.SlashCommandEvent) {
+	job, err := m.prepareRetranscription(context.Background(), RetranscribeInput{
+		SessionID: event.Option(optionSessionID),
+		GuildID:   event.GuildID,
+		Language:  event.Option(optionLanguage),
+		Model:     event.Option(optionModel),
+	})
+	if err != nil {
+		slog.Warn("failed to prepare retranscription", "error", err, "guild_id", event.GuildID, "user_id", event.UserID, "session_id", event.Option(optionSessionID))
+		m.respondEphemeral(event, retranscribeErrorMessage(err))
+		return
+	}
+	m.respondEphemeral(event, m.retranscribeEphemeralMessage())
+	go m.runRetranscriptionInBackground(job)
+}
+
+func (m *Manager) runRetranscriptionInBackground(job *retranscription) {
+	sessionID := job.session.ID
+	channelID := job.session.ChannelID
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			slog.Error("panic while retranscribing session", "panic", recovered, "session_id", sessionID)
+		}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), job.timeout())
+	defer cancel()
+	if _, err := job.run(ctx); err != nil {
+		slog.Error("retranscription failed", "error", err, "session_id", sessionID, "revision", job.revision)
+		if _, sendErr := m.discord.SendChannelMessage(channelID, messageRetranscribeFailedChannel); sendErr != nil {
+			slog.Error("failed to send retranscription failure message", "error", sendErr, "session_id", sessionID)
+		}
+	}
+}
+
+func retranscribeErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidLanguages):
+		return messageEphemeralLanguagesInvalid
+	case errors.Is(err, ErrSessionNotFound):
+		return messageEphemeralSessionNotFound
+	case errors.Is(err, ErrNotSessionParticipant):
+		return messageEphemeralRetranscribeNotAllowed
+	case errors.Is(err, ErrSessionStillRunning):
+		return messageEphemeralSessionStillRunning
+	case errors.Is(err, ErrSessionPurged):
+		return messageEphemeralSessionPurged
+	case errors.Is(err, ErrRetranscriptionInProgress):
+		return messageEphemeralRetranscribeInProgress
+	case errors.Is(err, audio.ErrArchiveNotFound):
+		return messageEphemeralAudioNotArchived
+	default:
+		return messageEphemeralRetranscribeFailed
+	}
+}
@@ -0,0 +1,246 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+	"github.com/foxseedlab/mojiokoshin/internal/transcriber"
+)
+
+type scriptedTranscriber struct {
+	results      []string
+	gotOpts      transcriber.StreamOptions
+	writtenBytes int
+}
+
+func (s *scriptedTranscriber) StartStreaming(_ context.Context, _ string, opts transcriber.StreamOptions, receiver transcriber.ResultReceiver) (transcriber.StreamWriter, error) {
+	s.gotOpts = opts
+	return &scriptedStreamWriter{transcriber: s, receiver: receiver}, nil
+}
+
+func (s *scriptedTranscriber) Ping(_ context.Context) error { return nil }
+
+type scriptedStreamWriter struct {
+	transcriber *scriptedTranscriber
+	receiver    transcriber.ResultReceiver
+}
+
+func (w *scriptedStreamWriter) Write(pcm []byte) error {
+	w.transcriber.writtenBytes += len(pcm)
+	return nil
+}
+
+func (w *scriptedStreamWriter) Close() error {
+	for i, text := range w.transcriber.results {
+		w.receiver.OnResult(transcriber.Result{SegmentIndex: i, Text: text, IsFinal: true})
+	}
+	return nil
+}
+
+func newRetranscribeTestManager(t *testing.T, stt transcriber.Transcriber, audioBody []byte) (*Manager, *mockRepository, *mockDiscordClient, *mockWebhookSender) {
+	t.Helper()
+	startedAt := time.Date(2026, 2, 28, 12, 0, 0, 0, time.UTC)
+	endedAt := startedAt.Add(time.Minute)
+	repo := &mockRepository{
+		sessionsByID: map[string]*repository.Session{
+			"session-1": {
+				ID:          "session-1",
+				GuildID:     "guild-1",
+				GuildName:   "Guild",
+				ChannelID:   "vc-1",
+				ChannelName: "General",
+				StartedAt:   startedAt,
+				EndedAt:     &endedAt,
+				Status:      repository.SessionStatusCompleted,
+				Timezone:    "Asia/Tokyo",
+			},
+		},
+		participantsBySession: map[string][]repository.SessionParticipant{
+			"session-1": {{SessionID: "session-1", UserID: "user-1", DisplayName: "Alice"}},
+		},
+	}
+	dc := &mockDiscordClient{}
+	wh := &mockWebhookSender{}
+	manager := newTestManager(repo, dc)
+	manager.transcriber = stt
+	manager.webhook = wh
+	manager.archive = &mockArchive{bodies: map[string][]byte{"session-1": audioBody}}
+	return manager, repo, dc, wh
+}
+
+func TestRetranscribeSession_WritesNewRevisionAndResends(t *testing.T) {
+	silence := make([]byte, audioFrameBytes)
+	voice := make([]byte, audioFrameBytes)
+	voice[0] = 1
+	audioBody := append(append([]byte{}, silence...), voice...)
+	stt := &scriptedTranscriber{results: []string{"hello again"}}
+	manager, repo, dc, wh := newRetranscribeTestManager(t, stt, audioBody)
+
+	result, err := manager.RetranscribeSession(context.Background(), RetranscribeInput{SessionID: "session-1", Language: "en-US", Model: "long"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Revision != 1 || result.SegmentCount != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if stt.gotOpts.Language != "en-US" || stt.gotOpts.Model != "long" {
+		t.Fatalf("unexpected stream options: %+v", stt.gotOpts)
+	}
+	if stt.writtenBytes != audioFrameBytes {
+		t.Fatalf("expected only non-silent frames to be replayed, got %d bytes", stt.writtenBytes)
+	}
+	if len(repo.insertCalls) != 1 || repo.insertCalls[0].Revision != 1 || repo.insertCalls[0].Content != "hello again" {
+		t.Fatalf("unexpected inserted segments: %+v", repo.insertCalls)
+	}
+	if len(repo.savedRevisionCalls) != 1 || repo.savedRevisionCalls[0].Revision != 1 {
+		t.Fatalf("unexpected saved revisions: %+v", repo.savedRevisionCalls)
+	}
+	if len(dc.fileCalls) != 1 || dc.fileCalls[0].Filename != "transcript-session-1-r1.txt" || dc.fileCalls[0].ChannelID != "vc-1" {
+		t.Fatalf("unexpected attachment: %+v", dc.fileCalls)
+	}
+	if len(wh.payloads) != 1 || !wh.payloads[0].IsRevision || wh.payloads[0].Revision != 1 {
+		t.Fatalf("unexpected webhook payloads: %+v", wh.payloads)
+	}
+	if wh.payloads[0].ParticipantDetails[0].DisplayName != "Alice" {
+		t.Fatalf("expected participants from repository, got %+v", wh.payloads[0].ParticipantDetails)
+	}
+}
+
+func TestRetranscribeSession_RejectsRunningSession(t *testing.T) {
+	manager, repo, _, _ := newRetranscribeTestManager(t, &scriptedTranscriber{}, nil)
+	repo.sessionsByID["session-1"].Status = repository.SessionStatusRunning
+
+	if _, err := manager.RetranscribeSession(context.Background(), RetranscribeInput{SessionID: "session-1"}); !errors.Is(err, ErrSessionStillRunning) {
+		t.Fatalf("expected ErrSessionStillRunning, got %v", err)
+	}
+}
+
+func TestHandleSlashCommand_RetranscribeReportsMissingAudio(t *testing.T) {
+	manager, _, _, _ := newRetranscribeTestManager(t, &scriptedTranscriber{}, nil)
+	manager.archive = &mockArchive{}
+	var got string
+
+	manager.HandleSlashCommand(discord.SlashCommandEvent{
+		GuildID:     "guild-1",
+		CommandName: commandMojiokoshiRetranscribe,
+		UserID:      "user-1",
+		Options:     map[string]string{optionSessionID: "session-1"},
+		RespondEphemeral: func(content string) error {
+			got = content
+			return nil
+		},
+	})
+
+	if got != messageEphemeralAudioNotArchived {
+		t.Fatalf("unexpected response: %q", got)
+	}
+}
+
+func TestHandleSlashCommand_RetranscribeHidesOtherGuildSession(t *testing.T) {
+	manager, repo, _, _ := newRetranscribeTestManager(t, &scriptedTranscriber{}, nil)
+	repo.sessionsByID["session-1"].GuildID = "guild-2"
+	var got string
+
+	manager.HandleSlashCommand(discord.SlashCommandEvent{
+		GuildID:     "guild-1",
+		CommandName: commandMojiokoshiRetranscribe,
+		UserID:      "user-1",
+		Options:     map[string]string{optionSessionID: "session-1"},
+		RespondEphemeral: func(content string) error {
+			got = content
+			return nil
+		},
+	})
+
+	if got != messageEphemeralSessionNotFound {
+		t.Fatalf("unexpected response: %q", got)
+	}
+}
+
+func TestHandleSlashCommand_RetranscribeRequiresParticipantOrModerator(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         string
+		canManageGuild bool
+		want           string
+	}{
+		{name: "participant", userID: "user-1", want: messageEphemeralAudioNotArchived},
+		{name: "moderator", userID: "user-9", canManageGuild: true, want: messageEphemeralAudioNotArchived},
+		{name: "outsider", userID: "user-9", want: messageEphemeralRetranscribeNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, _, _, _ := newRetranscribeTestManager(t, &scriptedTranscriber{}, nil)
+			manager.archive = &mockArchive{}
+			var got string
+
+			manager.HandleSlashCommand(discord.SlashCommandEvent{
+				GuildID:        "guild-1",
+				CommandName:    commandMojiokoshiRetranscribe,
+				UserID:         tt.userID,
+				CanManageGuild: tt.canManageGuild,
+				Options:        map[string]string{optionSessionID: "session-1"},
+				RespondEphemeral: func(content string) error {
+					got = content
+					return nil
+				},
+			})
+
+			if got != tt.want {
+				t.Fatalf("unexpected response: %q", got)
+			}
+		})
+	}
+}
+
+func TestReplayArchivedAudio_PacesBySentAudio(t *testing.T) {
+	silence := make([]byte, audioFrameBytes)
+	voice := make([]byte, audioFrameBytes)
+	voice[0] = 1
+	body := append(append(append([]byte{}, voice...), silence...), voice...)
+	stt := &scriptedTranscriber{}
+	var paced []time.Duration
+
+	err := replayArchivedAudio(context.Background(), bytes.NewReader(body), &scriptedStreamWriter{transcriber: stt}, &audioClock{}, time.Now(), func(_ context.Context, sent time.Duration) error {
+		paced = append(paced, sent)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	frame := pcmDuration(audioFrameBytes)
+	if len(paced) != 2 || paced[0] != 0 || paced[1] != frame {
+		t.Fatalf("expected pacing by sent audio only, got %v", paced)
+	}
+}
+
+func TestReplayArchivedAudio_StopsWhenContextIsCanceled(t *testing.T) {
+	voice := make([]byte, audioFrameBytes)
+	voice[0] = 1
+	body := append(append([]byte{}, voice...), voice...)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := replayArchivedAudio(ctx, bytes.NewReader(body), &scriptedStreamWriter{transcriber: &scriptedTranscriber{}}, &audioClock{}, time.Now(), newRealtimePacer())
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestRetranscribeSession_RejectsPurgedSession(t *testing.T) {
+	manager, repo, _, _ := newRetranscribeTestManager(t, &scriptedTranscriber{}, nil)
+	purgedAt := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	repo.sessionsByID["session-1"].ArtifactsPurgedAt = &purgedAt
+
+	if _, err := manager.RetranscribeSession(context.Background(), RetranscribeInput{SessionID: "session-1"}); !errors.Is(err, ErrSessionPurged) {
+		t.Fatalf("expected ErrSessionPurged, got %v", err)
+	}
+	if len(repo.savedRevisionCalls) != 0 {
+		t.Fatalf("expected no revision for purged session, got %+v", repo.savedRevisionCalls)
+	}
+}
@@ -0,0 +1,74 @@
+package session
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+)
+
+func (m *Manager) handleSubscribeCommand(event discord.SlashCommandEvent) {
+	ctx := context.Background()
+	value := event.Option(optionValue)
+	if value != settingChoiceOn && value != settingChoiceOff {
+		m.respondSubscriptionStatus(ctx, event)
+		return
+	}
+	subscribed := value == settingChoiceOn
+	if err := m.repo.SetTranscriptSubscription(ctx, event.GuildID, event.UserID, subscribed); err != nil {
+		slog.Error("failed to update transcript subscription", "error", err, "guild_id", event.GuildID, "user_id", event.UserID, "subscribed", subscribed)
+		m.respondEphemeral(event, messageEphemeralSubscriptionFailed)
+		return
+	}
+	if subscribed {
+		m.respondEphemeral(event, messageEphemeralSubscribed)
+		return
+	}
+	m.respondEphemeral(event, messageEphemeralUnsubscribed)
+}
+
+func (m *Manager) respondSubscriptionStatus(ctx context.Context, event discord.SlashCommandEvent) {
+	subscribed, err := m.repo.IsTranscriptSubscribed(ctx, event.GuildID, event.UserID)
+	switch {
+	case err != nil:
+		slog.Error("failed to load transcript subscription", "error", err, "guild_id", event.GuildID, "user_id", event.UserID)
+		m.respondEphemeral(event, messageEphemeralSubscriptionFailed)
+	case subscribed:
+		m.respondEphemeral(event, messageEphemeralSubscriptionStatusOn)
+	default:
+		m.respondEphemeral(event, messageEphemeralSubscriptionStatusOff)
+	}
+}
+
+// 終了処理で保存した session_participants から、DM での受け取りを希望した参加者に添付ファイルを送る
+func (m *Manager) deliverTranscriptDMsBestEffort(ctx context.Context, s *repository.Session, filename string, body []byte) {
+	participants, err := m.repo.ListSessionParticipants(ctx, s.ID)
+	if err != nil {
+		slog.Error("failed to list session participants for transcript DM", "error", err, "session_id", s.ID)
+		return
+	}
+	userIDs := make([]string, 0, len(participants))
+	for _, p := range participants {
+		if !p.IsBot {
+			userIDs = append(userIDs, p.UserID)
+		}
+	}
+	subscribers, err := m.repo.ListTranscriptSubscribers(ctx, s.GuildID, userIDs)
+	if err != nil {
+		slog.Error("failed to list transcript subscribers", "error", err, "session_id", s.ID)
+		return
+	}
+	for _, userID := range subscribers {
+		if err := m.discord.SendDirectMessageWithFile(userID, discord.FileMessage{
+			Content:  transcriptDMMessage(s.ChannelID),
+			Filename: filename,
+			FileBody: body,
+		}); err != nil {
+			slog.Warn("failed to send transcript DM", "error", err, "session_id", s.ID, "user_id", userID)
+		}
+	}
+	if len(subscribers) > 0 {
+		slog.Info("transcript DMs delivered", "session_id", s.ID, "recipients", len(subscribers))
+	}
+}
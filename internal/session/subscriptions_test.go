@@ -0,0 +1,77 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+)
+
+func runSubscribeCommand(manager *Manager, value string) string {
+	var got string
+	manager.HandleSlashCommand(discord.SlashCommandEvent{
+		GuildID:     "guild-1",
+		CommandName: commandMojiokoshiSubscribe,
+		UserID:      "user-1",
+		Options:     map[string]string{optionValue: value},
+		RespondEphemeral: func(content string) error {
+			got = content
+			return nil
+		},
+	})
+	return got
+}
+
+func TestHandleSlashCommand_SubscribeTogglesDMDelivery(t *testing.T) {
+	repo := &mockRepository{}
+	manager := newTestManager(repo, &mockDiscordClient{})
+
+	if got := runSubscribeCommand(manager, ""); got != messageEphemeralSubscriptionStatusOff {
+		t.Fatalf("unexpected initial status: %q", got)
+	}
+	if got := runSubscribeCommand(manager, settingChoiceOn); got != messageEphemeralSubscribed {
+		t.Fatalf("unexpected subscribe response: %q", got)
+	}
+	if !repo.subscriptions["guild-1:user-1"] {
+		t.Fatal("expected subscription to be stored")
+	}
+	if got := runSubscribeCommand(manager, ""); got != messageEphemeralSubscriptionStatusOn {
+		t.Fatalf("unexpected status after subscribe: %q", got)
+	}
+	if got := runSubscribeCommand(manager, settingChoiceOff); got != messageEphemeralUnsubscribed {
+		t.Fatalf("unexpected unsubscribe response: %q", got)
+	}
+	if repo.subscriptions["guild-1:user-1"] {
+		t.Fatal("expected subscription to be removed")
+	}
+}
+
+func TestFinalizeSession_DeliversTranscriptToSubscribedParticipants(t *testing.T) {
+	repo := &mockRepository{
+		subscriptions: map[string]bool{"guild-1:user-1": true, "guild-1:bot-1": true, "guild-1:user-3": true},
+		participantsBySession: map[string][]repository.SessionParticipant{
+			"session-dm": {
+				{SessionID: "session-dm", UserID: "user-1"},
+				{SessionID: "session-dm", UserID: "user-2"},
+				{SessionID: "session-dm", UserID: "bot-1", IsBot: true},
+			},
+		},
+	}
+	dc := &mockDiscordClient{}
+	manager := newTestManager(repo, dc)
+	manager.sessions[manager.sessionKey("guild-1", "vc-1")] = &runningSession{
+		repoSession:        &repository.Session{ID: "session-dm", GuildID: "guild-1", ChannelID: "vc-1", StartedAt: time.Now(), Status: repository.SessionStatusRunning},
+		activeParticipants: map[string]participantState{"user-1": {}},
+		allParticipants:    map[string]participantState{"user-1": {}, "user-2": {}},
+	}
+
+	if _, err := manager.stopSession("guild-1", "vc-1", stopReasonManualSlash); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitUntil(t, time.Second, func() bool { return len(dc.dmCalls) == 1 }, "expected transcript DM")
+	dm := dc.dmCalls[0]
+	if dm.ChannelID != "dm-user-1" || dm.Filename != transcriptFilename("session-dm", 0) || len(dm.FileBody) == 0 {
+		t.Fatalf("unexpected DM: %+v", dm)
+	}
+}
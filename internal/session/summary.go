@@ -0,0 +1,158 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+	"github.com/foxseedlab/mojiokoshin/internal/summarizer"
+)
+
+const (
+	// 長時間の通話でもモデルの文脈長に収まるように、要約に渡す文字数を制限する
+	maxSummaryInputRunes = 60000
+	// 要約に渡す文字起こしで、中盤を省いた位置に入れる行
+	summaryOmittedLineFormat = "[…中略: %d 件の発言…]\n"
+	// Discord の埋め込みの説明文とフィールドの上限
+	maxEmbedDescriptionRunes = 4096
+	maxEmbedFieldValueRunes  = 1024
+)
+
+func (m *Manager) summarizeSessionBestEffort(ctx context.Context, sessionID string, startedAt time.Time, segments []repository.TranscriptSegment) *summarizer.Summary {
+	if m.cfg.SummarizerBaseURL == "" || len(segments) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(m.cfg.SummarizerTimeoutSec)*time.Second)
+	defer cancel()
+	input, omitted := summaryInput(segments, startedAt)
+	if omitted > 0 {
+		slog.Warn("summary input truncated", "session_id", sessionID, "truncated", true, "segments", len(segments), "omitted_segments", omitted, "max_runes", maxSummaryInputRunes)
+	}
+	summary, err := m.summarizer.Summarize(ctx, input)
+	if err != nil {
+		if !errors.Is(err, summarizer.ErrDisabled) {
+			slog.Error("failed to summarize session", "error", err, "session_id", sessionID)
+		}
+		return nil
+	}
+	return summary
+}
+
+// attachSummaryBestEffort は保存済みの成果物と Webhook に要約を付け足し、Discord に追って投稿する
+func (m *Manager) attachSummaryBestEffort(ctx context.Context, sessionID, channelID string, src transcriptSource, filename string, body []byte) {
+	summary := m.summarizeSessionBestEffort(ctx, sessionID, src.StartedAt, src.Segments)
+	if summary == nil {
+		return
+	}
+	m.sendDiscordSummary(sessionID, channelID, summary)
+	src.Summary = summary
+	payload := buildTranscriptWebhookPayload(src)
+	if err := m.repo.SaveTranscriptArtifact(ctx, repository.SaveTranscriptArtifactInput{
+		SessionID:          sessionID,
+		TranscriptFilename: filename,
+		TranscriptText:     string(body),
+		WebhookPayloadJSON: marshalPayloadBestEffort(payload, sessionID),
+		SummaryJSON:        marshalSummaryBestEffort(payload.Summary, sessionID),
+	}); err != nil {
+		slog.Error("failed to save session summary", "error", err, "session_id", sessionID)
+	}
+	m.sendWebhookBestEffort(ctx, sessionID, payload)
+}
+
+// 経過時間付きの発言を並べる。上限を超える場合は結論が含まれやすい終盤を残すため、
+// 冒頭と末尾を半分ずつ残して間を省き、省いた発言の数を返す
+func summaryInput(segments []repository.TranscriptSegment, startedAt time.Time) (string, int) {
+	lines := make([]string, 0, len(segments))
+	total := 0
+	for _, seg := range segments {
+		elapsed := seg.SpokenAt.Sub(startedAt)
+		if elapsed < 0 {
+			elapsed = 0
+		}
+		line := fmt.Sprintf("[%s] %s\n", formatElapsedHMS(elapsed), seg.Content)
+		lines = append(lines, line)
+		total += utf8.RuneCountInString(line)
+	}
+	if total <= maxSummaryInputRunes {
+		return strings.Join(lines, ""), 0
+	}
+
+	budget := maxSummaryInputRunes - utf8.RuneCountInString(fmt.Sprintf(summaryOmittedLineFormat, len(segments)))
+	head, headLength := 0, 0
+	for head < len(lines) {
+		n := utf8.RuneCountInString(lines[head])
+		if headLength+n > budget/2 {
+			break
+		}
+		headLength += n
+		head++
+	}
+	tail, tailLength := len(lines), 0
+	for tail > head {
+		n := utf8.RuneCountInString(lines[tail-1])
+		if headLength+tailLength+n > budget {
+			break
+		}
+		tailLength += n
+		tail--
+	}
+	omitted := tail - head
+	var b strings.Builder
+	for _, line := range lines[:head] {
+		b.WriteString(line)
+	}
+	fmt.Fprintf(&b, summaryOmittedLineFormat, omitted)
+	for _, line := range lines[tail:] {
+		b.WriteString(line)
+	}
+	return b.String(), omitted
+}
+
+func (m *Manager) sendDiscordSummary(sessionID, channelID string, summary *summarizer.Summary) {
+	if summary == nil {
+		return
+	}
+	if _, err := m.discord.SendChannelEmbedMessage(discord.EmbedMessage{
+		ChannelID: channelID,
+		Embeds:    []discord.Embed{summaryEmbed(summary)},
+	}); err != nil {
+		slog.Error("failed to send session summary", "error", err, "session_id", sessionID, "channel_id", channelID)
+	}
+}
+
+func summaryEmbed(summary *summarizer.Summary) discord.Embed {
+	embed := discord.Embed{
+		Title:       messageSummaryTitle,
+		Description: truncateRunes(summary.Summary, maxEmbedDescriptionRunes),
+		Color:       transcriptEmbedColor,
+	}
+	if len(summary.Decisions) > 0 {
+		embed.Fields = append(embed.Fields, discord.EmbedField{Name: messageSummaryDecisions, Value: summaryBulletList(summary.Decisions)})
+	}
+	if len(summary.ActionItems) > 0 {
+		embed.Fields = append(embed.Fields, discord.EmbedField{Name: messageSummaryActionItems, Value: summaryBulletList(summary.ActionItems)})
+	}
+	return embed
+}
+
+func summaryBulletList(items []string) string {
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, "- "+item)
+	}
+	return truncateRunes(strings.Join(lines, "\n"), maxEmbedFieldValueRunes)
+}
+
+func truncateRunes(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,184 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+	"github.com/foxseedlab/mojiokoshin/internal/summarizer"
+)
+
+func newSummaryTestManager(repo *mockRepository, dc *mockDiscordClient, sum *mockSummarizer) *Manager {
+	manager := newTestManager(repo, dc)
+	manager.cfg.SummarizerBaseURL = "http://localhost:11434/v1"
+	manager.cfg.SummarizerTimeoutSec = 5
+	manager.summarizer = sum
+	startedAt := time.Now().Add(-time.Minute)
+	repo.segments = []repository.TranscriptSegment{{SessionID: "session-summary", SpokenAt: startedAt.Add(5 * time.Second), Content: "来週リリースします"}}
+	manager.sessions[manager.sessionKey("guild-1", "vc-1")] = &runningSession{
+		repoSession: &repository.Session{
+			ID:        "session-summary",
+			GuildID:   "guild-1",
+			ChannelID: "vc-1",
+			StartedAt: startedAt,
+			Status:    repository.SessionStatusRunning,
+		},
+		activeParticipants: map[string]participantState{"user-1": {isBot: false}},
+		allParticipants:    map[string]participantState{"user-1": {isBot: false}},
+	}
+	return manager
+}
+
+func TestFinalizeSession_PostsSummaryAfterTranscript(t *testing.T) {
+	repo := &mockRepository{}
+	dc := &mockDiscordClient{}
+	sum := &mockSummarizer{summary: &summarizer.Summary{
+		Summary:     "リリース日程を確認した",
+		Decisions:   []string{"来週リリースする"},
+		ActionItems: []string{"Alice がリリースノートを書く"},
+	}, release: make(chan struct{})}
+	manager := newSummaryTestManager(repo, dc, sum)
+	wh := manager.webhook.(*mockWebhookSender)
+	webhookCount := func() int {
+		wh.mu.Lock()
+		defer wh.mu.Unlock()
+		return len(wh.payloads)
+	}
+
+	if _, err := manager.stopSession("guild-1", "vc-1", stopReasonManualSlash); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 要約を待たずに添付・保存・Webhook を済ませる
+	waitUntil(t, time.Second, func() bool { return webhookCount() == 1 }, "expected webhook before summary")
+	if len(dc.fileCalls) != 1 || len(dc.embedCalls) != 1 {
+		t.Fatalf("expected attachment and stop message only, got files=%d embeds=%d", len(dc.fileCalls), len(dc.embedCalls))
+	}
+	if len(repo.savedOutputCalls) != 1 || repo.savedOutputCalls[0].SummaryJSON != nil {
+		t.Fatalf("expected output without summary, got %+v", repo.savedOutputCalls)
+	}
+	close(sum.release)
+	waitUntil(t, time.Second, func() bool { return webhookCount() == 2 }, "expected webhook with summary")
+
+	if len(sum.inputs) != 1 || !strings.Contains(sum.inputs[0], "[00:00:05] 来週リリースします") {
+		t.Fatalf("unexpected summarizer input: %q", sum.inputs)
+	}
+	if len(dc.embedCalls) != 2 || len(dc.embedCalls[1].Embeds) != 1 {
+		t.Fatalf("expected stop message and summary embed, got %+v", dc.embedCalls)
+	}
+	embed := dc.embedCalls[1].Embeds[0]
+	if embed.Description != "リリース日程を確認した" || len(embed.Fields) != 2 || embed.Fields[1].Value != "- Alice がリリースノートを書く" {
+		t.Fatalf("unexpected summary embed: %+v", embed)
+	}
+	repo.mu.Lock()
+	artifacts := append([]repository.SaveTranscriptArtifactInput(nil), repo.savedArtifactCalls...)
+	repo.mu.Unlock()
+	if len(artifacts) != 1 {
+		t.Fatalf("expected summary to be saved to the artifact, got %d", len(artifacts))
+	}
+	var stored summarizer.Summary
+	if err := json.Unmarshal(artifacts[0].SummaryJSON, &stored); err != nil || stored.Summary != "リリース日程を確認した" {
+		t.Fatalf("unexpected stored summary: %s (%v)", artifacts[0].SummaryJSON, err)
+	}
+	if artifacts[0].TranscriptText != repo.savedOutputCalls[0].TranscriptText {
+		t.Fatal("expected transcript text to be kept")
+	}
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	if wh.payloads[0].Summary != nil {
+		t.Fatalf("expected first webhook without summary, got %+v", wh.payloads[0].Summary)
+	}
+	if wh.payloads[1].Summary == nil || wh.payloads[1].Summary.Decisions[0] != "来週リリースする" {
+		t.Fatalf("unexpected webhook summary: %+v", wh.payloads[1].Summary)
+	}
+}
+
+func TestStopAllSessions_SkipsSummary(t *testing.T) {
+	repo := &mockRepository{}
+	dc := &mockDiscordClient{}
+	sum := &mockSummarizer{summary: &summarizer.Summary{Summary: "要約"}}
+	manager := newSummaryTestManager(repo, dc, sum)
+
+	if stopped := manager.StopAllSessions(StopReasonServerClosed); stopped != 1 {
+		t.Fatalf("stopped = %d", stopped)
+	}
+
+	if len(dc.fileCalls) != 1 || len(repo.savedOutputCalls) != 1 {
+		t.Fatalf("expected transcript to be delivered, got files=%d outputs=%d", len(dc.fileCalls), len(repo.savedOutputCalls))
+	}
+	if len(sum.inputs) != 0 {
+		t.Fatalf("expected no summary on shutdown, got %q", sum.inputs)
+	}
+}
+
+func TestFinalizeSession_AttachesTranscriptWhenSummaryFails(t *testing.T) {
+	repo := &mockRepository{}
+	dc := &mockDiscordClient{}
+	manager := newSummaryTestManager(repo, dc, &mockSummarizer{err: errors.New("model not loaded")})
+
+	if _, err := manager.stopSession("guild-1", "vc-1", stopReasonManualSlash); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitUntil(t, time.Second, func() bool { return len(dc.fileCalls) == 1 }, "expected transcript attachment")
+	if len(dc.embedCalls) != 1 {
+		t.Fatalf("expected only the stop message, got %+v", dc.embedCalls)
+	}
+	if len(repo.savedOutputCalls) != 1 || repo.savedOutputCalls[0].SummaryJSON != nil {
+		t.Fatalf("expected output without summary, got %+v", repo.savedOutputCalls)
+	}
+}
+
+func TestSummaryEmbed_TruncatesLongContent(t *testing.T) {
+	embed := summaryEmbed(&summarizer.Summary{
+		Summary:   strings.Repeat("あ", maxEmbedDescriptionRunes+10),
+		Decisions: []string{strings.Repeat("い", maxEmbedFieldValueRunes)},
+	})
+
+	if got := utf8.RuneCountInString(embed.Description); got != maxEmbedDescriptionRunes {
+		t.Fatalf("description length = %d", got)
+	}
+	if len(embed.Fields) != 1 || utf8.RuneCountInString(embed.Fields[0].Value) != maxEmbedFieldValueRunes {
+		t.Fatalf("unexpected fields: %d", len(embed.Fields))
+	}
+}
+
+func TestSummaryInput_KeepsHeadAndTailWhenTooLong(t *testing.T) {
+	startedAt := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	segments := make([]repository.TranscriptSegment, 0, 100)
+	for i := range 100 {
+		segments = append(segments, repository.TranscriptSegment{
+			SpokenAt: startedAt.Add(time.Duration(i) * time.Minute),
+			Content:  fmt.Sprintf("発言%03d %s", i, strings.Repeat("あ", 1000)),
+		})
+	}
+
+	got, omitted := summaryInput(segments, startedAt)
+
+	if omitted == 0 || omitted == len(segments) {
+		t.Fatalf("unexpected omitted count: %d", omitted)
+	}
+	if utf8.RuneCountInString(got) > maxSummaryInputRunes {
+		t.Fatalf("input length = %d", utf8.RuneCountInString(got))
+	}
+	if !strings.Contains(got, "発言000") || !strings.Contains(got, "発言099") {
+		t.Fatal("expected both the beginning and the end of the session to be kept")
+	}
+	if !strings.Contains(got, fmt.Sprintf(summaryOmittedLineFormat, omitted)) {
+		t.Fatal("expected an omission marker")
+	}
+}
+
+func TestSummaryInput_KeepsShortTranscript(t *testing.T) {
+	startedAt := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	segments := []repository.TranscriptSegment{{SpokenAt: startedAt.Add(5 * time.Second), Content: "来週リリースします"}}
+
+	got, omitted := summaryInput(segments, startedAt)
+
+	if omitted != 0 || got != "[00:00:05] 来週リリースします\n" {
+		t.Fatalf("unexpected input: %q omitted=%d", got, omitted)
+	}
+}
@@ -0,0 +1,17 @@
+package session
+
+import (
+	"context"
+
+	"github.com/foxseedlab/mojiokoshin/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/foxseedlab/mojiokoshin/internal/session")
+
+// startSessionSpan はセッションIDを属性に付けたスパンを開始する
+func startSessionSpan(ctx context.Context, name, sessionID string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(append(attrs, tracing.SessionID(sessionID))...))
+}
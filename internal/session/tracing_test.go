@@ -0,0 +1,59 @@
+package session
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+	"github.com/foxseedlab/mojiokoshin/internal/tracing"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing_RecordsStartAndFinalizeSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := tracer
+	tracer = provider.Tracer("test")
+	t.Cleanup(func() { tracer = previous })
+
+	repo := &mockRepository{}
+	dc := &mockDiscordClient{userVoiceChannelByID: map[string]string{"user-1": "vc-1"}}
+	manager := newTestManager(repo, dc)
+	manager.SetBotUserID("bot-self")
+	for _, name := range []string{commandMojiokoshi, commandMojiokoshiStop} {
+		manager.HandleSlashCommand(discord.SlashCommandEvent{
+			GuildID:          "guild-1",
+			CommandName:      name,
+			UserID:           "user-1",
+			RespondEphemeral: func(string) error { return nil },
+		})
+	}
+
+	endedNames := func() []string {
+		var names []string
+		for _, span := range recorder.Ended() {
+			names = append(names, span.Name())
+		}
+		return names
+	}
+	waitUntil(t, time.Second, func() bool { return slices.Contains(endedNames(), "session.finalize") }, "expected finalize span")
+
+	sessionIDs := map[string]string{}
+	for _, span := range recorder.Ended() {
+		for _, attr := range span.Attributes() {
+			if attr.Key == tracing.SessionIDKey {
+				sessionIDs[span.Name()] = attr.Value.AsString()
+			}
+		}
+	}
+	for _, name := range []string{"session.start", "session.finalize", "session.list_segments", "session.resolve_metadata", "session.send_attachment", "session.save_output", "session.send_webhook"} {
+		if sessionIDs[name] == "" || sessionIDs[name] != sessionIDs["session.start"] {
+			t.Fatalf("span %q should carry the session id: %v (ended: %v)", name, sessionIDs, endedNames())
+		}
+	}
+	if !slices.Contains(endedNames(), "session.join_voice") {
+		t.Fatalf("expected join voice span: %v", endedNames())
+	}
+}
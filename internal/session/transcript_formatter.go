@@ -8,41 +8,172 @@ import (
 
 	"github.com/foxseedlab/mojiokoshin/internal/discord"
 	"github.com/foxseedlab/mojiokoshin/internal/repository"
+	"github.com/foxseedlab/mojiokoshin/internal/summarizer"
 	"github.com/foxseedlab/mojiokoshin/internal/webhook"
 )
 
 // 変更容易性を高めるため、time.DateTime をあえて指定していない
 const transcriptTimeLayout = "2006-01-02 15:04:05"
 
-func buildTranscriptText(meta discord.TranscriptMetadata, startedAt, endedAt time.Time, timezone string, loc *time.Location, segments []repository.TranscriptSegment) []byte {
-	participants := canonicalParticipants(meta.Participants)
+const (
+	lowConfidenceOpen   = "〔"
+	lowConfidenceClose  = "〕"
+	lowConfidenceLegend = "〔〕内は認識の信頼度が低い語句です"
+	// 経過時間の幅だけ字下げして、翻訳がどの発言のものか分かるようにする
+	transcriptTranslationLineFormat = "         [%s] %s"
+
+	transcriptParticipantsLabel     = "参加者："
+	transcriptParticipantsSeparator = "、"
+	transcriptStartedByLabel        = "開始した人："
+	transcriptStartTriggerLabel     = "開始方法："
+	transcriptStoppedByLabel        = "中止した人："
+)
+
+type transcriptSource struct {
+	SessionID string
+	Meta      discord.TranscriptMetadata
+	StartedAt time.Time
+	EndedAt   time.Time
+	Timezone  string
+	Location  *time.Location
+	Segments  []repository.TranscriptSegment
+	Revision  transcriptRevisionInfo
+	// 手動修正を反映して再送する場合に true
+	IsCorrection bool
+	// 0 の場合は信頼度の低い語句を強調しない
+	LowConfidenceThreshold float64
+	// 要約しない場合や要約に失敗した場合は nil
+	Summary *summarizer.Summary
+	// 記録を始める前のセッションや自動で終了した場合は空になる
+	StartedByUserID string
+	StartTrigger    repository.StartTrigger
+	StoppedByUserID string
+}
+
+type transcriptRevisionInfo struct {
+	Number   int
+	Language string
+	Model    string
+}
+
+func buildTranscriptText(src transcriptSource) []byte {
+	participants := canonicalParticipants(src.Meta.Participants)
 	names := make([]string, 0, len(participants))
 	for _, p := range participants {
 		names = append(names, p.DisplayName)
 	}
 
-	startText := startedAt.In(safeLocation(loc)).Format(transcriptTimeLayout)
-	endText := endedAt.In(safeLocation(loc)).Format(transcriptTimeLayout)
+	startText := src.StartedAt.In(safeLocation(src.Location)).Format(transcriptTimeLayout)
+	endText := src.EndedAt.In(safeLocation(src.Location)).Format(transcriptTimeLayout)
 
 	lines := []string{
-		fmt.Sprintf("サーバー名：%s", meta.DiscordServerName),
-		fmt.Sprintf("ボイスチャンネル名：%s", meta.DiscordVoiceChannelName),
-		fmt.Sprintf("ボイスチャット期間：%s ~ %s（%s）", startText, endText, timezone),
-		fmt.Sprintf("参加者：%s", strings.Join(names, "、")),
-		"",
-	}
-	for _, seg := range segments {
-		elapsed := seg.SpokenAt.Sub(startedAt)
+		fmt.Sprintf("サーバー名：%s", src.Meta.DiscordServerName),
+		fmt.Sprintf("ボイスチャンネル名：%s", src.Meta.DiscordVoiceChannelName),
+		fmt.Sprintf("ボイスチャット期間：%s ~ %s（%s）", startText, endText, src.Timezone),
+		transcriptParticipantsLabel + strings.Join(names, transcriptParticipantsSeparator),
+	}
+	lines = append(lines, transcriptOperatorLines(src)...)
+	if src.Revision.Number > 0 {
+		lines = append(lines, transcriptRevisionLine(src.Revision))
+	}
+	segmentLines := make([]string, 0, len(src.Segments))
+	anyHighlighted := false
+	for _, seg := range src.Segments {
+		elapsed := seg.SpokenAt.Sub(src.StartedAt)
 		if elapsed < 0 {
 			elapsed = 0
 		}
-		lines = append(lines, fmt.Sprintf("%s %s", formatElapsedHMS(elapsed), seg.Content))
+		content, highlighted := highlightLowConfidenceWords(seg, src.LowConfidenceThreshold)
+		anyHighlighted = anyHighlighted || highlighted
+		segmentLines = append(segmentLines, fmt.Sprintf("%s %s", formatElapsedHMS(elapsed), content))
+		for _, t := range seg.Translations {
+			segmentLines = append(segmentLines, fmt.Sprintf(transcriptTranslationLineFormat, t.Language, t.Text))
+		}
 	}
+	if anyHighlighted {
+		lines = append(lines, lowConfidenceLegend)
+	}
+	lines = append(lines, "")
+	lines = append(lines, segmentLines...)
 	return []byte(strings.Join(lines, "\n"))
 }
 
-func buildTranscriptWebhookPayload(sessionID string, meta discord.TranscriptMetadata, startedAt, endedAt time.Time, timezone string, loc *time.Location, segments []repository.TranscriptSegment) webhook.TranscriptWebhookPayload {
-	participants := canonicalParticipants(meta.Participants)
+func transcriptOperatorLines(src transcriptSource) []string {
+	var lines []string
+	if src.StartedByUserID != "" {
+		lines = append(lines, transcriptStartedByLabel+participantDisplayName(src.Meta.Participants, src.StartedByUserID))
+	}
+	if src.StartTrigger != "" {
+		lines = append(lines, transcriptStartTriggerLabel+startTriggerLabel(src.StartTrigger))
+	}
+	if src.StoppedByUserID != "" {
+		lines = append(lines, transcriptStoppedByLabel+participantDisplayName(src.Meta.Participants, src.StoppedByUserID))
+	}
+	return lines
+}
+
+// 参加者に見つからない場合はユーザーIDを返す
+func participantDisplayName(participants []discord.TranscriptParticipant, userID string) string {
+	for _, p := range participants {
+		if p.UserID == userID && p.DisplayName != "" {
+			return p.DisplayName
+		}
+	}
+	return userID
+}
+
+// 単語を本文中で前から順に探して囲むため、本文の空白や句読点はそのまま残る。
+// 手動修正済みのセグメントは単語と本文が一致しないので強調しない。
+func highlightLowConfidenceWords(seg repository.TranscriptSegment, threshold float64) (string, bool) {
+	if threshold <= 0 || seg.CorrectedAt != nil || len(seg.Words) == 0 {
+		return seg.Content, false
+	}
+	var b strings.Builder
+	cursor := 0
+	highlighted := false
+	for _, w := range seg.Words {
+		text := strings.TrimSpace(w.Text)
+		i := strings.Index(seg.Content[cursor:], text)
+		if text == "" || i < 0 {
+			continue
+		}
+		start := cursor + i
+		b.WriteString(seg.Content[cursor:start])
+		if w.Confidence > 0 && w.Confidence < threshold {
+			b.WriteString(lowConfidenceOpen + text + lowConfidenceClose)
+			highlighted = true
+		} else {
+			b.WriteString(text)
+		}
+		cursor = start + len(text)
+	}
+	b.WriteString(seg.Content[cursor:])
+	return b.String(), highlighted
+}
+
+func transcriptRevisionLine(rev transcriptRevisionInfo) string {
+	details := make([]string, 0, 2)
+	if rev.Language != "" {
+		details = append(details, "言語："+rev.Language)
+	}
+	if rev.Model != "" {
+		details = append(details, "モデル："+rev.Model)
+	}
+	if len(details) == 0 {
+		return fmt.Sprintf("リビジョン：%d（再文字起こし）", rev.Number)
+	}
+	return fmt.Sprintf("リビジョン：%d（再文字起こし / %s）", rev.Number, strings.Join(details, " / "))
+}
+
+func transcriptFilename(sessionID string, revision int) string {
+	if revision > 0 {
+		return fmt.Sprintf("transcript-%s-r%d.txt", sessionID, revision)
+	}
+	return fmt.Sprintf("transcript-%s.txt", sessionID)
+}
+
+func buildTranscriptWebhookPayload(src transcriptSource) webhook.TranscriptWebhookPayload {
+	participants := canonicalParticipants(src.Meta.Participants)
 	participantNames := make([]string, 0, len(participants))
 	details := make([]webhook.TranscriptWebhookParticipant, 0, len(participants))
 	for _, p := range participants {
@@ -53,55 +184,128 @@ func buildTranscriptWebhookPayload(sessionID string, meta discord.TranscriptMeta
 			IsBot:       p.IsBot,
 		})
 	}
-	transcriptLines := make([]string, 0, len(segments))
-	for _, seg := range segments {
+	transcriptLines := make([]string, 0, len(src.Segments))
+	for _, seg := range src.Segments {
 		transcriptLines = append(transcriptLines, seg.Content)
 	}
 
-	durationSeconds := int64(endedAt.Sub(startedAt).Seconds())
+	durationSeconds := int64(src.EndedAt.Sub(src.StartedAt).Seconds())
 	if durationSeconds < 0 {
 		durationSeconds = 0
 	}
 
+	loc := safeLocation(src.Location)
 	return webhook.TranscriptWebhookPayload{
 		SchemaVersion:           webhook.TranscriptWebhookSchemaVersion,
-		SessionID:               sessionID,
-		DiscordServerID:         meta.DiscordServerID,
-		DiscordServerName:       meta.DiscordServerName,
-		DiscordVoiceChannelID:   meta.DiscordVoiceChannelID,
-		DiscordVoiceChannelName: meta.DiscordVoiceChannelName,
-		StartAt:                 startedAt.In(safeLocation(loc)).Format(time.RFC3339),
-		EndAt:                   endedAt.In(safeLocation(loc)).Format(time.RFC3339),
-		Timezone:                timezone,
+		SessionID:               src.SessionID,
+		Revision:                src.Revision.Number,
+		IsRevision:              src.Revision.Number > 0,
+		IsCorrection:            src.IsCorrection,
+		DiscordServerID:         src.Meta.DiscordServerID,
+		DiscordServerName:       src.Meta.DiscordServerName,
+		DiscordVoiceChannelID:   src.Meta.DiscordVoiceChannelID,
+		DiscordVoiceChannelName: src.Meta.DiscordVoiceChannelName,
+		StartAt:                 src.StartedAt.In(loc).Format(time.RFC3339),
+		EndAt:                   src.EndedAt.In(loc).Format(time.RFC3339),
+		Timezone:                src.Timezone,
 		DurationSeconds:         durationSeconds,
+		StartedByUserID:         src.StartedByUserID,
+		StartTrigger:            string(src.StartTrigger),
+		StoppedByUserID:         src.StoppedByUserID,
 		Participants:            participantNames,
 		ParticipantDetails:      details,
-		SegmentCount:            len(segments),
-		TranscriptSegments:      buildTranscriptWebhookSegments(segments, endedAt, safeLocation(loc)),
+		SegmentCount:            len(src.Segments),
+		TranscriptSegments:      buildTranscriptWebhookSegments(src.Segments, src.EndedAt, loc),
 		Transcript:              strings.Join(transcriptLines, "\n"),
+		Summary:                 buildTranscriptWebhookSummary(src.Summary),
 	}
 }
 
+func buildTranscriptWebhookSummary(summary *summarizer.Summary) *webhook.TranscriptWebhookSummary {
+	if summary == nil {
+		return nil
+	}
+	return &webhook.TranscriptWebhookSummary{
+		Summary:     summary.Summary,
+		Decisions:   nonNilStrings(summary.Decisions),
+		ActionItems: nonNilStrings(summary.ActionItems),
+	}
+}
+
+func nonNilStrings(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+	return items
+}
+
 func buildTranscriptWebhookSegments(segments []repository.TranscriptSegment, sessionEndedAt time.Time, loc *time.Location) []webhook.TranscriptWebhookSegment {
 	out := make([]webhook.TranscriptWebhookSegment, 0, len(segments))
 	for i, seg := range segments {
-		segmentEnd := sessionEndedAt
-		if i+1 < len(segments) {
-			segmentEnd = segments[i+1].SpokenAt
-		}
-		if segmentEnd.Before(seg.SpokenAt) {
-			segmentEnd = seg.SpokenAt
+		segmentEnd := segmentEndedAt(segments, i, sessionEndedAt)
+		confidence, words := seg.Confidence, seg.Words
+		// 修正済みの発言の単語と信頼度は修正前の本文のものなので送らない
+		if seg.CorrectedAt != nil {
+			confidence, words = nil, nil
 		}
 		out = append(out, webhook.TranscriptWebhookSegment{
-			Index:      seg.SegmentIndex,
-			StartAt:    seg.SpokenAt.In(loc).Format(time.RFC3339),
-			EndAt:      segmentEnd.In(loc).Format(time.RFC3339),
-			Transcript: seg.Content,
+			Index:        seg.SegmentIndex,
+			StartAt:      seg.SpokenAt.In(loc).Format(time.RFC3339),
+			EndAt:        segmentEnd.In(loc).Format(time.RFC3339),
+			Transcript:   seg.Content,
+			Corrected:    seg.CorrectedAt != nil,
+			Confidence:   confidence,
+			Words:        buildTranscriptWebhookWords(words, loc),
+			Language:     seg.Language,
+			Translations: buildTranscriptWebhookTranslations(seg.Translations),
 		})
 	}
 	return out
 }
 
+func buildTranscriptWebhookTranslations(translations []repository.SegmentTranslation) []webhook.TranscriptWebhookTranslation {
+	if len(translations) == 0 {
+		return nil
+	}
+	out := make([]webhook.TranscriptWebhookTranslation, 0, len(translations))
+	for _, t := range translations {
+		out = append(out, webhook.TranscriptWebhookTranslation{Language: t.Language, Text: t.Text})
+	}
+	return out
+}
+
+func buildTranscriptWebhookWords(words []repository.SegmentWord, loc *time.Location) []webhook.TranscriptWebhookWord {
+	if len(words) == 0 {
+		return nil
+	}
+	out := make([]webhook.TranscriptWebhookWord, 0, len(words))
+	for _, w := range words {
+		out = append(out, webhook.TranscriptWebhookWord{
+			Word:       w.Text,
+			StartAt:    w.StartAt.In(loc).Format(time.RFC3339Nano),
+			EndAt:      w.EndAt.In(loc).Format(time.RFC3339Nano),
+			Confidence: w.Confidence,
+		})
+	}
+	return out
+}
+
+// 終了時刻を持たない過去のセグメントは、次のセグメントの開始時刻を終了時刻とみなす
+func segmentEndedAt(segments []repository.TranscriptSegment, i int, sessionEndedAt time.Time) time.Time {
+	seg := segments[i]
+	end := sessionEndedAt
+	switch {
+	case seg.EndedAt != nil:
+		end = *seg.EndedAt
+	case i+1 < len(segments):
+		end = segments[i+1].SpokenAt
+	}
+	if end.Before(seg.SpokenAt) {
+		end = seg.SpokenAt
+	}
+	return end
+}
+
 func canonicalParticipants(participants []discord.TranscriptParticipant) []discord.TranscriptParticipant {
 	byUserID := make(map[string]discord.TranscriptParticipant, len(participants))
 	for _, p := range participants {
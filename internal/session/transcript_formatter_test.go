@@ -1,6 +1,7 @@
 package session
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
 	"time"
@@ -22,14 +23,21 @@ func TestBuildTranscriptText(t *testing.T) {
 		{SegmentIndex: 1, SpokenAt: startedAt.Add(75 * time.Second), Content: "よろしくお願いします"},
 	}
 
-	body := string(buildTranscriptText(discord.TranscriptMetadata{
-		DiscordServerName:       "Kemo Server",
-		DiscordVoiceChannelName: "General VC",
-		Participants: []discord.TranscriptParticipant{
-			{UserID: "u2", DisplayName: "Bob"},
-			{UserID: "u1", DisplayName: "Alice"},
+	body := string(buildTranscriptText(transcriptSource{
+		Meta: discord.TranscriptMetadata{
+			DiscordServerName:       "Kemo Server",
+			DiscordVoiceChannelName: "General VC",
+			Participants: []discord.TranscriptParticipant{
+				{UserID: "u2", DisplayName: "Bob"},
+				{UserID: "u1", DisplayName: "Alice"},
+			},
 		},
-	}, startedAt, endedAt, "Asia/Tokyo", loc, segments))
+		StartedAt: startedAt,
+		EndedAt:   endedAt,
+		Timezone:  "Asia/Tokyo",
+		Location:  loc,
+		Segments:  segments,
+	}))
 
 	if !strings.Contains(body, "サーバー名：Kemo Server") {
 		t.Fatalf("server name not found in body: %s", body)
@@ -60,21 +68,176 @@ func TestBuildTranscriptWebhookPayload_SegmentEndAtRules(t *testing.T) {
 	}
 	endedAt := startedAt.Add(45 * time.Second)
 
-	payload := buildTranscriptWebhookPayload("session-1", discord.TranscriptMetadata{
-		DiscordServerID:         "guild-1",
-		DiscordServerName:       "guild",
-		DiscordVoiceChannelID:   "vc-1",
-		DiscordVoiceChannelName: "vc",
-		Participants: []discord.TranscriptParticipant{
-			{UserID: "u2", DisplayName: "bob"},
-			{UserID: "u1", DisplayName: "alice"},
+	payload := buildTranscriptWebhookPayload(transcriptSource{
+		SessionID: "session-1",
+		Meta: discord.TranscriptMetadata{
+			DiscordServerID:         "guild-1",
+			DiscordServerName:       "guild",
+			DiscordVoiceChannelID:   "vc-1",
+			DiscordVoiceChannelName: "vc",
+			Participants: []discord.TranscriptParticipant{
+				{UserID: "u2", DisplayName: "bob"},
+				{UserID: "u1", DisplayName: "alice"},
+			},
 		},
-	}, startedAt, endedAt, "Asia/Tokyo", loc, segments)
+		StartedAt: startedAt,
+		EndedAt:   endedAt,
+		Timezone:  "Asia/Tokyo",
+		Location:  loc,
+		Segments:  segments,
+	})
 
 	assertTranscriptPayloadCore(t, payload, segments, endedAt)
 	assertTranscriptPayloadMetadata(t, payload)
 }
 
+func TestBuildTranscriptText_RevisionHeader(t *testing.T) {
+	startedAt := time.Date(2026, 2, 28, 12, 0, 0, 0, time.UTC)
+	body := string(buildTranscriptText(transcriptSource{
+		StartedAt: startedAt,
+		EndedAt:   startedAt.Add(time.Minute),
+		Timezone:  "UTC",
+		Revision:  transcriptRevisionInfo{Number: 2, Language: "en-US", Model: "long"},
+	}))
+	if !strings.Contains(body, "リビジョン：2（再文字起こし / 言語：en-US / モデル：long）") {
+		t.Fatalf("revision line not found in body: %s", body)
+	}
+	if got := transcriptFilename("session-1", 2); got != "transcript-session-1-r2.txt" {
+		t.Fatalf("unexpected revision filename: %s", got)
+	}
+	if got := transcriptFilename("session-1", 0); got != "transcript-session-1.txt" {
+		t.Fatalf("unexpected original filename: %s", got)
+	}
+}
+
+func TestBuildTranscriptText_OperatorLines(t *testing.T) {
+	startedAt := time.Date(2026, 2, 28, 12, 0, 0, 0, time.UTC)
+	src := transcriptSource{
+		Meta: discord.TranscriptMetadata{Participants: []discord.TranscriptParticipant{
+			{UserID: "u1", DisplayName: "Alice"},
+		}},
+		StartedAt:       startedAt,
+		EndedAt:         startedAt.Add(time.Minute),
+		Timezone:        "UTC",
+		StartedByUserID: "u1",
+		StartTrigger:    repository.StartTriggerAuto,
+		StoppedByUserID: "u9",
+	}
+	body := string(buildTranscriptText(src))
+	if !strings.Contains(body, "開始した人：Alice\n開始方法：自動\n中止した人：u9\n") {
+		t.Fatalf("operator lines not found in body: %s", body)
+	}
+
+	src.StartedByUserID, src.StartTrigger, src.StoppedByUserID = "", "", ""
+	body = string(buildTranscriptText(src))
+	if strings.Contains(body, transcriptStartedByLabel) || strings.Contains(body, transcriptStartTriggerLabel) || strings.Contains(body, transcriptStoppedByLabel) {
+		t.Fatalf("expected no operator lines for unknown operator: %s", body)
+	}
+}
+
+func TestBuildTranscriptText_HighlightsLowConfidenceWords(t *testing.T) {
+	startedAt := time.Date(2026, 2, 28, 12, 0, 0, 0, time.UTC)
+	correctedAt := startedAt.Add(time.Minute)
+	words := []repository.SegmentWord{
+		{Text: "see", Confidence: 0.9},
+		{Text: "you", Confidence: 0.3},
+		{Text: "tomorrow", Confidence: 0.8},
+	}
+	src := transcriptSource{
+		StartedAt: startedAt,
+		EndedAt:   startedAt.Add(time.Minute),
+		Timezone:  "UTC",
+		Segments: []repository.TranscriptSegment{
+			{SegmentIndex: 0, SpokenAt: startedAt, Content: "see you tomorrow.", Words: words},
+			{SegmentIndex: 1, SpokenAt: startedAt, Content: "see ya tomorrow.", Words: words, CorrectedAt: &correctedAt},
+		},
+		LowConfidenceThreshold: 0.5,
+	}
+
+	body := string(buildTranscriptText(src))
+	if !strings.Contains(body, "00:00:00 see 〔you〕 tomorrow.") {
+		t.Fatalf("low confidence word not highlighted: %s", body)
+	}
+	if !strings.Contains(body, "00:00:00 see ya tomorrow.") {
+		t.Fatalf("corrected segment should not be highlighted: %s", body)
+	}
+	if !strings.Contains(body, lowConfidenceLegend) {
+		t.Fatalf("legend not found in body: %s", body)
+	}
+
+	src.LowConfidenceThreshold = 0
+	if body := string(buildTranscriptText(src)); strings.Contains(body, lowConfidenceOpen) || strings.Contains(body, lowConfidenceLegend) {
+		t.Fatalf("expected no highlight when threshold is disabled: %s", body)
+	}
+}
+
+func TestBuildTranscriptWebhookPayload_IncludesConfidenceAndWords(t *testing.T) {
+	startedAt := time.Date(2026, 2, 28, 12, 0, 0, 0, time.UTC)
+	confidence := 0.87
+	endedAt := startedAt.Add(1500 * time.Millisecond)
+	payload := buildTranscriptWebhookPayload(transcriptSource{
+		StartedAt: startedAt,
+		EndedAt:   startedAt.Add(time.Minute),
+		Timezone:  "UTC",
+		Segments: []repository.TranscriptSegment{{
+			SpokenAt:   startedAt,
+			EndedAt:    &endedAt,
+			Content:    "hello",
+			Confidence: &confidence,
+			Words:      []repository.SegmentWord{{Text: "hello", StartAt: startedAt, EndAt: endedAt, Confidence: 0.87}},
+			Language:   "en-us",
+		}},
+	})
+
+	seg := payload.TranscriptSegments[0]
+	if seg.Confidence == nil || *seg.Confidence != confidence {
+		t.Fatalf("unexpected confidence: %v", seg.Confidence)
+	}
+	if seg.EndAt != endedAt.Format(time.RFC3339) {
+		t.Fatalf("expected stored end time to be used, got %s", seg.EndAt)
+	}
+	if len(seg.Words) != 1 || seg.Words[0].Word != "hello" || seg.Words[0].EndAt != "2026-02-28T12:00:01.5Z" {
+		t.Fatalf("unexpected words: %+v", seg.Words)
+	}
+	if seg.Language != "en-us" {
+		t.Fatalf("unexpected language: %q", seg.Language)
+	}
+}
+
+func TestBuildTranscriptWebhookPayload_CorrectedSegmentHidesOriginalWords(t *testing.T) {
+	startedAt := time.Date(2026, 2, 28, 12, 0, 0, 0, time.UTC)
+	confidence := 0.42
+	correctedAt := startedAt.Add(time.Hour)
+	src := transcriptSource{
+		StartedAt:              startedAt,
+		EndedAt:                startedAt.Add(time.Minute),
+		Timezone:               "UTC",
+		LowConfidenceThreshold: 0.5,
+		Segments: []repository.TranscriptSegment{{
+			SpokenAt:    startedAt,
+			Content:     "山中さんです",
+			CorrectedAt: &correctedAt,
+			Confidence:  &confidence,
+			Words:       []repository.SegmentWord{{Text: "田中", StartAt: startedAt, EndAt: startedAt.Add(time.Second), Confidence: 0.42}},
+		}},
+	}
+
+	payload := buildTranscriptWebhookPayload(src)
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("failed to marshal payload: %v", err)
+	}
+	if strings.Contains(string(body), "田中") {
+		t.Fatalf("expected original word text to be hidden: %s", body)
+	}
+	if seg := payload.TranscriptSegments[0]; !seg.Corrected || seg.Confidence != nil || len(seg.Words) != 0 {
+		t.Fatalf("unexpected corrected segment: %+v", seg)
+	}
+	if text := string(buildTranscriptText(src)); strings.Contains(text, "田中") || !strings.Contains(text, "山中さんです") {
+		t.Fatalf("unexpected transcript text: %s", text)
+	}
+}
+
 func assertTranscriptPayloadCore(t *testing.T, payload webhook.TranscriptWebhookPayload, segments []repository.TranscriptSegment, endedAt time.Time) {
 	if payload.SchemaVersion != "2026-02-28" {
 		t.Fatalf("unexpected schema_version: %s", payload.SchemaVersion)
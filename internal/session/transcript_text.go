@@ -0,0 +1,72 @@
+package session
+
+import (
+	"context"
+
+	"github.com/foxseedlab/mojiokoshin/internal/config"
+	"github.com/foxseedlab/mojiokoshin/internal/textfilter"
+)
+
+type processedTranscriptText struct {
+	content string
+	// raw は監査用に保存する後処理前の文字列。原文を保存しない設定では伏せ字にした文字列になる
+	raw string
+	// 伏せ字にした場合、単語には原文が残り Webhook などにそのまま出てしまうため保存しない。
+	// REDACT_STORE_ORIGINAL は raw のみに関わる
+	keepWords bool
+}
+
+func newRedactor(cfg *config.Config) *textfilter.Redactor {
+	return textfilter.NewRedactor(textfilter.RedactionConfig{
+		PhoneNumbers: cfg.RedactPhoneNumbers,
+		Emails:       cfg.RedactEmails,
+		CreditCards:  cfg.RedactCreditCards,
+		BannedWords:  cfg.RedactBannedWords,
+	})
+}
+
+type guildTextProcessor struct {
+	normalize textfilter.NormalizeOptions
+	replacer  *textfilter.Replacer
+}
+
+// 設定や置換ルールの読み込みに失敗した場合はキャッシュせず、次の発言で読み直す
+func (m *Manager) textProcessor(ctx context.Context, guildID string) guildTextProcessor {
+	m.mu.Lock()
+	cached, ok := m.textProcessors[guildID]
+	m.mu.Unlock()
+	if ok {
+		return cached
+	}
+	settings, settingsLoaded := m.guildSettingsBestEffort(ctx, guildID)
+	replacer, err := m.loadTextReplacer(ctx, guildID)
+	processor := guildTextProcessor{normalize: normalizeOptions(settings), replacer: replacer}
+	if !settingsLoaded || err != nil {
+		return processor
+	}
+	m.mu.Lock()
+	m.textProcessors[guildID] = processor
+	m.mu.Unlock()
+	return processor
+}
+
+func (m *Manager) invalidateTextProcessor(guildID string) {
+	m.mu.Lock()
+	delete(m.textProcessors, guildID)
+	m.mu.Unlock()
+}
+
+// 整形してから置換ルールを適用し、最後に伏せ字にする。置換後の語句が禁止語句であれば伏せられる。
+func (m *Manager) processTranscriptText(ctx context.Context, guildID, text string) processedTranscriptText {
+	processor := m.textProcessor(ctx, guildID)
+	content := processor.replacer.Apply(textfilter.Normalize(text, processor.normalize))
+	content, redacted := m.redactor.Redact(content)
+	if !redacted {
+		return processedTranscriptText{content: content, raw: text, keepWords: true}
+	}
+	if m.cfg.RedactStoreOriginal {
+		return processedTranscriptText{content: content, raw: text}
+	}
+	raw, _ := m.redactor.Redact(text)
+	return processedTranscriptText{content: content, raw: raw}
+}
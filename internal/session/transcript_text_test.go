@@ -0,0 +1,46 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+	"github.com/foxseedlab/mojiokoshin/internal/textfilter"
+	"github.com/foxseedlab/mojiokoshin/internal/transcriber"
+)
+
+func TestHandleTranscriptionResult_RedactsWithoutStoringOriginal(t *testing.T) {
+	repo := &mockRepository{}
+	dc := &mockDiscordClient{}
+	manager := newTestManager(repo, dc)
+	manager.redactor = textfilter.NewRedactor(textfilter.RedactionConfig{PhoneNumbers: true})
+	now := time.Now()
+	timing := segmentTiming{spokenAt: now, endedAt: now, words: []repository.SegmentWord{{Text: "090-1234-5678"}}}
+
+	manager.handleTranscriptionResult("guild-1", "session-1", "vc-1", 0, transcriber.Result{Text: "番号は 090-1234-5678 です", IsFinal: true}, timing)
+
+	got := repo.insertCalls[0]
+	if got.Content != "番号は [電話番号] です" || got.RawContent != got.Content || got.Words != nil {
+		t.Fatalf("expected original text and words to be discarded, got %+v", got)
+	}
+	if dc.sendCalls[0] != "番号は [電話番号] です" {
+		t.Fatalf("unexpected posted text: %q", dc.sendCalls[0])
+	}
+}
+
+func TestHandleTranscriptionResult_RedactsAndKeepsOriginalWhenConfigured(t *testing.T) {
+	repo := &mockRepository{}
+	manager := newTestManager(repo, &mockDiscordClient{})
+	manager.cfg.RedactStoreOriginal = true
+	manager.redactor = textfilter.NewRedactor(textfilter.RedactionConfig{Emails: true})
+	now := time.Now()
+
+	timing := segmentTiming{spokenAt: now, endedAt: now, words: []repository.SegmentWord{{Text: "a@example.com"}}}
+
+	manager.handleTranscriptionResult("guild-1", "session-1", "vc-1", 0, transcriber.Result{Text: "a@example.com まで", IsFinal: true}, timing)
+
+	got := repo.insertCalls[0]
+	if got.Content != "[メールアドレス] まで" || got.RawContent != "a@example.com まで" || got.Words != nil {
+		t.Fatalf("unexpected stored segment: %+v", got)
+	}
+}
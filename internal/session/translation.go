@@ -0,0 +1,182 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+	"github.com/foxseedlab/mojiokoshin/internal/translator"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// 翻訳が詰まっても発言ごとの処理が積み上がらないように、1発言あたりの待ち時間を制限する
+	translateTimeout = 5 * time.Second
+	// 終了処理で翻訳の完了を待つ上限。翻訳と保存・編集にかかる時間に余裕を持たせる
+	finalizeTranslationWaitTimeout = 2 * translateTimeout
+)
+
+// 保存と投稿を済ませた発言。翻訳を付け足すときに使う
+type postedSegment struct {
+	sessionID    string
+	channelID    string
+	segmentIndex int
+	content      string
+	language     string
+	// 投稿に失敗した場合は空になる
+	messageID string
+}
+
+// 翻訳には伏せ字にした後の本文を渡し、原文を外部に送らない。
+// 発言と同じ言語への翻訳は省き、失敗した言語は翻訳なしで続ける。
+func (m *Manager) translateSegment(ctx context.Context, text, sourceLanguage string) []repository.SegmentTranslation {
+	if len(m.cfg.TranslationTargetLanguages) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, translateTimeout)
+	defer cancel()
+	var translations []repository.SegmentTranslation
+	for _, target := range m.cfg.TranslationTargetLanguages {
+		if sameBaseLanguage(sourceLanguage, target) {
+			continue
+		}
+		translated, err := m.translator.Translate(ctx, text, sourceLanguage, target)
+		if err != nil {
+			if !errors.Is(err, translator.ErrDisabled) {
+				slog.Warn("failed to translate transcript segment", "error", err, "target_language", target)
+			}
+			continue
+		}
+		translated = strings.TrimSpace(translated)
+		if translated == "" || translated == text {
+			continue
+		}
+		translations = append(translations, repository.SegmentTranslation{Language: target, Text: translated})
+	}
+	return translations
+}
+
+// segmentTranslations はセッションごとに非同期の翻訳を管理する。
+// 終了処理で閉じた後は新しい翻訳を始めず、待ちきれなかった翻訳は取り消す
+type segmentTranslations struct {
+	mu     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newSegmentTranslations() *segmentTranslations {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &segmentTranslations{ctx: ctx, cancel: cancel}
+}
+
+// 閉じた後に呼ばれた場合は fn を実行せず false を返す
+func (t *segmentTranslations) start(fn func(ctx context.Context)) bool {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return false
+	}
+	t.wg.Add(1)
+	t.mu.Unlock()
+	go func() {
+		defer t.wg.Done()
+		ctx, cancel := context.WithTimeout(t.ctx, finalizeTranslationWaitTimeout)
+		defer cancel()
+		fn(ctx)
+	}()
+	return true
+}
+
+// 成果物に翻訳を含めるため、終了処理の前に実行中の翻訳を timeout まで待ち、残りは取り消す
+func (t *segmentTranslations) closeAndWait(timeout time.Duration) bool {
+	t.mu.Lock()
+	t.closed = true
+	t.mu.Unlock()
+	defer t.cancel()
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// 音声認識の結果を受け取るゴルーチンを翻訳で止めないように、保存・投稿の後で別のゴルーチンで翻訳する
+func (m *Manager) translatePostedSegmentInBackground(pending *segmentTranslations, seg postedSegment) {
+	if len(m.cfg.TranslationTargetLanguages) == 0 {
+		return
+	}
+	started := pending.start(func(ctx context.Context) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				slog.Error("panic while translating transcript segment", "panic", recovered, "session_id", seg.sessionID, "segment_index", seg.segmentIndex)
+			}
+		}()
+		m.translatePostedSegment(ctx, seg)
+	})
+	if !started {
+		slog.Info("skipping translation for segment received after finalization", "session_id", seg.sessionID, "segment_index", seg.segmentIndex)
+	}
+}
+
+// 翻訳を保存してから投稿済みのメッセージに書き足す。途中で修正された発言は修正時の翻訳を優先する
+func (m *Manager) translatePostedSegment(ctx context.Context, seg postedSegment) {
+	ctx, span := startSessionSpan(ctx, "session.segment.translate", seg.sessionID, attribute.Int("segment.index", seg.segmentIndex))
+	defer span.End()
+	translations := m.translateSegment(ctx, seg.content, seg.language)
+	if len(translations) == 0 || ctx.Err() != nil {
+		return
+	}
+	updated, err := m.repo.SetSegmentTranslations(ctx, seg.sessionID, seg.segmentIndex, translations)
+	if err != nil {
+		slog.Error("failed to save segment translations", "error", err, "session_id", seg.sessionID, "segment_index", seg.segmentIndex)
+		return
+	}
+	if !updated || seg.messageID == "" || ctx.Err() != nil {
+		return
+	}
+	if err := m.discord.EditChannelMessage(seg.channelID, seg.messageID, segmentChannelMessage(seg.content, translations)); err != nil {
+		slog.Warn("failed to add translations to transcript message", "error", err, "session_id", seg.sessionID, "message_id", seg.messageID)
+	}
+}
+
+func waitForTranslations(pending *segmentTranslations, sessionID string) {
+	if pending == nil {
+		return
+	}
+	if !pending.closeAndWait(finalizeTranslationWaitTimeout) {
+		slog.Warn("timed out waiting for segment translations; canceled the rest", "session_id", sessionID, "timeout", finalizeTranslationWaitTimeout.String())
+	}
+}
+
+func sameBaseLanguage(a, b string) bool {
+	baseA, _, _ := strings.Cut(a, "-")
+	baseB, _, _ := strings.Cut(b, "-")
+	return baseA != "" && strings.EqualFold(baseA, baseB)
+}
+
+// チャンネルには原文の下に翻訳を小さく添えて投稿する
+func segmentChannelMessage(content string, translations []repository.SegmentTranslation) string {
+	if len(translations) == 0 {
+		return content
+	}
+	lines := []string{content}
+	for _, t := range translations {
+		lines = append(lines, fmt.Sprintf(messageTranslationLineFormat, t.Language, t.Text))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,126 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+	"github.com/foxseedlab/mojiokoshin/internal/transcriber"
+)
+
+type blockingTranslator struct {
+	release chan struct{}
+}
+
+func (b *blockingTranslator) Translate(ctx context.Context, text, _, targetLanguage string) (string, error) {
+	select {
+	case <-b.release:
+		return "[" + targetLanguage + "] " + text, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func TestResultReceiver_PostsOriginalBeforeTranslation(t *testing.T) {
+	repo := &mockRepository{}
+	dc := &mockDiscordClient{}
+	manager := newTestManager(repo, dc)
+	manager.cfg.TranslationTargetLanguages = []string{"en", "ja"}
+	tr := &blockingTranslator{release: make(chan struct{})}
+	manager.translator = tr
+	pending := newSegmentTranslations()
+	receiver := &resultReceiver{manager: manager, guildID: "guild-1", sessionID: "session-1", channelID: "vc-1", translations: pending}
+
+	receiver.OnResult(transcriber.Result{Text: "おはよう", IsFinal: true, Language: "ja-jp"})
+
+	if len(repo.insertCalls) != 1 || len(repo.insertCalls[0].Translations) != 0 {
+		t.Fatalf("expected original to be stored before translation: %+v", repo.insertCalls)
+	}
+	if len(dc.sendCalls) != 1 || dc.sendCalls[0] != "おはよう" {
+		t.Fatalf("expected original to be posted before translation: %+v", dc.sendCalls)
+	}
+	close(tr.release)
+	if !pending.closeAndWait(time.Second) {
+		t.Fatal("timed out waiting for translation")
+	}
+
+	want := []repository.SegmentTranslation{{Language: "en", Text: "[en] おはよう"}}
+	if len(repo.translationUpdates) != 1 || len(repo.insertCalls[0].Translations) != 1 || repo.insertCalls[0].Translations[0] != want[0] {
+		t.Fatalf("unexpected stored translations: %+v", repo.insertCalls)
+	}
+	if len(dc.editCalls) != 1 || dc.editCalls[0] != "message-1:"+segmentChannelMessage("おはよう", want) {
+		t.Fatalf("expected posted message to be edited with translations: %+v", dc.editCalls)
+	}
+}
+
+func TestSegmentTranslations_RejectsWorkAfterCloseAndCancelsOnTimeout(t *testing.T) {
+	repo := &mockRepository{}
+	dc := &mockDiscordClient{}
+	manager := newTestManager(repo, dc)
+	manager.cfg.TranslationTargetLanguages = []string{"en"}
+	manager.translator = &blockingTranslator{release: make(chan struct{})}
+	pending := newSegmentTranslations()
+	receiver := &resultReceiver{manager: manager, guildID: "guild-1", sessionID: "session-1", channelID: "vc-1", translations: pending}
+
+	receiver.OnResult(transcriber.Result{Text: "おはよう", IsFinal: true, Language: "ja-jp"})
+	if pending.closeAndWait(10 * time.Millisecond) {
+		t.Fatal("expected blocked translation to time out")
+	}
+	pending.wg.Wait()
+	if started := pending.start(func(context.Context) {}); started {
+		t.Fatal("expected no new translation after close")
+	}
+
+	if len(repo.translationUpdates) != 0 || len(dc.editCalls) != 0 {
+		t.Fatalf("expected canceled translation to leave the segment untouched: updates=%v edits=%v", repo.translationUpdates, dc.editCalls)
+	}
+}
+
+func TestTranslatePostedSegment_KeepsOriginalWhenTranslationFails(t *testing.T) {
+	repo := &mockRepository{}
+	dc := &mockDiscordClient{}
+	manager := newTestManager(repo, dc)
+	manager.cfg.TranslationTargetLanguages = []string{"en"}
+	manager.translator = &mockTranslator{err: errors.New("quota exceeded")}
+	now := time.Now()
+
+	posted, ok := manager.handleTranscriptionResult("guild-1", "session-1", "vc-1", 0, transcriber.Result{Text: "おはよう", IsFinal: true}, segmentTiming{spokenAt: now, endedAt: now})
+	if !ok {
+		t.Fatal("expected segment to be posted")
+	}
+	manager.translatePostedSegment(context.Background(), posted)
+
+	if len(repo.translationUpdates) != 0 || len(repo.insertCalls) != 1 || len(repo.insertCalls[0].Translations) != 0 {
+		t.Fatalf("unexpected stored translations: %+v", repo.insertCalls)
+	}
+	if len(dc.sendCalls) != 1 || dc.sendCalls[0] != "おはよう" || len(dc.editCalls) != 0 {
+		t.Fatalf("unexpected messages: sent=%+v edited=%+v", dc.sendCalls, dc.editCalls)
+	}
+}
+
+func TestBuildTranscript_IncludesTranslations(t *testing.T) {
+	startedAt := time.Date(2026, 2, 28, 12, 0, 0, 0, time.UTC)
+	src := transcriptSource{
+		StartedAt: startedAt,
+		EndedAt:   startedAt.Add(time.Minute),
+		Timezone:  "UTC",
+		Segments: []repository.TranscriptSegment{{
+			SpokenAt:     startedAt.Add(5 * time.Second),
+			Content:      "おはよう",
+			Translations: []repository.SegmentTranslation{{Language: "en", Text: "Good morning"}},
+		}},
+	}
+
+	body := string(buildTranscriptText(src))
+	if !strings.Contains(body, "00:00:05 おはよう\n         [en] Good morning") {
+		t.Fatalf("expected translation under the segment, got:\n%s", body)
+	}
+	payload := buildTranscriptWebhookPayload(src)
+	got := payload.TranscriptSegments[0].Translations
+	if len(got) != 1 || got[0].Language != "en" || got[0].Text != "Good morning" {
+		t.Fatalf("unexpected webhook translations: %+v", got)
+	}
+}
@@ -0,0 +1,377 @@
+package session
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+)
+
+type ErasureMode string
+
+const (
+	// 参加記録を削除し、保存済みの出力からも名前を取り除く
+	ErasureModeDelete ErasureMode = "delete"
+	// 参加記録は残し、ID と表示名をランダムな仮名に置き換える
+	ErasureModePseudonymize ErasureMode = "pseudonymize"
+
+	pseudonymPrefix = "anonymous-"
+)
+
+var (
+	ErrUserIDRequired       = errors.New("user id is required")
+	ErrInvalidErasureMode   = errors.New("invalid erasure mode")
+	ErrUserInRunningSession = errors.New("user is participating in a running session")
+)
+
+type EraseUserDataResult struct {
+	UserID string
+	// ErasureModeDelete の場合は空になる
+	Pseudonym string
+	Sessions  int
+}
+
+type userDataExportInfo struct {
+	UserID     string `json:"user_id"`
+	ExportedAt string `json:"exported_at"`
+	Timezone   string `json:"timezone"`
+}
+
+type userDataExportSession struct {
+	SessionID   string `json:"session_id"`
+	GuildID     string `json:"guild_id"`
+	GuildName   string `json:"guild_name"`
+	ChannelID   string `json:"channel_id"`
+	ChannelName string `json:"channel_name"`
+	StartAt     string `json:"start_at"`
+	EndAt       string `json:"end_at,omitempty"`
+	DisplayName string `json:"display_name"`
+	IsBot       bool   `json:"is_bot"`
+	FirstSeenAt string `json:"first_seen_at"`
+	LastSeenAt  string `json:"last_seen_at"`
+}
+
+type userDataExportSegmentEdit struct {
+	SessionID    string `json:"session_id"`
+	SegmentIndex int    `json:"segment_index"`
+	Revision     int    `json:"revision"`
+	Version      int    `json:"version"`
+	Content      string `json:"content"`
+	EditedAt     string `json:"edited_at"`
+}
+
+type userDataExportAuditEvent struct {
+	GuildID   string `json:"guild_id"`
+	ChannelID string `json:"channel_id,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+	Action    string `json:"action"`
+	Reason    string `json:"reason,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+type userDataExportPreference struct {
+	GuildID              string `json:"guild_id"`
+	TranscriptSubscribed bool   `json:"transcript_subscribed"`
+	RecordingConsent     string `json:"recording_consent"`
+}
+
+// ExportUserData はユーザーについて保存しているデータを JSON にまとめ、ZIP として w に書き出す
+func (m *Manager) ExportUserData(ctx context.Context, userID string, w io.Writer) error {
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return ErrUserIDRequired
+	}
+	participations, err := m.repo.ListUserParticipations(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("list participations: %w", err)
+	}
+	edits, err := m.repo.ListUserSegmentEdits(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("list segment edits: %w", err)
+	}
+	preferences, err := m.repo.ListUserGuildPreferences(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("list preferences: %w", err)
+	}
+	auditEvents, err := m.repo.ListAuditEvents(ctx, repository.ListAuditEventsInput{ActorUserID: userID})
+	if err != nil {
+		return fmt.Errorf("list audit events: %w", err)
+	}
+
+	zw := zip.NewWriter(w)
+	files := []struct {
+		name string
+		body any
+	}{
+		{"user.json", userDataExportInfo{UserID: userID, ExportedAt: m.exportTime(time.Now()), Timezone: m.cfg.TranscriptTimezone}},
+		{"sessions.json", m.exportSessions(participations)},
+		{"segment_edits.json", m.exportSegmentEdits(edits)},
+		{"preferences.json", exportPreferences(preferences)},
+		{"audit_events.json", m.exportAuditEvents(auditEvents)},
+	}
+	for _, f := range files {
+		if err := writeZipJSON(zw, f.name, f.body); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeZipJSON(zw *zip.Writer, name string, body any) error {
+	fw, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(fw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(body); err != nil {
+		return fmt.Errorf("encode %s: %w", name, err)
+	}
+	return nil
+}
+
+func (m *Manager) exportTime(t time.Time) string {
+	return t.In(safeLocation(m.transcriptLocation)).Format(time.RFC3339)
+}
+
+func (m *Manager) exportSessions(participations []repository.UserParticipation) []userDataExportSession {
+	out := make([]userDataExportSession, 0, len(participations))
+	for _, p := range participations {
+		s := userDataExportSession{
+			SessionID:   p.SessionID,
+			GuildID:     p.GuildID,
+			GuildName:   p.GuildName,
+			ChannelID:   p.ChannelID,
+			ChannelName: p.ChannelName,
+			StartAt:     m.exportTime(p.StartedAt),
+			DisplayName: p.DisplayName,
+			IsBot:       p.IsBot,
+			FirstSeenAt: m.exportTime(p.FirstSeenAt),
+			LastSeenAt:  m.exportTime(p.LastSeenAt),
+		}
+		if p.EndedAt != nil {
+			s.EndAt = m.exportTime(*p.EndedAt)
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func (m *Manager) exportSegmentEdits(edits []repository.UserSegmentEdit) []userDataExportSegmentEdit {
+	out := make([]userDataExportSegmentEdit, 0, len(edits))
+	for _, e := range edits {
+		out = append(out, userDataExportSegmentEdit{
+			SessionID:    e.SessionID,
+			SegmentIndex: e.SegmentIndex,
+			Revision:     e.Revision,
+			Version:      e.Version,
+			Content:      e.Content,
+			EditedAt:     m.exportTime(e.EditedAt),
+		})
+	}
+	return out
+}
+
+func (m *Manager) exportAuditEvents(events []repository.AuditEvent) []userDataExportAuditEvent {
+	out := make([]userDataExportAuditEvent, 0, len(events))
+	for _, e := range events {
+		out = append(out, userDataExportAuditEvent{
+			GuildID:   e.GuildID,
+			ChannelID: e.ChannelID,
+			SessionID: e.SessionID,
+			Action:    string(e.Action),
+			Reason:    e.Reason,
+			CreatedAt: m.exportTime(e.CreatedAt),
+		})
+	}
+	return out
+}
+
+func exportPreferences(preferences []repository.UserGuildPreference) []userDataExportPreference {
+	out := make([]userDataExportPreference, 0, len(preferences))
+	for _, p := range preferences {
+		out = append(out, userDataExportPreference{
+			GuildID:              p.GuildID,
+			TranscriptSubscribed: p.TranscriptSubscribed,
+			RecordingConsent:     string(p.RecordingConsent),
+		})
+	}
+	return out
+}
+
+// EraseUserData はユーザーの参加記録を削除または仮名化し、保存済みの添付ファイルと Webhook Payload の参加者も書き換える。
+// 実行中のセッションに参加している場合は、終了時に書き戻されてしまうためエラーにする。
+func (m *Manager) EraseUserData(ctx context.Context, userID string, mode ErasureMode) (EraseUserDataResult, error) {
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return EraseUserDataResult{}, ErrUserIDRequired
+	}
+	if mode != ErasureModeDelete && mode != ErasureModePseudonymize {
+		return EraseUserDataResult{}, ErrInvalidErasureMode
+	}
+	if m.isInRunningSession(userID) {
+		return EraseUserDataResult{}, ErrUserInRunningSession
+	}
+	result := EraseUserDataResult{UserID: userID}
+	if mode == ErasureModePseudonymize {
+		pseudonym, err := newPseudonym()
+		if err != nil {
+			return result, err
+		}
+		result.Pseudonym = pseudonym
+	}
+	artifacts, err := m.repo.ListUserArtifacts(ctx, userID)
+	if err != nil {
+		return result, fmt.Errorf("list artifacts: %w", err)
+	}
+	for i, a := range artifacts {
+		artifacts[i].TranscriptText = rewriteTranscriptParticipant(a.TranscriptText, a.DisplayName, result.Pseudonym)
+		payload, err := rewriteWebhookPayloadParticipant(a.WebhookPayloadJSON, userID, a.DisplayName, result.Pseudonym)
+		if err != nil {
+			return result, fmt.Errorf("rewrite webhook payload of session %s: %w", a.SessionID, err)
+		}
+		artifacts[i].WebhookPayloadJSON = payload
+	}
+	if err := m.repo.EraseUser(ctx, repository.EraseUserInput{UserID: userID, Pseudonym: result.Pseudonym, Artifacts: artifacts}); err != nil {
+		return result, err
+	}
+	result.Sessions = len(artifacts)
+	slog.Info("user data erased", "mode", mode, "sessions", result.Sessions)
+	// 消去したユーザーを特定できないように、ユーザーIDは記録しない
+	m.recordAudit(ctx, repository.AuditEvent{GuildID: m.cfg.DiscordGuildID, Action: repository.AuditActionUserErase, Reason: string(mode)})
+	return result, nil
+}
+
+func (m *Manager) isInRunningSession(userID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, rs := range m.sessions {
+		if _, ok := rs.allParticipants[userID]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func newPseudonym() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return pseudonymPrefix + hex.EncodeToString(b), nil
+}
+
+// 添付ファイルのヘッダーの参加者一覧と開始・中止した人から名前を取り除く。replacement が空でなければ置き換える
+func rewriteTranscriptParticipant(text, displayName, replacement string) string {
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+	for i, line := range lines {
+		// 本文の発言は書き換えない
+		if line == "" {
+			out = append(out, lines[i:]...)
+			break
+		}
+		if names, ok := strings.CutPrefix(line, transcriptParticipantsLabel); ok {
+			line = transcriptParticipantsLabel + strings.Join(replaceParticipantName(strings.Split(names, transcriptParticipantsSeparator), displayName, replacement), transcriptParticipantsSeparator)
+		} else if label, ok := operatorLineLabel(line, displayName); ok {
+			if replacement == "" {
+				continue
+			}
+			line = label + replacement
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
+
+func operatorLineLabel(line, displayName string) (string, bool) {
+	for _, label := range []string{transcriptStartedByLabel, transcriptStoppedByLabel} {
+		if line == label+displayName {
+			return label, true
+		}
+	}
+	return "", false
+}
+
+func replaceParticipantName(names []string, displayName, replacement string) []string {
+	out := make([]string, 0, len(names))
+	replaced := false
+	for _, name := range names {
+		if !replaced && name == displayName {
+			replaced = true
+			if replacement == "" {
+				continue
+			}
+			name = replacement
+		}
+		out = append(out, name)
+	}
+	return out
+}
+
+// 既知の項目以外はそのまま残すため、構造体ではなく汎用の JSON として書き換える
+func rewriteWebhookPayloadParticipant(payload []byte, userID, displayName, replacement string) ([]byte, error) {
+	if len(payload) == 0 {
+		return payload, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if names, ok := doc["participants"].([]any); ok {
+		doc["participants"] = replaceParticipantNameValues(names, displayName, replacement)
+	}
+	if details, ok := doc["participant_details"].([]any); ok {
+		doc["participant_details"] = replaceParticipantDetails(details, userID, replacement)
+	}
+	for _, key := range []string{"started_by_user_id", "stopped_by_user_id"} {
+		if doc[key] == userID {
+			doc[key] = replacement
+		}
+	}
+	return json.Marshal(doc)
+}
+
+func replaceParticipantNameValues(values []any, displayName, replacement string) []any {
+	names := make([]string, 0, len(values))
+	for _, v := range values {
+		name, _ := v.(string)
+		names = append(names, name)
+	}
+	replaced := replaceParticipantName(names, displayName, replacement)
+	out := make([]any, 0, len(replaced))
+	for _, name := range replaced {
+		out = append(out, name)
+	}
+	return out
+}
+
+func replaceParticipantDetails(details []any, userID, replacement string) []any {
+	out := make([]any, 0, len(details))
+	for _, d := range details {
+		detail, ok := d.(map[string]any)
+		if !ok || detail["user_id"] != userID {
+			out = append(out, d)
+			continue
+		}
+		if replacement == "" {
+			continue
+		}
+		detail["user_id"] = replacement
+		detail["display_name"] = replacement
+		out = append(out, detail)
+	}
+	return out
+}
@@ -0,0 +1,166 @@
+package session
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/foxseedlab/mojiokoshin/internal/repository"
+)
+
+func TestExportUserData_WritesZipWithAllFiles(t *testing.T) {
+	startedAt := time.Date(2026, 4, 1, 10, 0, 0, 0, time.UTC)
+	repo := &mockRepository{
+		userParticipations: []repository.UserParticipation{{
+			SessionID: "session-1", GuildID: "guild-1", ChannelID: "vc-1", StartedAt: startedAt,
+			DisplayName: "Alice", FirstSeenAt: startedAt, LastSeenAt: startedAt.Add(time.Hour),
+		}},
+		userSegmentEdits: []repository.UserSegmentEdit{{SessionID: "session-1", SegmentIndex: 2, Version: 1, Content: "修正後", EditedAt: startedAt}},
+		userPreferences:  []repository.UserGuildPreference{{GuildID: "guild-1", TranscriptSubscribed: true, RecordingConsent: repository.RecordingConsentDenied}},
+		auditEvents: []repository.AuditEvent{
+			{GuildID: "guild-1", ActorUserID: "user-1", Action: repository.AuditActionConfigUpdate, Reason: "mojiokoshi-settings language=en-US", CreatedAt: startedAt},
+			{GuildID: "guild-1", ActorUserID: "user-2", Action: repository.AuditActionConfigUpdate, CreatedAt: startedAt},
+		},
+	}
+	manager := newTestManager(repo, &mockDiscordClient{})
+
+	var buf bytes.Buffer
+	if err := manager.ExportUserData(context.Background(), "user-1", &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		files[f.Name] = string(body)
+	}
+	for _, name := range []string{"user.json", "sessions.json", "segment_edits.json", "preferences.json", "audit_events.json"} {
+		if _, ok := files[name]; !ok {
+			t.Fatalf("expected %s in export, got %v", name, files)
+		}
+	}
+	var sessions []userDataExportSession
+	if err := json.Unmarshal([]byte(files["sessions.json"]), &sessions); err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].DisplayName != "Alice" || sessions[0].EndAt != "" {
+		t.Fatalf("unexpected sessions: %+v", sessions)
+	}
+	if !strings.Contains(files["segment_edits.json"], "修正後") || !strings.Contains(files["preferences.json"], `"denied"`) {
+		t.Fatalf("unexpected export: %v", files)
+	}
+	var auditEvents []userDataExportAuditEvent
+	if err := json.Unmarshal([]byte(files["audit_events.json"]), &auditEvents); err != nil {
+		t.Fatal(err)
+	}
+	if len(auditEvents) != 1 || auditEvents[0].Reason != "mojiokoshi-settings language=en-US" {
+		t.Fatalf("unexpected audit events: %+v", auditEvents)
+	}
+}
+
+func TestExportUserData_RequiresUserID(t *testing.T) {
+	manager := newTestManager(&mockRepository{}, &mockDiscordClient{})
+	if err := manager.ExportUserData(context.Background(), " ", io.Discard); !errors.Is(err, ErrUserIDRequired) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func newErasureFixture() *mockRepository {
+	return &mockRepository{
+		userArtifacts: []repository.UserArtifact{{
+			SessionID:          "session-1",
+			DisplayName:        "Alice",
+			TranscriptText:     "タイトル\n参加者：Alice、Bob\n開始した人：Alice\n中止した人：Bob\n\n[00:00:01] Alice: こんにちは\n開始した人：Alice",
+			WebhookPayloadJSON: []byte(`{"session_id":"session-1","started_by_user_id":"user-1","stopped_by_user_id":"user-2","participants":["Alice","Bob"],"participant_details":[{"user_id":"user-1","display_name":"Alice"},{"user_id":"user-2","display_name":"Bob"}],"duration_seconds":12}`),
+		}},
+	}
+}
+
+func TestEraseUserData_Delete(t *testing.T) {
+	repo := newErasureFixture()
+	manager := newTestManager(repo, &mockDiscordClient{})
+
+	result, err := manager.EraseUserData(context.Background(), "user-1", ErasureModeDelete)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Pseudonym != "" || result.Sessions != 1 || len(repo.eraseCalls) != 1 {
+		t.Fatalf("unexpected result: %+v calls=%+v", result, repo.eraseCalls)
+	}
+	artifact := repo.eraseCalls[0].Artifacts[0]
+	if !strings.HasPrefix(artifact.TranscriptText, "タイトル\n参加者：Bob\n中止した人：Bob\n\n") {
+		t.Fatalf("expected participant to be removed from header: %q", artifact.TranscriptText)
+	}
+	// 本文は書き換えない
+	if !strings.HasSuffix(artifact.TranscriptText, "\n開始した人：Alice") {
+		t.Fatalf("expected body to be kept: %q", artifact.TranscriptText)
+	}
+	payload := string(artifact.WebhookPayloadJSON)
+	if strings.Contains(payload, "user-1") || strings.Contains(payload, `"Alice"`) {
+		t.Fatalf("expected participant to be removed from payload: %s", payload)
+	}
+	if !strings.Contains(payload, `"duration_seconds":12`) {
+		t.Fatalf("expected other fields to be kept: %s", payload)
+	}
+}
+
+func TestEraseUserData_Pseudonymize(t *testing.T) {
+	repo := newErasureFixture()
+	manager := newTestManager(repo, &mockDiscordClient{})
+
+	result, err := manager.EraseUserData(context.Background(), "user-1", ErasureModePseudonymize)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(result.Pseudonym, pseudonymPrefix) || repo.eraseCalls[0].Pseudonym != result.Pseudonym {
+		t.Fatalf("unexpected pseudonym: %+v", result)
+	}
+	artifact := repo.eraseCalls[0].Artifacts[0]
+	if !strings.Contains(artifact.TranscriptText, "参加者："+result.Pseudonym+"、Bob\n開始した人："+result.Pseudonym+"\n") {
+		t.Fatalf("expected participant to be pseudonymized: %q", artifact.TranscriptText)
+	}
+	if strings.Count(string(artifact.WebhookPayloadJSON), result.Pseudonym) != 4 {
+		t.Fatalf("expected name and id to be pseudonymized: %s", artifact.WebhookPayloadJSON)
+	}
+}
+
+func TestEraseUserData_Rejected(t *testing.T) {
+	repo := newErasureFixture()
+	manager := newTestManager(repo, &mockDiscordClient{})
+	manager.sessions[manager.sessionKey("guild-1", "vc-1")] = &runningSession{
+		repoSession:     &repository.Session{ID: "session-2", GuildID: "guild-1", ChannelID: "vc-1", Status: repository.SessionStatusRunning},
+		allParticipants: map[string]participantState{"user-1": {}},
+	}
+
+	cases := []struct {
+		userID string
+		mode   ErasureMode
+		want   error
+	}{
+		{"", ErasureModeDelete, ErrUserIDRequired},
+		{"user-2", "wipe", ErrInvalidErasureMode},
+		{"user-1", ErasureModeDelete, ErrUserInRunningSession},
+	}
+	for _, tc := range cases {
+		if _, err := manager.EraseUserData(context.Background(), tc.userID, tc.mode); !errors.Is(err, tc.want) {
+			t.Fatalf("expected %v for %q/%q, got %v", tc.want, tc.userID, tc.mode, err)
+		}
+	}
+	if len(repo.eraseCalls) != 0 {
+		t.Fatalf("expected nothing to be erased: %+v", repo.eraseCalls)
+	}
+}
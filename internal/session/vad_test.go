@@ -0,0 +1,88 @@
+package session
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"testing"
+
+	"github.com/foxseedlab/mojiokoshin/internal/audio"
+	"github.com/foxseedlab/mojiokoshin/internal/discord"
+)
+
+type scriptedMixer struct {
+	mu     sync.Mutex
+	frames [][]byte
+	done   func()
+}
+
+func (m *scriptedMixer) WriteOpusPacket(_ string, _ []byte) {}
+func (m *scriptedMixer) Close()                             {}
+func (m *scriptedMixer) ReadMixedPCM(buf []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.frames) == 0 {
+		m.done()
+		return 0, nil
+	}
+	frame := m.frames[0]
+	m.frames = m.frames[1:]
+	return copy(buf, frame), nil
+}
+
+type countingStreamWriter struct {
+	mu     sync.Mutex
+	writes int
+}
+
+func (w *countingStreamWriter) Write(_ []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writes++
+	return nil
+}
+func (w *countingStreamWriter) Close() error { return nil }
+
+func constantFrame(amplitude int16) []byte {
+	buf := make([]byte, audioFrameBytes)
+	for i := 0; i+1 < len(buf); i += 2 {
+		binary.LittleEndian.PutUint16(buf[i:], uint16(amplitude))
+	}
+	return buf
+}
+
+func TestStreamMixedAudio_VADDropsComfortNoise(t *testing.T) {
+	manager := newTestManager(&mockRepository{}, &mockDiscordClient{})
+	ctx, cancel := context.WithCancel(context.Background())
+	noise := constantFrame(8)
+	speech := constantFrame(4000)
+	mixer := &scriptedMixer{frames: [][]byte{noise, noise, speech, noise, noise, noise}, done: cancel}
+	writer := &countingStreamWriter{}
+	vad := audio.NewVAD(audio.VADConfig{Enabled: true, ThresholdDBFS: -45, Hangover: 2 * audioMixInterval}, audioMixInterval)
+	var packets int64
+
+	manager.streamMixedAudio(ctx, "guild-1", "session-1", mixer, writer, audioPipeline{vad: vad, clock: &audioClock{}}, &packets)
+
+	if writer.writes != 3 {
+		t.Fatalf("expected speech plus two hangover frames to be written, got %d", writer.writes)
+	}
+}
+
+func TestSessionOptionsFromCommand_OverridesVAD(t *testing.T) {
+	manager := newTestManager(&mockRepository{}, &mockDiscordClient{})
+	manager.cfg.VADEnabled = true
+
+	opts, err := manager.sessionOptionsFromCommand(discord.SlashCommandEvent{Options: map[string]string{optionVAD: vadChoiceOff}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.vad.Enabled {
+		t.Fatal("expected vad option to disable VAD for the session")
+	}
+	if opts.vad.ThresholdDBFS != audio.DefaultVADThresholdDBFS {
+		t.Fatalf("expected default threshold fallback, got %f", opts.vad.ThresholdDBFS)
+	}
+	if defaults, _ := manager.sessionOptionsFromCommand(discord.SlashCommandEvent{}); !defaults.vad.Enabled {
+		t.Fatal("expected server default when option is omitted")
+	}
+}
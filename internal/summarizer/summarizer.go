@@ -0,0 +1,19 @@
+package summarizer
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrDisabled = errors.New("summarization is disabled")
+
+type Summary struct {
+	Summary     string   `json:"summary"`
+	Decisions   []string `json:"decisions"`
+	ActionItems []string `json:"action_items"`
+}
+
+type Summarizer interface {
+	// transcript は発言ごとに改行した文字起こしの全文
+	Summarize(ctx context.Context, transcript string) (*Summary, error)
+}
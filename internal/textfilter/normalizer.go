@@ -0,0 +1,78 @@
+package textfilter
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+// NormalizeOptions は音声認識側で整形できない場合に Go 側で行う整形の種類
+type NormalizeOptions struct {
+	// 全角英数字・記号を半角に、半角カナを全角にそろえる
+	Width bool
+	// 連続する空白をまとめ、日本語の文字どうしや日本語と数字の間の空白を取り除く
+	Spacing bool
+	// 全角数字と、数字の間の全角の区切り記号を半角にそろえる
+	Numbers bool
+}
+
+func Normalize(text string, opts NormalizeOptions) string {
+	if opts.Width {
+		text = width.Fold.String(text)
+	}
+	if opts.Numbers {
+		text = normalizeNumbers(text)
+	}
+	if opts.Spacing {
+		text = normalizeSpacing(text)
+	}
+	return text
+}
+
+var numberSeparators = map[rune]rune{'，': ',', '．': '.'}
+
+func normalizeNumbers(text string) string {
+	runes := []rune(text)
+	for i, r := range runes {
+		if r >= '０' && r <= '９' {
+			runes[i] = r - '０' + '0'
+			continue
+		}
+		sep, ok := numberSeparators[r]
+		if ok && i > 0 && i+1 < len(runes) && unicode.IsDigit(runes[i-1]) && unicode.IsDigit(runes[i+1]) {
+			runes[i] = sep
+		}
+	}
+	return string(runes)
+}
+
+func normalizeSpacing(text string) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	var b strings.Builder
+	for i, r := range runes {
+		if r == ' ' && i > 0 && i+1 < len(runes) && joinsWithoutSpace(runes[i-1], runes[i+1]) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func joinsWithoutSpace(before, after rune) bool {
+	switch {
+	case isJapanese(before) && isJapanese(after):
+		return true
+	case isJapanese(before):
+		return unicode.IsDigit(after)
+	case isJapanese(after):
+		return unicode.IsDigit(before)
+	default:
+		return false
+	}
+}
+
+func isJapanese(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) ||
+		r == 'ー' || (r >= '　' && r <= '〿') || (r >= '！' && r <= '／')
+}
@@ -0,0 +1,25 @@
+package textfilter
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		opts NormalizeOptions
+		want string
+	}{
+		{name: "width", text: "ＡＢＣ１２３　ｶﾀｶﾅ", opts: NormalizeOptions{Width: true}, want: "ABC123 カタカナ"},
+		{name: "numbers", text: "価格は１，２００．５円", opts: NormalizeOptions{Numbers: true}, want: "価格は1,200.5円"},
+		{name: "numbers keep japanese comma", text: "１，２、３", opts: NormalizeOptions{Numbers: true}, want: "1,2、3"},
+		{name: "spacing", text: " 今日 は  3 時 から Discord で 会議 。 ", opts: NormalizeOptions{Spacing: true}, want: "今日は3時から Discord で会議。"},
+		{name: "disabled", text: "ＡＢＣ  今日 は", opts: NormalizeOptions{}, want: "ＡＢＣ  今日 は"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.text, tt.opts); got != tt.want {
+				t.Fatalf("Normalize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package textfilter
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	emailMask      = "[メールアドレス]"
+	phoneMask      = "[電話番号]"
+	creditCardMask = "[カード番号]"
+	bannedWordMask = "＊"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// 空白で区切った数字は日付や時刻と見分けられないため、カード番号の区切り方の場合だけまとめる
+	spacedCardPattern = regexp.MustCompile(`\b(?:\d{4}(?: \d{4}){3}(?: \d{1,3})?|\d{4} \d{6} \d{4,5})\b`)
+	numberRunPattern  = regexp.MustCompile(`\+?\d(?:[\d\-]*\d)?`)
+	// 空白・ドット・括弧で区切った電話番号。日付などとまとめないように、+ か 0 で始まる場合だけ区切りを許す
+	phonePattern = regexp.MustCompile(`\+\d{1,3}(?:[ .\-]?\(?\d{1,4}\)?){2,5}|(?:\(0\d{1,4}\)|\b0\d{1,4})(?:[ .\-]?\d{1,4}){2,3}\b`)
+)
+
+type RedactionConfig struct {
+	PhoneNumbers bool
+	Emails       bool
+	CreditCards  bool
+	BannedWords  []string
+}
+
+type Redactor struct {
+	cfg    RedactionConfig
+	banned *regexp.Regexp
+}
+
+// 何も伏せる設定がない場合は nil を返す。nil の Redactor は文字列をそのまま返す。
+func NewRedactor(cfg RedactionConfig) *Redactor {
+	words := make([]string, 0, len(cfg.BannedWords))
+	for _, w := range cfg.BannedWords {
+		if w = strings.TrimSpace(w); w != "" {
+			folded, _ := foldWidth(w)
+			words = append(words, regexp.QuoteMeta(folded))
+		}
+	}
+	if !cfg.PhoneNumbers && !cfg.Emails && !cfg.CreditCards && len(words) == 0 {
+		return nil
+	}
+	r := &Redactor{cfg: cfg}
+	if len(words) > 0 {
+		r.banned = regexp.MustCompile(`(?i)` + strings.Join(words, "|"))
+	}
+	return r
+}
+
+// サーバーの整形設定に関わらず全角の英数字・記号も伏せるため、半角にそろえた文字列で照合し、元の文字列の該当箇所を置き換える
+func (r *Redactor) Redact(text string) (string, bool) {
+	if r == nil {
+		return text, false
+	}
+	out := text
+	if r.cfg.Emails {
+		out = replaceFolded(out, emailPattern, func(string) (string, bool) { return emailMask, true })
+	}
+	if r.cfg.CreditCards {
+		out = replaceFolded(out, spacedCardPattern, r.maskCard)
+	}
+	if r.cfg.PhoneNumbers {
+		out = replaceFolded(out, phonePattern, r.maskPhone)
+	}
+	if r.cfg.PhoneNumbers || r.cfg.CreditCards {
+		out = replaceFolded(out, numberRunPattern, r.maskNumberRun)
+	}
+	if r.banned != nil {
+		out = replaceFolded(out, r.banned, func(w string) (string, bool) {
+			return strings.Repeat(bannedWordMask, utf8.RuneCountInString(w)), true
+		})
+	}
+	return out, out != text
+}
+
+func (r *Redactor) maskCard(run string) (string, bool) {
+	digits := asciiDigits(run)
+	if len(digits) >= 13 && len(digits) <= 19 && luhnValid(digits) {
+		return creditCardMask, true
+	}
+	return run, false
+}
+
+// 桁数とチェックディジットでカード番号を、桁数で電話番号を見分ける
+func (r *Redactor) maskNumberRun(run string) (string, bool) {
+	if r.cfg.CreditCards {
+		if masked, ok := r.maskCard(run); ok {
+			return masked, true
+		}
+	}
+	if r.cfg.PhoneNumbers {
+		return r.maskPhone(run)
+	}
+	return run, false
+}
+
+func (r *Redactor) maskPhone(run string) (string, bool) {
+	if digits := asciiDigits(run); len(digits) >= 10 && len(digits) <= 15 {
+		return phoneMask, true
+	}
+	return run, false
+}
+
+func asciiDigits(run string) []byte {
+	digits := make([]byte, 0, len(run))
+	for i := 0; i < len(run); i++ {
+		if run[i] >= '0' && run[i] <= '9' {
+			digits = append(digits, run[i])
+		}
+	}
+	return digits
+}
+
+// replaceFolded は半角にそろえた text で re に一致した箇所を mask の結果で置き換える。
+// mask が false を返した箇所は元の文字列のまま残す
+func replaceFolded(text string, re *regexp.Regexp, mask func(folded string) (string, bool)) string {
+	folded, offsets := foldWidth(text)
+	matches := re.FindAllStringIndex(folded, -1)
+	if len(matches) == 0 {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		masked, ok := mask(folded[m[0]:m[1]])
+		if !ok {
+			continue
+		}
+		start, end := offsets[m[0]], offsets[m[1]]
+		b.WriteString(text[last:start])
+		b.WriteString(masked)
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// foldWidth は全角の英数字・記号と全角空白を半角にした文字列と、
+// その各バイト位置に対応する元の文字列のバイト位置（末尾の位置を含む）を返す
+func foldWidth(text string) (string, []int) {
+	var b strings.Builder
+	offsets := make([]int, 0, len(text)+1)
+	for i, r := range text {
+		switch {
+		case r >= '！' && r <= '～':
+			r -= '！' - '!'
+		case r == '　':
+			r = ' '
+		case r == '‐' || r == '−':
+			r = '-'
+		}
+		n, _ := b.WriteRune(r)
+		for j := 0; j < n; j++ {
+			offsets = append(offsets, i)
+		}
+	}
+	offsets = append(offsets, len(text))
+	return b.String(), offsets
+}
+
+func luhnValid(digits []byte) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
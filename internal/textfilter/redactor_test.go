@@ -0,0 +1,91 @@
+package textfilter
+
+import "testing"
+
+func TestRedactor_MasksContactDetailsAndCards(t *testing.T) {
+	r := NewRedactor(RedactionConfig{PhoneNumbers: true, Emails: true, CreditCards: true})
+
+	got, redacted := r.Redact("連絡は taro.yamada@example.com か 090-1234-5678 、カードは 4111 1111 1111 1111 です")
+	want := "連絡は [メールアドレス] か [電話番号] 、カードは [カード番号] です"
+	if !redacted || got != want {
+		t.Fatalf("unexpected redaction: %q", got)
+	}
+	if got, redacted := r.Redact("会議は 2026 年 3 月 1 日の 15 時からです"); redacted {
+		t.Fatalf("expected dates to be kept, got %q", got)
+	}
+}
+
+func TestRedactor_CardNumbersFailingChecksumAreNotCards(t *testing.T) {
+	r := NewRedactor(RedactionConfig{CreditCards: true})
+	if got, redacted := r.Redact("1234 5678 9012 3456"); redacted {
+		t.Fatalf("expected non-luhn number to be kept, got %q", got)
+	}
+}
+
+func TestRedactor_MasksBannedWordsCaseInsensitively(t *testing.T) {
+	r := NewRedactor(RedactionConfig{BannedWords: []string{"darn", " ばか "}})
+	got, _ := r.Redact("Darn, ばかだな")
+	if got != "＊＊＊＊, ＊＊だな" {
+		t.Fatalf("unexpected redaction: %q", got)
+	}
+}
+
+func TestNewRedactor_ReturnsNilWhenDisabled(t *testing.T) {
+	r := NewRedactor(RedactionConfig{BannedWords: []string{" "}})
+	if r != nil {
+		t.Fatal("expected nil redactor when nothing is configured")
+	}
+	if got, redacted := r.Redact("090-1234-5678"); redacted || got != "090-1234-5678" {
+		t.Fatalf("expected nil redactor to keep text, got %q", got)
+	}
+}
+
+func TestRedactor_MasksFullWidthWithoutNormalizing(t *testing.T) {
+	r := NewRedactor(RedactionConfig{PhoneNumbers: true, Emails: true, CreditCards: true, BannedWords: []string{"ＮＧ"}})
+
+	got, redacted := r.Redact("電話は ０９０－１２３４－５６７８、メールは ｔａｒｏ＠ｅｘａｍｐｌｅ．ｃｏｍ、カードは ４１１１　１１１１　１１１１　１１１１ です。ＮＧ と ng")
+	want := "電話は [電話番号]、メールは [メールアドレス]、カードは [カード番号] です。＊＊ と ＊＊"
+	if !redacted || got != want {
+		t.Fatalf("unexpected redaction: %q", got)
+	}
+	if got, _ := r.Redact("全角の ＡＢＣ １２３ はそのまま"); got != "全角の ＡＢＣ １２３ はそのまま" {
+		t.Fatalf("expected text without matches to keep its width, got %q", got)
+	}
+}
+
+func TestRedactor_DoesNotMergeSpaceSeparatedNumbers(t *testing.T) {
+	r := NewRedactor(RedactionConfig{PhoneNumbers: true, CreditCards: true})
+	for _, text := range []string{"2024 10 15 3 30 に集合", "１２ ３４５ ６７８９ ０１２"} {
+		if got, redacted := r.Redact(text); redacted {
+			t.Fatalf("expected %q to be kept, got %q", text, got)
+		}
+	}
+	if got, _ := r.Redact("番号は 09012345678 です"); got != "番号は [電話番号] です" {
+		t.Fatalf("unexpected redaction: %q", got)
+	}
+}
+
+func TestRedactor_MasksSeparatedPhoneNumbers(t *testing.T) {
+	r := NewRedactor(RedactionConfig{PhoneNumbers: true})
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "spaces", text: "番号は 090 1234 5678 です", want: "番号は [電話番号] です"},
+		{name: "area code in parentheses", text: "番号は (03) 1234-5678 です", want: "番号は [電話番号] です"},
+		{name: "international", text: "番号は +81 90 1234 5678 です", want: "番号は [電話番号] です"},
+		{name: "international with parentheses", text: "call +1 (415) 555-0134 now", want: "call [電話番号] now"},
+		{name: "dots", text: "番号は 03.1234.5678 です", want: "番号は [電話番号] です"},
+		{name: "full width", text: "番号は （０３）　１２３４－５６７８ です", want: "番号は [電話番号] です"},
+		{name: "too short", text: "内線は 03 1234 です", want: "内線は 03 1234 です"},
+		{name: "date with dots", text: "2026.03.01 10.30 に集合", want: "2026.03.01 10.30 に集合"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := r.Redact(tt.text); got != tt.want {
+				t.Fatalf("Redact(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package textfilter
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var ErrEmptyPattern = errors.New("replacement pattern is empty")
+
+type ReplacementRule struct {
+	Pattern     string
+	Replacement string
+	Regex       bool
+}
+
+type compiledRule struct {
+	rule ReplacementRule
+	re   *regexp.Regexp
+}
+
+// Replacer は登録順にルールを適用する。前のルールで置換した結果にも後のルールが適用される。
+type Replacer struct {
+	rules []compiledRule
+}
+
+func NewReplacer(rules []ReplacementRule) (*Replacer, error) {
+	compiled := make([]compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		c, err := compileRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, c)
+	}
+	return &Replacer{rules: compiled}, nil
+}
+
+func ValidateReplacementRule(rule ReplacementRule) error {
+	_, err := compileRule(rule)
+	return err
+}
+
+func compileRule(rule ReplacementRule) (compiledRule, error) {
+	if rule.Pattern == "" {
+		return compiledRule{}, ErrEmptyPattern
+	}
+	if !rule.Regex {
+		return compiledRule{rule: rule}, nil
+	}
+	re, err := regexp.Compile(rule.Pattern)
+	if err != nil {
+		return compiledRule{}, fmt.Errorf("compile replacement pattern %q: %w", rule.Pattern, err)
+	}
+	return compiledRule{rule: rule, re: re}, nil
+}
+
+func (r *Replacer) Apply(text string) string {
+	if r == nil {
+		return text
+	}
+	for _, c := range r.rules {
+		if c.re != nil {
+			text = c.re.ReplaceAllString(text, c.rule.Replacement)
+			continue
+		}
+		text = strings.ReplaceAll(text, c.rule.Pattern, c.rule.Replacement)
+	}
+	return text
+}
@@ -0,0 +1,40 @@
+package textfilter
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReplacer_AppliesLiteralAndRegexRulesInOrder(t *testing.T) {
+	r, err := NewReplacer([]ReplacementRule{
+		{Pattern: "もじおこしん", Replacement: "Mojiokoshin"},
+		{Pattern: `(\d+)じ`, Replacement: "${1}時", Regex: true},
+		{Pattern: "Mojiokoshin", Replacement: "Mojiokoshin Bot"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := r.Apply("もじおこしんは3じに起動します")
+	if got != "Mojiokoshin Botは3時に起動します" {
+		t.Fatalf("unexpected replaced text: %q", got)
+	}
+}
+
+func TestReplacer_NilReturnsTextUnchanged(t *testing.T) {
+	var r *Replacer
+	if got := r.Apply("hello"); got != "hello" {
+		t.Fatalf("unexpected text: %q", got)
+	}
+}
+
+func TestValidateReplacementRule(t *testing.T) {
+	if err := ValidateReplacementRule(ReplacementRule{Pattern: "("}); err != nil {
+		t.Fatalf("literal rule should not be compiled as regex: %v", err)
+	}
+	if err := ValidateReplacementRule(ReplacementRule{Pattern: "(", Regex: true}); err == nil {
+		t.Fatal("expected invalid regex to be rejected")
+	}
+	if err := ValidateReplacementRule(ReplacementRule{}); !errors.Is(err, ErrEmptyPattern) {
+		t.Fatalf("expected ErrEmptyPattern, got %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+package tracing
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SessionIDKey はスパンにセッションIDを付ける属性のキー
+const SessionIDKey = attribute.Key("session.id")
+
+func SessionID(sessionID string) attribute.KeyValue {
+	return SessionIDKey.String(sessionID)
+}
+
+// End は err があればスパンをエラーにしてから終了する
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
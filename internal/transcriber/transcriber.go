@@ -1,17 +1,65 @@
 package transcriber
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type StreamWriter interface {
 	Write(pcm []byte) error
 	Close() error
 }
 
+// Result のオフセットは StartStreaming 以降に Write された音声の先頭からの位置で、
+// 内部で接続を張り直しても連続した値になる。
+type Result struct {
+	SegmentIndex int
+	Text         string
+	IsFinal      bool
+	StartOffset  time.Duration
+	EndOffset    time.Duration
+	// Confidence はモデルが返さない場合 0 になる
+	Confidence float64
+	Words      []Word
+	// Language は複数の候補言語を指定したときに検出された言語で、返さない実装では空になる
+	Language string
+}
+
+type Word struct {
+	Text        string
+	StartOffset time.Duration
+	EndOffset   time.Duration
+	Confidence  float64
+}
+
 type ResultReceiver interface {
-	OnResult(segmentIndex int, text string, isFinal bool)
+	OnResult(result Result)
 	OnError(err error)
 }
 
+type StreamOptions struct {
+	Language string
+	// AlternativeLanguages は Language に加えて話されうる言語の候補で、
+	// 多言語の自動検出に対応しない実装では無視してよい
+	AlternativeLanguages []string
+	Model                string
+	// PhraseHints は認識されやすくしたい語句で、
+	// 語句の重み付けに対応しない実装ではプロンプトとして渡してよい
+	PhraseHints []string
+	Features    RecognitionFeatures
+	// GuildID はメトリクスのラベルに使う
+	GuildID string
+}
+
+// RecognitionFeatures に対応しない実装は無視してよい
+type RecognitionFeatures struct {
+	AutomaticPunctuation bool
+	SpokenPunctuation    bool
+	ProfanityFilter      bool
+}
+
 type Transcriber interface {
-	StartStreaming(ctx context.Context, sessionID, language string, receiver ResultReceiver) (StreamWriter, error)
+	StartStreaming(ctx context.Context, sessionID string, opts StreamOptions, receiver ResultReceiver) (StreamWriter, error)
+	// Ping は音声認識を使える状態かを確かめる
+	Ping(ctx context.Context) error
 }
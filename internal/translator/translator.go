@@ -0,0 +1,13 @@
+package translator
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrDisabled = errors.New("translation is disabled")
+
+type Translator interface {
+	// sourceLanguage が空の場合は翻訳元の言語を自動で判定する
+	Translate(ctx context.Context, text, sourceLanguage, targetLanguage string) (string, error)
+}
@@ -11,15 +11,41 @@ type TranscriptWebhookParticipant struct {
 }
 
 type TranscriptWebhookSegment struct {
-	Index      int    `json:"index"`
-	StartAt    string `json:"start_at"`
-	EndAt      string `json:"end_at"`
-	Transcript string `json:"transcript"`
+	Index        int                            `json:"index"`
+	StartAt      string                         `json:"start_at"`
+	EndAt        string                         `json:"end_at"`
+	Transcript   string                         `json:"transcript"`
+	Corrected    bool                           `json:"corrected"`
+	Confidence   *float64                       `json:"confidence"`
+	Words        []TranscriptWebhookWord        `json:"words,omitempty"`
+	Language     string                         `json:"language,omitempty"`
+	Translations []TranscriptWebhookTranslation `json:"translations,omitempty"`
+}
+
+type TranscriptWebhookSummary struct {
+	Summary     string   `json:"summary"`
+	Decisions   []string `json:"decisions"`
+	ActionItems []string `json:"action_items"`
+}
+
+type TranscriptWebhookTranslation struct {
+	Language string `json:"language"`
+	Text     string `json:"text"`
+}
+
+type TranscriptWebhookWord struct {
+	Word       string  `json:"word"`
+	StartAt    string  `json:"start_at"`
+	EndAt      string  `json:"end_at"`
+	Confidence float64 `json:"confidence"`
 }
 
 type TranscriptWebhookPayload struct {
 	SchemaVersion           string                         `json:"schema_version"`
 	SessionID               string                         `json:"session_id"`
+	Revision                int                            `json:"revision"`
+	IsRevision              bool                           `json:"is_revision"`
+	IsCorrection            bool                           `json:"is_correction"`
 	DiscordServerID         string                         `json:"discord_server_id"`
 	DiscordServerName       string                         `json:"discord_server_name"`
 	DiscordVoiceChannelID   string                         `json:"discord_voice_channel_id"`
@@ -28,11 +54,15 @@ type TranscriptWebhookPayload struct {
 	EndAt                   string                         `json:"end_at"`
 	Timezone                string                         `json:"timezone"`
 	DurationSeconds         int64                          `json:"duration_seconds"`
+	StartedByUserID         string                         `json:"started_by_user_id"`
+	StartTrigger            string                         `json:"start_trigger"`
+	StoppedByUserID         string                         `json:"stopped_by_user_id"`
 	Participants            []string                       `json:"participants"`
 	ParticipantDetails      []TranscriptWebhookParticipant `json:"participant_details"`
 	SegmentCount            int                            `json:"segment_count"`
 	TranscriptSegments      []TranscriptWebhookSegment     `json:"transcript_segments"`
 	Transcript              string                         `json:"transcript"`
+	Summary                 *TranscriptWebhookSummary      `json:"summary,omitempty"`
 }
 
 type Sender interface {